@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type addressCTX string
+
+const addressCtx addressCTX = "address"
+
+type createAddressPayload struct {
+	Label      string `json:"label" validate:"max=50"`
+	Recipient  string `json:"recipient" validate:"required,max=100"`
+	Phone      string `json:"phone" validate:"required,min=5,max=30"`
+	Line1      string `json:"line1" validate:"required,max=255"`
+	Line2      string `json:"line2" validate:"max=255"`
+	City       string `json:"city" validate:"required,max=100"`
+	Region     string `json:"region" validate:"max=100"`
+	PostalCode string `json:"postal_code" validate:"max=20"`
+	Country    string `json:"country" validate:"required,max=100"`
+	IsDefault  bool   `json:"is_default"`
+}
+
+// createAddressHandler godoc
+//
+//	@Summary		Add an address
+//	@Description	Adds an address to the authenticated user's address book. The first address becomes the default.
+//	@Tags			address
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		createAddressPayload	true	"Address Payload"
+//	@Success		201		{object}	store.Address			"Created Address"
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/addresses [post]
+func (app *Application) createAddressHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload createAddressPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	address := &store.Address{
+		UserID:     user.ID,
+		Label:      payload.Label,
+		Recipient:  payload.Recipient,
+		Phone:      payload.Phone,
+		Line1:      payload.Line1,
+		Line2:      payload.Line2,
+		City:       payload.City,
+		Region:     payload.Region,
+		PostalCode: payload.PostalCode,
+		Country:    payload.Country,
+		IsDefault:  payload.IsDefault,
+	}
+
+	if err := app.store.Addresses.Create(r.Context(), address); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, address); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getAddressesHandler godoc
+//
+//	@Summary		List addresses
+//	@Description	Lists the authenticated user's address book, default address first
+//	@Tags			address
+//	@Produce		json
+//	@Success		200	{array}		store.Address	"Addresses"
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/addresses [get]
+func (app *Application) getAddressesHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	addresses, err := app.store.Addresses.GetByUserID(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, addresses); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getAddressHandler godoc
+//
+//	@Summary		Get an address
+//	@Description	Get an address of the authenticated user by its ID
+//	@Tags			address
+//	@Produce		json
+//	@Param			addressID	path		int				true	"Address ID"
+//	@Success		200			{object}	store.Address	"Address"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/addresses/{addressID} [get]
+func (app *Application) getAddressHandler(w http.ResponseWriter, r *http.Request) {
+	address := getAddressFromContext(r)
+
+	if err := jsonResponse(w, http.StatusOK, address); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type updateAddressPayload struct {
+	Label      *string `json:"label" validate:"omitempty,max=50"`
+	Recipient  *string `json:"recipient" validate:"omitempty,min=1,max=100"`
+	Phone      *string `json:"phone" validate:"omitempty,min=5,max=30"`
+	Line1      *string `json:"line1" validate:"omitempty,min=1,max=255"`
+	Line2      *string `json:"line2" validate:"omitempty,max=255"`
+	City       *string `json:"city" validate:"omitempty,min=1,max=100"`
+	Region     *string `json:"region" validate:"omitempty,max=100"`
+	PostalCode *string `json:"postal_code" validate:"omitempty,max=20"`
+	Country    *string `json:"country" validate:"omitempty,min=1,max=100"`
+	IsDefault  *bool   `json:"is_default"`
+}
+
+// updateAddressHandler godoc
+//
+//	@Summary		Update an address
+//	@Description	Updates an address of the authenticated user. Orders already placed keep the address they were placed with.
+//	@Tags			address
+//	@Accept			json
+//	@Produce		json
+//	@Param			addressID	path		int						true	"Address ID"
+//	@Param			payload		body		updateAddressPayload	true	"Update Address Payload"
+//	@Success		200			{object}	store.Address			"Updated Address"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/addresses/{addressID} [patch]
+func (app *Application) updateAddressHandler(w http.ResponseWriter, r *http.Request) {
+	address := getAddressFromContext(r)
+
+	var payload updateAddressPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	if payload.Label != nil {
+		address.Label = *payload.Label
+	}
+	if payload.Recipient != nil {
+		address.Recipient = *payload.Recipient
+	}
+	if payload.Phone != nil {
+		address.Phone = *payload.Phone
+	}
+	if payload.Line1 != nil {
+		address.Line1 = *payload.Line1
+	}
+	if payload.Line2 != nil {
+		address.Line2 = *payload.Line2
+	}
+	if payload.City != nil {
+		address.City = *payload.City
+	}
+	if payload.Region != nil {
+		address.Region = *payload.Region
+	}
+	if payload.PostalCode != nil {
+		address.PostalCode = *payload.PostalCode
+	}
+	if payload.Country != nil {
+		address.Country = *payload.Country
+	}
+	// the default can only be moved to another address, not unset
+	if payload.IsDefault != nil && *payload.IsDefault {
+		address.IsDefault = true
+	}
+
+	if err := app.store.Addresses.Update(r.Context(), address); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, address); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// deleteAddressHandler godoc
+//
+//	@Summary		Delete an address
+//	@Description	Removes an address from the authenticated user's address book
+//	@Tags			address
+//	@Param			addressID	path	int	true	"Address ID"
+//	@Success		204			"Address deleted"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/addresses/{addressID} [delete]
+func (app *Application) deleteAddressHandler(w http.ResponseWriter, r *http.Request) {
+	address := getAddressFromContext(r)
+
+	if err := app.store.Addresses.Delete(r.Context(), address.UserID, address.ID); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *Application) addressContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromContext(r)
+
+		addressID, err := strconv.Atoi(chi.URLParam(r, "addressID"))
+		if err != nil {
+			app.notFoundError(w, r, err)
+			return
+		}
+		ctx := r.Context()
+		address, err := app.store.Addresses.GetByID(ctx, user.ID, addressID)
+		if err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		ctx = context.WithValue(ctx, addressCtx, address)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getAddressFromContext(r *http.Request) *store.Address {
+	address, _ := r.Context().Value(addressCtx).(*store.Address)
+	return address
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+)
+
+type adminOrderQuery struct {
+	Status        string `validate:"omitempty,oneof=pending processing shipped delivered cancelled returned failed refunded"`
+	PaymentMethod string `validate:"omitempty,oneof=cash_on_delivery Bkash credit_card"`
+	SortBy        string `validate:"omitempty,oneof=placed_at total_amount id"`
+	SortOrder     string `validate:"omitempty,oneof=asc desc"`
+	Format        string `validate:"omitempty,oneof=json csv"`
+}
+
+// listAdminOrdersHandler godoc
+//
+//	@Summary		List all orders
+//	@Description	Lists orders of every user with filters, sorting and cursor pagination. Pass format=csv to download every matching order as CSV.
+//	@Tags			order
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			status			query		string			false	"Order status"
+//	@Param			payment_method	query		string			false	"Payment method"
+//	@Param			user_id			query		int				false	"User ID"
+//	@Param			from			query		string			false	"Placed at or after, RFC3339 or YYYY-MM-DD"
+//	@Param			to				query		string			false	"Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)"
+//	@Param			min_total		query		number			false	"Minimum total amount"
+//	@Param			max_total		query		number			false	"Maximum total amount"
+//	@Param			sort_by			query		string			false	"placed_at (default), total_amount or id"
+//	@Param			sort_order		query		string			false	"asc or desc (default)"
+//	@Param			limit			query		int				false	"Page size, at most 500"
+//	@Param			cursor			query		string			false	"next_cursor of the previous page"
+//	@Param			format			query		string			false	"json (default) or csv"
+//	@Success		200				{object}	store.OrderPage	"Orders"
+//	@Failure		400				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/orders [get]
+func (app *Application) listAdminOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	params := adminOrderQuery{
+		Status:        q.Get("status"),
+		PaymentMethod: q.Get("payment_method"),
+		SortBy:        q.Get("sort_by"),
+		SortOrder:     q.Get("sort_order"),
+		Format:        q.Get("format"),
+	}
+	if err := validate.Struct(params); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	filters := store.OrderFilters{
+		Status:        params.Status,
+		PaymentMethod: params.PaymentMethod,
+		SortBy:        params.SortBy,
+		Descending:    params.SortOrder != "asc",
+		Cursor:        q.Get("cursor"),
+	}
+
+	var err error
+	if filters.UserID, err = parseIntParam(q.Get("user_id")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("user_id: %w", err))
+		return
+	}
+	if filters.Limit, err = parseIntParam(q.Get("limit")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("limit: %w", err))
+		return
+	}
+	if filters.PlacedFrom, _, err = parseDateParam(q.Get("from")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("from: %w", err))
+		return
+	}
+	var dateOnly bool
+	if filters.PlacedTo, dateOnly, err = parseDateParam(q.Get("to")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("to: %w", err))
+		return
+	}
+	if filters.PlacedTo != nil && dateOnly {
+		end := filters.PlacedTo.AddDate(0, 0, 1)
+		filters.PlacedTo = &end
+	}
+	if filters.MinTotal, err = parseFloatParam(q.Get("min_total")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("min_total: %w", err))
+		return
+	}
+	if filters.MaxTotal, err = parseFloatParam(q.Get("max_total")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("max_total: %w", err))
+		return
+	}
+
+	if params.Format == "csv" {
+		app.exportOrdersCSV(w, r, filters)
+		return
+	}
+
+	page, err := app.store.Orders.List(r.Context(), filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidCursor):
+			app.badRequestError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// exportOrdersCSV streams every order matching the filters, walking the
+// listing page by page so memory use stays flat however many orders match.
+func (app *Application) exportOrdersCSV(w http.ResponseWriter, r *http.Request, filters store.OrderFilters) {
+	ctx := r.Context()
+	filters.Limit = store.MaxOrderPageSize
+
+	// fetch the first page before writing anything so errors can still be
+	// reported with a proper status code
+	page, err := app.store.Orders.List(ctx, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidCursor):
+			app.badRequestError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	cw, err := csvResponse(w, "orders-"+time.Now().Format("20060102")+".csv", []string{
+		"id", "placed_at", "user_id", "status", "payment_method", "items", "copies",
+		"subtotal_amount", "discount_amount", "shipping_amount", "tax_amount", "total_amount", "refunded_amount",
+		"coupon_code", "shipping_region", "shipping_address",
+	})
+	for err == nil {
+		for _, order := range page.Orders {
+			var copies int
+			for _, item := range order.Items {
+				copies += item.Quantity
+			}
+			err = cw.Write([]string{
+				strconv.Itoa(order.ID),
+				order.PlacedAt.Format(time.RFC3339),
+				strconv.Itoa(order.UserID),
+				order.Status,
+				order.PaymentMethod,
+				strconv.Itoa(len(order.Items)),
+				strconv.Itoa(copies),
+				formatAmount(order.SubtotalAmount),
+				formatAmount(order.DiscountAmount),
+				formatAmount(order.ShippingAmount),
+				formatAmount(order.TaxAmount),
+				formatAmount(order.TotalAmount),
+				formatAmount(order.RefundedAmount),
+				order.CouponCode,
+				order.ShippingRegion,
+				order.ShippingAddress,
+			})
+			if err != nil {
+				break
+			}
+		}
+		if err != nil || page.NextCursor == "" {
+			break
+		}
+		filters.Cursor = page.NextCursor
+		page, err = app.store.Orders.List(ctx, filters)
+	}
+	cw.Flush()
+	if err == nil {
+		err = cw.Error()
+	}
+	if err != nil {
+		// the status line is already sent, all we can do is log
+		app.logger.Errorw("exporting orders", "path", r.URL.Path, "error", err.Error())
+	}
+}
+
+func parseIntParam(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a valid number", value)
+	}
+	return n, nil
+}
+
+func parseFloatParam(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid amount", value)
+	}
+	return &f, nil
+}
+
+// parseDateParam accepts RFC3339 timestamps and plain YYYY-MM-DD dates and
+// reports which of the two it got.
+func parseDateParam(value string) (*time.Time, bool, error) {
+	if value == "" {
+		return nil, false, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return &t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, false, fmt.Errorf("%q is not a valid date", value)
+	}
+	return &t, false, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/AmiyoKm/book_store/docs"
 	"github.com/AmiyoKm/book_store/internal/auth"
 	mailer "github.com/AmiyoKm/book_store/internal/mail"
+	"github.com/AmiyoKm/book_store/internal/payment"
 	"github.com/AmiyoKm/book_store/internal/store"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,21 +18,26 @@ import (
 )
 
 type Application struct {
-	cfg    Config
-	logger *zap.SugaredLogger
-	store  store.Storage
-	mail   mailer.Client
-	auth   auth.Authenticator
+	cfg      Config
+	logger   *zap.SugaredLogger
+	store    store.Storage
+	mail     mailer.Client
+	auth     auth.Authenticator
+	payments map[string]payment.Provider
 }
 
 type Config struct {
-	addr        string
-	auth        authConfig
-	apiUrl      string
-	env         string
-	db          DbConfig
-	mail        MailConfig
-	frontendURL string
+	addr               string
+	auth               authConfig
+	apiUrl             string
+	env                string
+	db                 DbConfig
+	mail               MailConfig
+	frontendURL        string
+	idempotencyTTL     time.Duration
+	cartReservationTTL time.Duration
+	searchLanguage     string
+	payment            paymentConfig
 }
 type authConfig struct {
 	basic basicConfig
@@ -54,6 +60,12 @@ type DbConfig struct {
 	maxIdleTime string
 }
 
+type paymentConfig struct {
+	provider          string
+	currency          string
+	fakeWebhookSecret string
+}
+
 type MailConfig struct {
 	apiKey    string
 	fromEmail string
@@ -67,8 +79,8 @@ func (app *Application) mount() http.Handler {
 		AllowedOrigins: []string{"https://*", "http://*"},
 		// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Link", "Idempotent-Replayed"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
@@ -86,7 +98,7 @@ func (app *Application) mount() http.Handler {
 		r.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(docsURL)))
 
 		r.Route("/authentication", func(r chi.Router) {
-			r.Post("/user", app.createUserHandler)
+			r.With(app.idempotencyMiddleware).Post("/user", app.createUserHandler)
 			r.Post("/token", app.createTokenHandler)
 			r.Put("/activate/{token}", app.activateUserHandler)
 		})
@@ -97,9 +109,23 @@ func (app *Application) mount() http.Handler {
 			r.Route("/me", func(r chi.Router) {
 				r.Get("/", app.getUserHandler)
 				r.Patch("/", app.updateUserHandler)
+
+				r.Route("/addresses", func(r chi.Router) {
+					r.Get("/", app.getAddressesHandler)
+					r.Post("/", app.createAddressHandler)
+
+					r.Route("/{addressID}", func(r chi.Router) {
+						r.Use(app.addressContextMiddleware)
+
+						r.Get("/", app.getAddressHandler)
+						r.Patch("/", app.updateAddressHandler)
+						r.Delete("/", app.deleteAddressHandler)
+					})
+				})
 			})
 
 			r.Get("/{userID}", app.getUserByIDHandler)
+			r.Get("/{userID}/wishlists", app.getPublicWishlistsHandler)
 
 		})
 		r.Route("/password", func(r chi.Router) {
@@ -121,6 +147,9 @@ func (app *Application) mount() http.Handler {
 				r.Patch("/", app.checkBookManipulationAuthority("moderator", app.updateBookHandler))
 				r.Delete("/", app.checkBookManipulationAuthority("moderator", app.deleteBookHandler))
 
+				r.Post("/stock-subscription", app.subscribeStockHandler)
+				r.Delete("/stock-subscription", app.unsubscribeStockHandler)
+
 				r.Route("/reviews", func(r chi.Router) {
 					r.Get("/", app.getAllReviewsHandler)
 					r.Post("/", app.createReviewHandler)
@@ -135,21 +164,51 @@ func (app *Application) mount() http.Handler {
 			})
 
 			r.Get("/search", app.getBooksBySearchHandler)
+			r.Get("/suggest", app.suggestBooksHandler)
 		})
 		r.Route("/wishlist", func(r chi.Router) {
 			r.Use(app.AuthTokenMiddleware)
+			r.Use(app.defaultWishlistMiddleware)
 
 			r.Get("/", app.getWishlistHandler)
 			r.Post("/", app.addToWishlistHandler)
+			r.Patch("/{bookID}", app.updateWishlistItemHandler)
 			r.Delete("/{bookID}", app.removeFromWishlistHandler)
 		})
 
+		r.Route("/wishlists", func(r chi.Router) {
+			r.Get("/shared/{token}", app.getSharedWishlistHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware)
+
+				r.Get("/", app.listWishlistsHandler)
+				r.Post("/", app.createWishlistHandler)
+
+				r.Route("/{wishlistID}", func(r chi.Router) {
+					r.Use(app.wishlistContextMiddleware)
+
+					r.Get("/", app.getWishlistByIDHandler)
+					r.Patch("/", app.updateWishlistHandler)
+					r.Delete("/", app.deleteWishlistHandler)
+					r.With(app.idempotencyMiddleware).Post("/move-to-cart", app.moveWishlistToCartHandler)
+
+					r.Route("/items", func(r chi.Router) {
+						r.Post("/", app.addToWishlistHandler)
+						r.Patch("/{bookID}", app.updateWishlistItemHandler)
+						r.Delete("/{bookID}", app.removeFromWishlistHandler)
+					})
+				})
+			})
+		})
+
 		r.Route("/carts", func(r chi.Router) {
 			r.Use(app.AuthTokenMiddleware)
 
-			r.Post("/", app.addToCartHandler)
+			r.With(app.idempotencyMiddleware).Post("/", app.addToCartHandler)
 			r.Get("/", app.getCartHandler)
 			r.Delete("/", app.deleteCartHandler)
+			r.With(app.idempotencyMiddleware).Post("/checkout", app.checkoutCartHandler)
 			r.Route("/items/{itemID}", func(r chi.Router) {
 				r.Use(app.itemContextMiddleware)
 
@@ -162,7 +221,8 @@ func (app *Application) mount() http.Handler {
 		r.Route("/orders", func(r chi.Router) {
 			r.Use(app.AuthTokenMiddleware)
 
-			r.Post("/", app.createOrderHandler)
+			r.With(app.idempotencyMiddleware).Post("/", app.createOrderHandler)
+			r.Post("/quote", app.quoteOrderHandler)
 			r.Get("/", app.getAllOrdersHandler)
 
 			r.Route("/{orderID}", func(r chi.Router) {
@@ -170,18 +230,142 @@ func (app *Application) mount() http.Handler {
 				r.Get("/", app.getOrderHandler)
 
 				r.Patch("/", app.updateOderHandler)
+				r.Get("/history", app.getOrderHistoryHandler)
+				r.Post("/cancel", app.cancelOrderHandler)
+				r.Get("/shipments", app.getShipmentsHandler)
+				r.Get("/invoice.pdf", app.getInvoiceHandler)
+
+				r.Route("/returns", func(r chi.Router) {
+					r.Get("/", app.getOrderReturnsHandler)
+					r.Post("/", app.createReturnHandler)
+				})
+
+				r.Route("/payments", func(r chi.Router) {
+					r.Get("/", app.getPaymentsHandler)
+					if app.paymentsEnabled() {
+						r.Post("/", app.createPaymentHandler)
+					}
+				})
 			})
 		})
+
+		if len(app.payments) > 0 {
+			r.Post("/payments/webhooks/{provider}", app.paymentWebhookHandler)
+		}
+
 		r.Route("/admin", func(r chi.Router) {
 			r.Use(app.AuthTokenMiddleware)
 			r.Use(app.adminCheck)
 
 			r.Route("/orders", func(r chi.Router) {
+				r.Get("/", app.listAdminOrdersHandler)
 
 				r.Route("/{orderID}", func(r chi.Router) {
 					r.Use(app.orderContextMiddleware)
 
 					r.Patch("/", app.updateAdminOrderHandler)
+
+					r.Route("/refunds", func(r chi.Router) {
+						r.Get("/", app.getRefundsHandler)
+						r.Post("/", app.createRefundHandler)
+					})
+					if app.paymentsEnabled() {
+						r.Post("/payments/capture", app.capturePaymentHandler)
+					}
+
+					r.Get("/packing-slip.pdf", app.getPackingSlipHandler)
+
+					r.Route("/shipments", func(r chi.Router) {
+						r.Get("/", app.getShipmentsHandler)
+						r.Post("/", app.createShipmentHandler)
+						r.Post("/{shipmentID}/deliver", app.deliverShipmentHandler)
+					})
+				})
+			})
+
+			r.Post("/books/import", app.importBooksHandler)
+			r.Route("/books/{bookID}", func(r chi.Router) {
+				r.Use(app.bookContextMiddleware)
+
+				r.Get("/", app.getAdminBookHandler)
+				r.Post("/stock-adjustments", app.createStockAdjustmentHandler)
+				r.Get("/stock-movements", app.getStockMovementsHandler)
+
+				r.Route("/transfers", func(r chi.Router) {
+					r.Get("/", app.getStockTransfersHandler)
+					r.Post("/", app.createStockTransferHandler)
+				})
+			})
+
+			r.Route("/locations", func(r chi.Router) {
+				r.Get("/", app.listLocationsHandler)
+				r.Post("/", app.createLocationHandler)
+
+				r.Route("/{locationID}", func(r chi.Router) {
+					r.Use(app.locationContextMiddleware)
+
+					r.Get("/", app.getLocationHandler)
+					r.Patch("/", app.updateLocationHandler)
+				})
+			})
+
+			r.Route("/reports", func(r chi.Router) {
+				r.Get("/revenue", app.getRevenueReportHandler)
+				r.Get("/top-books", app.getTopBooksReportHandler)
+				r.Get("/top-authors", app.getTopAuthorsReportHandler)
+				r.Get("/order-value", app.getOrderValueReportHandler)
+				r.Get("/status", app.getStatusReportHandler)
+				r.Get("/low-stock", app.getLowStockReportHandler)
+			})
+
+			r.Route("/returns", func(r chi.Router) {
+				r.Get("/", app.listReturnsHandler)
+
+				r.Route("/{returnID}", func(r chi.Router) {
+					r.Use(app.returnContextMiddleware)
+
+					r.Get("/", app.getReturnHandler)
+					r.Patch("/", app.reviewReturnHandler)
+					r.Post("/receive", app.receiveReturnHandler)
+				})
+			})
+
+			r.Route("/shipping-rates", func(r chi.Router) {
+				r.Get("/", app.listShippingRatesHandler)
+				r.Post("/", app.createShippingRateHandler)
+
+				r.Route("/{shippingRateID}", func(r chi.Router) {
+					r.Use(app.shippingRateContextMiddleware)
+
+					r.Get("/", app.getShippingRateHandler)
+					r.Patch("/", app.updateShippingRateHandler)
+					r.Delete("/", app.deleteShippingRateHandler)
+				})
+			})
+
+			r.Route("/tax-rates", func(r chi.Router) {
+				r.Get("/", app.listTaxRatesHandler)
+				r.Post("/", app.createTaxRateHandler)
+
+				r.Route("/{taxRateID}", func(r chi.Router) {
+					r.Use(app.taxRateContextMiddleware)
+
+					r.Get("/", app.getTaxRateHandler)
+					r.Patch("/", app.updateTaxRateHandler)
+					r.Delete("/", app.deleteTaxRateHandler)
+				})
+			})
+
+			r.Route("/promotions", func(r chi.Router) {
+				r.Get("/", app.listPromotionsHandler)
+				r.Post("/", app.createPromotionHandler)
+
+				r.Route("/{promotionID}", func(r chi.Router) {
+					r.Use(app.promotionContextMiddleware)
+
+					r.Get("/", app.getPromotionHandler)
+					r.Patch("/", app.updatePromotionHandler)
+					r.Delete("/", app.deletePromotionHandler)
 				})
 			})
 		})
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+)
+
+const (
+	maxImportBytes = 10 << 20
+	maxImportRows  = 10_000
+
+	// importTagSeparator separates the tags in the tags column of a CSV import
+	importTagSeparator = "|"
+)
+
+// bookImportColumns are the columns a CSV import may have, named like the
+// fields of createBookPayload.
+var bookImportColumns = []string{"title", "author", "isbn", "price", "tags", "description", "cover_image_url", "pages", "stock"}
+
+var errTooManyImportRows = fmt.Errorf("an import can have at most %d rows", maxImportRows)
+
+type BookImportReport struct {
+	DryRun  bool                   `json:"dry_run"`
+	Created int                    `json:"created"`
+	Updated int                    `json:"updated"`
+	Failed  int                    `json:"failed"`
+	Rows    []*store.BookImportRow `json:"rows"`
+}
+
+// importBooksHandler godoc
+//
+//	@Summary		Import books
+//	@Description	Creates or updates books in bulk from a CSV file with a header row or from NDJSON, one createBookPayload object per line. Columns and fields are named like those of createBookPayload and validated the same way; CSV tags are separated by |. Books are matched by ISBN: new ones are created, existing ones updated and their stock set to the imported level. The format comes from the format parameter or the Content-Type (text/csv or application/x-ndjson). Rows that fail are reported and do not stop the others. Rows are committed batch_size at a time; dry_run reports what would change without writing anything.
+//	@Tags			book
+//	@Accept			text/csv
+//	@Accept			application/x-ndjson
+//	@Produce		json
+//	@Param			format		query		string				false	"csv or ndjson"
+//	@Param			batch_size	query		int					false	"Rows per transaction, defaults to 500"
+//	@Param			dry_run		query		boolean				false	"Report what would change without writing"
+//	@Success		200			{object}	BookImportReport	"Per-row report"
+//	@Failure		400			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/books/import [post]
+func (app *Application) importBooksHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+	batchSize, err := parseIntParam(q.Get("batch_size"))
+	if err != nil {
+		app.badRequestError(w, r, fmt.Errorf("batch_size: %w", err))
+		return
+	}
+	dryRun := q.Get("dry_run") == "true"
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var rows []*store.BookImportRow
+	switch format {
+	case "csv":
+		rows, err = readCSVImport(r.Body)
+	case "ndjson":
+		rows, err = readNDJSONImport(r.Body)
+	default:
+		err = errors.New("send text/csv or application/x-ndjson, or pass format=csv or format=ndjson")
+	}
+	if err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	opts := store.BookImportOptions{BatchSize: batchSize, DryRun: dryRun, ActorID: user.ID}
+	if err := app.store.Books.Import(r.Context(), rows, opts); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	report := BookImportReport{DryRun: dryRun, Rows: rows}
+	for _, row := range rows {
+		switch row.Status {
+		case store.ImportCreated:
+			report.Created++
+		case store.ImportUpdated:
+			report.Updated++
+		case store.ImportFailed:
+			report.Failed++
+		}
+	}
+	if err := jsonResponse(w, http.StatusOK, report); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return "csv"
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return "ndjson"
+	default:
+		return ""
+	}
+}
+
+// readCSVImport reads the rows of a CSV import. Columns may come in any
+// order and missing ones are empty.
+func readCSVImport(body io.Reader) ([]*store.BookImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []*store.BookImportRow{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(bookImportColumns, name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns[name] = i
+	}
+
+	rows := []*store.BookImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == maxImportRows {
+			return nil, errTooManyImportRows
+		}
+		line, _ := reader.FieldPos(0)
+
+		get := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		payload := createBookPayload{
+			Title:         get("title"),
+			Author:        get("author"),
+			ISBN:          get("isbn"),
+			Description:   get("description"),
+			CoverImageUrl: get("cover_image_url"),
+		}
+		for _, tag := range strings.Split(get("tags"), importTagSeparator) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				payload.Tags = append(payload.Tags, tag)
+			}
+		}
+
+		var fieldErr error
+		if v := get("price"); v != "" {
+			price, err := strconv.ParseFloat(v, 32)
+			if err != nil {
+				fieldErr = errors.Join(fieldErr, fmt.Errorf("price: %w", err))
+			}
+			payload.Price = float32(price)
+		}
+		if v := get("pages"); v != "" {
+			if payload.Pages, err = strconv.Atoi(v); err != nil {
+				fieldErr = errors.Join(fieldErr, fmt.Errorf("pages: %w", err))
+			}
+		}
+		if v := get("stock"); v != "" {
+			if payload.Stock, err = strconv.Atoi(v); err != nil {
+				fieldErr = errors.Join(fieldErr, fmt.Errorf("stock: %w", err))
+			}
+		}
+		rows = append(rows, newBookImportRow(line, payload, fieldErr))
+	}
+	return rows, nil
+}
+
+// readNDJSONImport reads the rows of an NDJSON import, skipping blank lines.
+func readNDJSONImport(body io.Reader) ([]*store.BookImportRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxImportBytes)
+
+	rows := []*store.BookImportRow{}
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, errTooManyImportRows
+		}
+
+		var payload createBookPayload
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&payload)
+		rows = append(rows, newBookImportRow(line, payload, err))
+	}
+	return rows, scanner.Err()
+}
+
+// newBookImportRow validates a row like createBookHandler validates its
+// payload. Invalid rows are marked failed and never reach the store.
+func newBookImportRow(line int, payload createBookPayload, parseErr error) *store.BookImportRow {
+	row := &store.BookImportRow{Line: line, ISBN: payload.ISBN}
+	if parseErr == nil {
+		parseErr = validate.Struct(payload)
+	}
+	if parseErr != nil {
+		row.Status = store.ImportFailed
+		row.Error = parseErr.Error()
+		return row
+	}
+	row.Book = &store.Book{
+		Title:         payload.Title,
+		Author:        payload.Author,
+		ISBN:          payload.ISBN,
+		Price:         payload.Price,
+		Tags:          payload.Tags,
+		Description:   payload.Description,
+		CoverImageUrl: payload.CoverImageUrl,
+		Pages:         payload.Pages,
+		Stock:         payload.Stock,
+	}
+	return row
+}
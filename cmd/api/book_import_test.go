@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+)
+
+const (
+	importCSVHeader = "title,author,isbn,price,tags,description,cover_image_url,pages,stock\n"
+	importCSVRow    = "Dune,Frank Herbert,9780441013593,9.99,sci-fi|classic,Spice,https://example.com/dune.jpg,412,3\n"
+	importJSONRow   = `{"title":"Dune","author":"Frank Herbert","isbn":"9780441013593","price":9.99,"tags":["sci-fi"],"cover_image_url":"https://example.com/dune.jpg","pages":412,"stock":3}`
+)
+
+func TestReadCSVImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus []string
+		wantLines  []int
+		wantErrors []string
+	}{
+		{
+			name:       "valid row",
+			body:       importCSVHeader + importCSVRow,
+			wantStatus: []string{""},
+			wantLines:  []int{2},
+		},
+		{
+			name:       "byte order mark and reordered columns",
+			body:       "\ufeffISBN, Title ,author,price,pages,stock,cover_image_url\n9780441013593,Dune,Frank Herbert,9.99,412,3,https://example.com/dune.jpg\n",
+			wantStatus: []string{""},
+			wantLines:  []int{2},
+		},
+		{
+			name:       "field errors fail the row",
+			body:       importCSVHeader + "Dune,Frank Herbert,9780441013593,cheap,,,https://example.com/dune.jpg,many,3\n" + importCSVRow,
+			wantStatus: []string{store.ImportFailed, ""},
+			wantLines:  []int{2, 3},
+			wantErrors: []string{"price", "pages"},
+		},
+		{
+			name:       "invalid row fails validation",
+			body:       importCSVHeader + "Dune,Frank Herbert,123,9.99,,,https://example.com/dune.jpg,412,3\n",
+			wantStatus: []string{store.ImportFailed},
+			wantLines:  []int{2},
+			wantErrors: []string{"ISBN"},
+		},
+		{
+			name:       "missing fields are empty",
+			body:       importCSVHeader + "Dune,Frank Herbert\n",
+			wantStatus: []string{store.ImportFailed},
+			wantLines:  []int{2},
+		},
+		{
+			name: "empty body",
+			body: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readCSVImport(strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("readCSVImport: %v", err)
+			}
+			checkImportRows(t, rows, tt.wantStatus, tt.wantLines, tt.wantErrors)
+		})
+	}
+}
+
+func TestReadCSVImportBook(t *testing.T) {
+	rows, err := readCSVImport(strings.NewReader(importCSVHeader + importCSVRow))
+	if err != nil {
+		t.Fatalf("readCSVImport: %v", err)
+	}
+	book := rows[0].Book
+	if book == nil {
+		t.Fatalf("row failed: %s", rows[0].Error)
+	}
+	if book.Title != "Dune" || book.ISBN != "9780441013593" || book.Price != 9.99 || book.Pages != 412 || book.Stock != 3 {
+		t.Errorf("unexpected book %+v", book)
+	}
+	if !slices.Equal(book.Tags, []string{"sci-fi", "classic"}) {
+		t.Errorf("tags = %q, want [sci-fi classic]", book.Tags)
+	}
+	if rows[0].ISBN != book.ISBN {
+		t.Errorf("row ISBN = %q, want %q", rows[0].ISBN, book.ISBN)
+	}
+}
+
+func TestReadCSVImportRejectsFile(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"unknown column", "title,author,publisher\nDune,Frank Herbert,Chilton\n", `unknown column "publisher"`},
+		{"too many rows", importCSVHeader + strings.Repeat(importCSVRow, maxImportRows+1), errTooManyImportRows.Error()},
+		{"malformed CSV", importCSVHeader + "\"Dune,Frank Herbert\n", "quote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readCSVImport(strings.NewReader(tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("readCSVImport error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVImportRowCap(t *testing.T) {
+	rows, err := readCSVImport(strings.NewReader(importCSVHeader + strings.Repeat(importCSVRow, maxImportRows)))
+	if err != nil {
+		t.Fatalf("readCSVImport: %v", err)
+	}
+	if len(rows) != maxImportRows {
+		t.Errorf("got %d rows, want %d", len(rows), maxImportRows)
+	}
+}
+
+func TestReadNDJSONImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus []string
+		wantLines  []int
+		wantErrors []string
+	}{
+		{
+			name:       "blank lines are skipped",
+			body:       importJSONRow + "\n\n  \n" + importJSONRow + "\n",
+			wantStatus: []string{"", ""},
+			wantLines:  []int{1, 4},
+		},
+		{
+			name:       "unknown field fails the row",
+			body:       `{"title":"Dune","publisher":"Chilton"}` + "\n" + importJSONRow,
+			wantStatus: []string{store.ImportFailed, ""},
+			wantLines:  []int{1, 2},
+			wantErrors: []string{"publisher"},
+		},
+		{
+			name:       "malformed JSON fails the row",
+			body:       `{"title":` + "\n" + importJSONRow,
+			wantStatus: []string{store.ImportFailed, ""},
+			wantLines:  []int{1, 2},
+		},
+		{
+			name:       "invalid row fails validation",
+			body:       `{"title":"Dune","author":"Frank Herbert","isbn":"9780441013593","price":9.99,"cover_image_url":"https://example.com/dune.jpg","pages":0,"stock":3}`,
+			wantStatus: []string{store.ImportFailed},
+			wantLines:  []int{1},
+			wantErrors: []string{"Pages"},
+		},
+		{
+			name: "empty body",
+			body: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readNDJSONImport(strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("readNDJSONImport: %v", err)
+			}
+			checkImportRows(t, rows, tt.wantStatus, tt.wantLines, tt.wantErrors)
+		})
+	}
+}
+
+func TestReadNDJSONImportRowCap(t *testing.T) {
+	body := strings.Repeat(importJSONRow+"\n", maxImportRows)
+	if _, err := readNDJSONImport(strings.NewReader(body)); err != nil {
+		t.Fatalf("readNDJSONImport with %d rows: %v", maxImportRows, err)
+	}
+	_, err := readNDJSONImport(strings.NewReader(body + importJSONRow))
+	if !errors.Is(err, errTooManyImportRows) {
+		t.Errorf("readNDJSONImport with %d rows error = %v, want %v", maxImportRows+1, err, errTooManyImportRows)
+	}
+}
+
+// checkImportRows compares the rows' statuses and lines with the expected
+// ones. Every expected error must show up in one of the failed rows.
+func checkImportRows(t *testing.T, rows []*store.BookImportRow, wantStatus []string, wantLines []int, wantErrors []string) {
+	t.Helper()
+
+	if len(rows) != len(wantStatus) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(wantStatus))
+	}
+	var errs []string
+	for i, row := range rows {
+		if row.Status != wantStatus[i] {
+			t.Errorf("row %d status = %q, want %q (%s)", i, row.Status, wantStatus[i], row.Error)
+		}
+		if row.Line != wantLines[i] {
+			t.Errorf("row %d line = %d, want %d", i, row.Line, wantLines[i])
+		}
+		if row.Status == store.ImportFailed {
+			if row.Book != nil {
+				t.Errorf("row %d failed but has a book", i)
+			}
+			errs = append(errs, row.Error)
+		} else if row.Book == nil {
+			t.Errorf("row %d has no book", i)
+		}
+	}
+	for _, want := range wantErrors {
+		if !strings.Contains(strings.Join(errs, "\n"), want) {
+			t.Errorf("errors %s do not mention %q", fmt.Sprint(errs), want)
+		}
+	}
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/AmiyoKm/book_store/internal/store"
 	"github.com/go-chi/chi/v5"
@@ -36,6 +38,7 @@ type createBookPayload struct {
 //	@Param			payload	body		createBookPayload	true	"Book details"
 //	@Success		201		{object}	store.Book			"Book created"
 //	@Failure		400		{object}	error
+//	@Failure		409		{object}	error	"A book with this ISBN already exists"
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/books [post]
@@ -66,7 +69,12 @@ func (app *Application) createBookHandler(w http.ResponseWriter, r *http.Request
 	err := app.store.Books.Create(ctx, book)
 
 	if err != nil {
-		app.internalServerError(w, r, err)
+		switch err {
+		case store.ErrDuplicateISBN:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
 		return
 	}
 
@@ -123,6 +131,7 @@ type updateBookPayload struct {
 //	@Failure		400		{object}	error
 //	@Failure		401		{object}	error
 //	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"Duplicate ISBN or negative stock"
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/books/{id} [patch]
@@ -166,21 +175,28 @@ func (app *Application) updateBookHandler(w http.ResponseWriter, r *http.Request
 	if payload.Pages != nil {
 		book.Pages = *payload.Pages
 	}
+
+	var err error
 	if payload.Stock != nil {
-		book.Stock = *payload.Stock
+		// a new stock level is booked as an adjustment so the ledger explains it
+		movement := &store.StockMovement{
+			Reason:  "stock set on book update",
+			ActorID: getUserFromContext(r).ID,
+		}
+		err = app.store.Books.UpdateWithStock(ctx, book, movement, *payload.Stock)
+	} else {
+		err = app.store.Books.Update(ctx, book)
 	}
-
-	err := app.store.Books.Update(ctx, book)
-
 	if err != nil {
-		switch err {
-		case store.ErrorNotFound:
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
 			app.notFoundError(w, r, err)
-			return
+		case errors.Is(err, store.ErrNegativeStock), errors.Is(err, store.ErrDuplicateISBN):
+			app.conflictError(w, r, err)
 		default:
 			app.internalServerError(w, r, err)
-			return
 		}
+		return
 	}
 
 	if err := jsonResponse(w, http.StatusCreated, book); err != nil {
@@ -221,63 +237,157 @@ func (app *Application) deleteBookHandler(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusNoContent)
 }
 
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 20
+)
+
+type bookSearchQuery struct {
+	Sort  string `validate:"omitempty,oneof=relevance price newest rating title"`
+	Order string `validate:"omitempty,oneof=asc desc"`
+}
+
 // getBooksBySearchHandler godoc
 //
 //	@Summary		Search books
-//	@Description	Search for books using a general query or specific filters such as title, author, tags, price range, and stock status.
+//	@Description	Searches books by a free-text query and filters. Results come in pages, ranked by relevance or by the chosen sort.
 //	@Tags			book
 //	@Accept			json
 //	@Produce		json
-//	@Param			query		query		string		false	"Free-text search query"
+//	@Param			query		query		string		false	"Free-text query in web search syntax: quoted phrases, or, -exclude. Matches in the description come back as a snippet wrapped in <mark> tags"
 //	@Param			title		query		string		false	"Filter by book title"
 //	@Param			author		query		string		false	"Filter by author name"
 //	@Param			tag			query		[]string	false	"Filter by tags"
 //	@Param			min_price	query		number		false	"Minimum price filter"
 //	@Param			max_price	query		number		false	"Maximum price filter"
 //	@Param			in_stock	query		boolean		false	"Filter by stock status (true for in-stock, false for out-of-stock)"
-//	@Success		200			{array}		store.Book
+//	@Param			fuzzy		query		boolean		false	"Match the query by similarity to titles and authors. A query finding fewer than 3 books falls back to this by itself, flags the page as fuzzy and suggests a correction in did_you_mean; pass fuzzy=true for its next pages"
+//	@Param			sort		query		string		false	"relevance (default with a query), price, newest, rating or title (default without a query)"
+//	@Param			order		query		string		false	"asc or desc; defaults to best match, lowest price, newest and best rated first, titles A to Z"
+//	@Param			limit		query		int			false	"Page size, at most 100"
+//	@Param			cursor		query		string		false	"next_cursor of the previous page, sent with the same filters and sort"
+//	@Param			facets		query		boolean		false	"Also count the matching books per tag, author, price range, average rating and availability"
+//	@Success		200			{object}	store.BookSearchPage
+//	@Failure		400			{object}	error
 //	@Failure		500			{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/books/search [get]
 func (app *Application) getBooksBySearchHandler(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
+	params := bookSearchQuery{
+		Sort:  q.Get("sort"),
+		Order: q.Get("order"),
+	}
+	if err := validate.Struct(params); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
 	filters := store.BooksBySearchPayload{
 		Query:  q.Get("query"),
 		Title:  q.Get("title"),
 		Author: q.Get("author"),
 		Tags:   q["tag"],
+		Sort:   params.Sort,
+		Order:  params.Order,
+		Cursor: q.Get("cursor"),
 	}
 
 	if min := q.Get("min_price"); min != "" {
-		if v, err := strconv.ParseFloat(min, 32); err != nil {
-			filters.MinPrice = float32(v)
+		v, err := strconv.ParseFloat(min, 32)
+		if err != nil {
+			app.badRequestError(w, r, fmt.Errorf("min_price: %w", err))
+			return
 		}
+		filters.MinPrice = float32(v)
 	}
 
 	if max := q.Get("max_price"); max != "" {
-		if v, err := strconv.ParseFloat(max, 64); err != nil {
-			filters.MaxPrice = float32(v)
+		v, err := strconv.ParseFloat(max, 32)
+		if err != nil {
+			app.badRequestError(w, r, fmt.Errorf("max_price: %w", err))
+			return
 		}
+		filters.MaxPrice = float32(v)
 	}
 	if stock := q.Get("in_stock"); stock != "" {
 		inStock := stock == "true"
 		filters.InStock = &inStock
 	}
+	filters.Fuzzy = q.Get("fuzzy") == "true"
+	var err error
+	if filters.Limit, err = parseIntParam(q.Get("limit")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("limit: %w", err))
+		return
+	}
 	ctx := r.Context()
-	books, err := app.store.Books.SearchByBooks(ctx, filters)
+	page, err := app.store.Books.SearchByBooks(ctx, filters)
 
 	if err != nil {
-		app.internalServerError(w, r, err)
+		switch {
+		case errors.Is(err, store.ErrInvalidCursor):
+			app.badRequestError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
 		return
 	}
+	if q.Get("facets") == "true" {
+		filters.Fuzzy = page.Fuzzy
+		if page.Facets, err = app.store.Books.SearchFacets(ctx, filters); err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
 
-	if err := jsonResponse(w, http.StatusOK, books); err != nil {
+	if err := jsonResponse(w, http.StatusOK, page); err != nil {
 		app.internalServerError(w, r, err)
 	}
 
 }
 
+// suggestBooksHandler godoc
+//
+//	@Summary		Autocomplete titles and authors
+//	@Description	Suggests titles and authors for what the user has typed so far. Those starting with the text come first; similar ones are included so that typos still find something.
+//	@Tags			book
+//	@Produce		json
+//	@Param			q		query		string	true	"Text typed so far"
+//	@Param			limit	query		int		false	"Number of suggestions, at most 20"
+//	@Success		200		{array}		store.BookSuggestion
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/books/suggest [get]
+func (app *Application) suggestBooksHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	text := strings.TrimSpace(q.Get("q"))
+	if text == "" {
+		app.badRequestError(w, r, errors.New("q is required"))
+		return
+	}
+	limit, err := parseIntParam(q.Get("limit"))
+	if err != nil {
+		app.badRequestError(w, r, fmt.Errorf("limit: %w", err))
+		return
+	}
+	if limit == 0 {
+		limit = defaultSuggestLimit
+	}
+	limit = min(limit, maxSuggestLimit)
+
+	suggestions, err := app.store.Books.Suggest(r.Context(), text, limit)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, suggestions); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
 func (app *Application) bookContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		paramID := chi.URLParam(r, "bookID")
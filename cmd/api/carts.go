@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -25,13 +26,15 @@ type CartResponse struct {
 // addToCartHandler godoc
 //
 //	@Summary		Add book to cart
-//	@Description	Add book to cart
+//	@Description	Add book to cart. The cart's copies of the book are reserved for a limited time so other customers cannot buy them meanwhile.
 //	@Tags			cart
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body		addToCartPayload	true	"Add to Cart Payload"
 //	@Success		201		{object}	map[string]string
 //	@Failure		400		{object}	error
+//	@Failure		404		{object}	error	"Book not found"
+//	@Failure		409		{object}	error	"Not enough copies available"
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/carts [post]
@@ -59,9 +62,9 @@ func (app *Application) addToCartHandler(w http.ResponseWriter, r *http.Request)
 			return
 		}
 	}
-	err = app.store.Carts.InsertOrUpdateCartItem(ctx, cart.ID, payload.BookID, payload.Quantity)
+	err = app.store.Carts.InsertOrUpdateCartItem(ctx, cart.ID, payload.BookID, payload.Quantity, app.cfg.cartReservationTTL)
 	if err != nil {
-		app.internalServerError(w, r, err)
+		app.cartStockError(w, r, err)
 		return
 	}
 
@@ -182,6 +185,7 @@ type updateItemPayload struct {
 //	@Param			payload	body		updateItemPayload	true	"Quantity Payload"
 //	@Success		200		{object}	store.CartItem		"CartItem Updated successfully"
 //	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"Not enough copies available"
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/carts/items/{itemID} [patch]
@@ -199,24 +203,97 @@ func (app *Application) updateItemHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err := app.store.Carts.UpdateQuantity(r.Context(), payload.Quantity, item.ID, user.ID)
+	err := app.store.Carts.UpdateQuantity(r.Context(), payload.Quantity, item.ID, user.ID, app.cfg.cartReservationTTL)
 	if err != nil {
+		app.cartStockError(w, r, err)
+		return
+	}
+	item.Quantity = payload.Quantity
+	if err := jsonResponse(w, http.StatusOK, item); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type checkoutCartPayload struct {
+	PaymentMethod   string `json:"payment_method" validate:"required,oneof=cash_on_delivery Bkash credit_card"`
+	AddressID       *int   `json:"address_id" validate:"omitempty,min=1"`
+	ShippingAddress string `json:"shipping_address" validate:"required_without=AddressID,omitempty,min=5"`
+	ShippingRegion  string `json:"shipping_region" validate:"omitempty,max=100"`
+	CouponCode      string `json:"coupon_code" validate:"omitempty,max=50"`
+}
+
+// checkoutCartHandler godoc
+//
+//	@Summary		Checkout the cart
+//	@Description	Turns the authenticated user's cart into an order, validating stock and emptying the cart
+//	@Tags			cart
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		checkoutCartPayload	true	"Checkout Payload"
+//	@Success		201		{object}	store.Order			"Created Order"
+//	@Failure		400		{object}	error				"Invalid request or empty cart"
+//	@Failure		404		{object}	error				"Book not found"
+//	@Failure		409		{object}	error				"Insufficient stock"
+//	@Failure		500		{object}	error				"Server error"
+//	@Security		ApiKeyAuth
+//	@Router			/carts/checkout [post]
+func (app *Application) checkoutCartHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload checkoutCartPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	cart, err := app.store.Carts.GetOrCreateCart(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	order := &store.Order{
+		UserID:          user.ID,
+		PaymentMethod:   payload.PaymentMethod,
+		AddressID:       payload.AddressID,
+		ShippingAddress: payload.ShippingAddress,
+		ShippingRegion:  payload.ShippingRegion,
+		CouponCode:      payload.CouponCode,
+	}
+	if err := app.store.Orders.CreateFromCart(ctx, order, cart.ID); err != nil {
 		switch err {
-		case store.ErrorNotFound:
-			app.notFoundError(w, r, err)
-			return
+		case store.ErrEmptyCart:
+			app.badRequestError(w, r, err)
 		default:
-			app.internalServerError(w, r, err)
-			return
+			app.orderCreationError(w, r, err)
 		}
+		return
 	}
-	item.Quantity = payload.Quantity
-	if err := jsonResponse(w, http.StatusOK, item); err != nil {
+	if err := jsonResponse(w, http.StatusCreated, order); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 }
 
+// cartStockError maps errors of adding or updating cart items, which
+// reserve stock, to responses.
+func (app *Application) cartStockError(w http.ResponseWriter, r *http.Request, err error) {
+	var stockErr *store.InsufficientStockError
+	switch {
+	case errors.As(err, &stockErr):
+		app.conflictError(w, r, err)
+	case errors.Is(err, store.ErrorNotFound):
+		app.notFoundError(w, r, err)
+	default:
+		app.internalServerError(w, r, err)
+	}
+}
+
 func (app *Application) itemContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		idParam := chi.URLParam(r, "itemID")
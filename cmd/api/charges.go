@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type shippingRateCTX string
+
+const shippingRateCtx shippingRateCTX = "shippingRate"
+
+type taxRateCTX string
+
+const taxRateCtx taxRateCTX = "taxRate"
+
+type createShippingRatePayload struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Region        string   `json:"region" validate:"max=100"`
+	Method        string   `json:"method" validate:"required,oneof=flat per_item page_count"`
+	BaseAmount    float64  `json:"base_amount" validate:"gte=0"`
+	PerUnitAmount float64  `json:"per_unit_amount" validate:"gte=0"`
+	UnitSize      int      `json:"unit_size" validate:"omitempty,min=1"`
+	FreeOver      *float64 `json:"free_over" validate:"omitempty,gte=0"`
+	IsActive      *bool    `json:"is_active"`
+}
+
+// createShippingRateHandler godoc
+//
+//	@Summary		Create a shipping rate
+//	@Description	Creates a flat, per item or page count based shipping rate for a region. An empty region applies everywhere else.
+//	@Tags			shipping
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		createShippingRatePayload	true	"Shipping Rate Payload"
+//	@Success		201		{object}	store.ShippingRate			"Created Shipping Rate"
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/shipping-rates [post]
+func (app *Application) createShippingRateHandler(w http.ResponseWriter, r *http.Request) {
+	var payload createShippingRatePayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	rate := &store.ShippingRate{
+		Name:          payload.Name,
+		Region:        payload.Region,
+		Method:        payload.Method,
+		BaseAmount:    payload.BaseAmount,
+		PerUnitAmount: payload.PerUnitAmount,
+		UnitSize:      payload.UnitSize,
+		FreeOver:      payload.FreeOver,
+		IsActive:      true,
+	}
+	if rate.UnitSize == 0 {
+		rate.UnitSize = 1
+	}
+	if payload.IsActive != nil {
+		rate.IsActive = *payload.IsActive
+	}
+
+	if err := app.store.ShippingRates.Create(r.Context(), rate); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, rate); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// listShippingRatesHandler godoc
+//
+//	@Summary		List shipping rates
+//	@Description	Lists every shipping rate grouped by region
+//	@Tags			shipping
+//	@Produce		json
+//	@Success		200	{array}		store.ShippingRate	"Shipping Rates"
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/shipping-rates [get]
+func (app *Application) listShippingRatesHandler(w http.ResponseWriter, r *http.Request) {
+	rates, err := app.store.ShippingRates.List(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, rates); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getShippingRateHandler godoc
+//
+//	@Summary		Get a shipping rate
+//	@Description	Get a shipping rate by its ID
+//	@Tags			shipping
+//	@Produce		json
+//	@Param			shippingRateID	path		int					true	"Shipping Rate ID"
+//	@Success		200				{object}	store.ShippingRate	"Shipping Rate"
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/shipping-rates/{shippingRateID} [get]
+func (app *Application) getShippingRateHandler(w http.ResponseWriter, r *http.Request) {
+	rate := getShippingRateFromContext(r)
+
+	if err := jsonResponse(w, http.StatusOK, rate); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type updateShippingRatePayload struct {
+	Name          *string  `json:"name" validate:"omitempty,max=100"`
+	Region        *string  `json:"region" validate:"omitempty,max=100"`
+	Method        *string  `json:"method" validate:"omitempty,oneof=flat per_item page_count"`
+	BaseAmount    *float64 `json:"base_amount" validate:"omitempty,gte=0"`
+	PerUnitAmount *float64 `json:"per_unit_amount" validate:"omitempty,gte=0"`
+	UnitSize      *int     `json:"unit_size" validate:"omitempty,min=1"`
+	FreeOver      *float64 `json:"free_over" validate:"omitempty,gte=0"`
+	IsActive      *bool    `json:"is_active"`
+}
+
+// updateShippingRateHandler godoc
+//
+//	@Summary		Update a shipping rate
+//	@Description	Update a shipping rate by its ID
+//	@Tags			shipping
+//	@Accept			json
+//	@Produce		json
+//	@Param			shippingRateID	path		int							true	"Shipping Rate ID"
+//	@Param			payload			body		updateShippingRatePayload	true	"Update Shipping Rate Payload"
+//	@Success		200				{object}	store.ShippingRate			"Updated Shipping Rate"
+//	@Failure		400				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/shipping-rates/{shippingRateID} [patch]
+func (app *Application) updateShippingRateHandler(w http.ResponseWriter, r *http.Request) {
+	rate := getShippingRateFromContext(r)
+
+	var payload updateShippingRatePayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	if payload.Name != nil {
+		rate.Name = *payload.Name
+	}
+	if payload.Region != nil {
+		rate.Region = *payload.Region
+	}
+	if payload.Method != nil {
+		rate.Method = *payload.Method
+	}
+	if payload.BaseAmount != nil {
+		rate.BaseAmount = *payload.BaseAmount
+	}
+	if payload.PerUnitAmount != nil {
+		rate.PerUnitAmount = *payload.PerUnitAmount
+	}
+	if payload.UnitSize != nil {
+		rate.UnitSize = *payload.UnitSize
+	}
+	if payload.FreeOver != nil {
+		rate.FreeOver = payload.FreeOver
+	}
+	if payload.IsActive != nil {
+		rate.IsActive = *payload.IsActive
+	}
+
+	if err := app.store.ShippingRates.Update(r.Context(), rate); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, rate); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// deleteShippingRateHandler godoc
+//
+//	@Summary		Delete a shipping rate
+//	@Description	Delete a shipping rate by its ID
+//	@Tags			shipping
+//	@Param			shippingRateID	path	int	true	"Shipping Rate ID"
+//	@Success		204				"Shipping rate deleted"
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/shipping-rates/{shippingRateID} [delete]
+func (app *Application) deleteShippingRateHandler(w http.ResponseWriter, r *http.Request) {
+	rate := getShippingRateFromContext(r)
+
+	if err := app.store.ShippingRates.Delete(r.Context(), rate.ID); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type createTaxRatePayload struct {
+	Region            string  `json:"region" validate:"max=100"`
+	Rate              float64 `json:"rate" validate:"gte=0,lt=1"`
+	AppliesToShipping bool    `json:"applies_to_shipping"`
+	IsActive          *bool   `json:"is_active"`
+}
+
+// createTaxRateHandler godoc
+//
+//	@Summary		Create a tax rate
+//	@Description	Creates the tax rate of a region as a fraction, e.g. 0.15 for 15%. An empty region applies everywhere else.
+//	@Tags			tax
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		createTaxRatePayload	true	"Tax Rate Payload"
+//	@Success		201		{object}	store.TaxRate			"Created Tax Rate"
+//	@Failure		400		{object}	error
+//	@Failure		409		{object}	error	"Region already has a tax rate"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/tax-rates [post]
+func (app *Application) createTaxRateHandler(w http.ResponseWriter, r *http.Request) {
+	var payload createTaxRatePayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	rate := &store.TaxRate{
+		Region:            payload.Region,
+		Rate:              payload.Rate,
+		AppliesToShipping: payload.AppliesToShipping,
+		IsActive:          true,
+	}
+	if payload.IsActive != nil {
+		rate.IsActive = *payload.IsActive
+	}
+
+	if err := app.store.TaxRates.Create(r.Context(), rate); err != nil {
+		switch err {
+		case store.ErrDuplicateTaxRegion:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, rate); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// listTaxRatesHandler godoc
+//
+//	@Summary		List tax rates
+//	@Description	Lists the tax rate of every region
+//	@Tags			tax
+//	@Produce		json
+//	@Success		200	{array}		store.TaxRate	"Tax Rates"
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/tax-rates [get]
+func (app *Application) listTaxRatesHandler(w http.ResponseWriter, r *http.Request) {
+	rates, err := app.store.TaxRates.List(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, rates); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getTaxRateHandler godoc
+//
+//	@Summary		Get a tax rate
+//	@Description	Get a tax rate by its ID
+//	@Tags			tax
+//	@Produce		json
+//	@Param			taxRateID	path		int				true	"Tax Rate ID"
+//	@Success		200			{object}	store.TaxRate	"Tax Rate"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/tax-rates/{taxRateID} [get]
+func (app *Application) getTaxRateHandler(w http.ResponseWriter, r *http.Request) {
+	rate := getTaxRateFromContext(r)
+
+	if err := jsonResponse(w, http.StatusOK, rate); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type updateTaxRatePayload struct {
+	Region            *string  `json:"region" validate:"omitempty,max=100"`
+	Rate              *float64 `json:"rate" validate:"omitempty,gte=0,lt=1"`
+	AppliesToShipping *bool    `json:"applies_to_shipping"`
+	IsActive          *bool    `json:"is_active"`
+}
+
+// updateTaxRateHandler godoc
+//
+//	@Summary		Update a tax rate
+//	@Description	Update a tax rate by its ID
+//	@Tags			tax
+//	@Accept			json
+//	@Produce		json
+//	@Param			taxRateID	path		int						true	"Tax Rate ID"
+//	@Param			payload		body		updateTaxRatePayload	true	"Update Tax Rate Payload"
+//	@Success		200			{object}	store.TaxRate			"Updated Tax Rate"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Region already has a tax rate"
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/tax-rates/{taxRateID} [patch]
+func (app *Application) updateTaxRateHandler(w http.ResponseWriter, r *http.Request) {
+	rate := getTaxRateFromContext(r)
+
+	var payload updateTaxRatePayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	if payload.Region != nil {
+		rate.Region = *payload.Region
+	}
+	if payload.Rate != nil {
+		rate.Rate = *payload.Rate
+	}
+	if payload.AppliesToShipping != nil {
+		rate.AppliesToShipping = *payload.AppliesToShipping
+	}
+	if payload.IsActive != nil {
+		rate.IsActive = *payload.IsActive
+	}
+
+	if err := app.store.TaxRates.Update(r.Context(), rate); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		case store.ErrDuplicateTaxRegion:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, rate); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// deleteTaxRateHandler godoc
+//
+//	@Summary		Delete a tax rate
+//	@Description	Delete a tax rate by its ID
+//	@Tags			tax
+//	@Param			taxRateID	path	int	true	"Tax Rate ID"
+//	@Success		204			"Tax rate deleted"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/tax-rates/{taxRateID} [delete]
+func (app *Application) deleteTaxRateHandler(w http.ResponseWriter, r *http.Request) {
+	rate := getTaxRateFromContext(r)
+
+	if err := app.store.TaxRates.Delete(r.Context(), rate.ID); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *Application) shippingRateContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rateID, err := strconv.Atoi(chi.URLParam(r, "shippingRateID"))
+		if err != nil {
+			app.notFoundError(w, r, err)
+			return
+		}
+		ctx := r.Context()
+		rate, err := app.store.ShippingRates.GetByID(ctx, rateID)
+		if err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		ctx = context.WithValue(ctx, shippingRateCtx, rate)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getShippingRateFromContext(r *http.Request) *store.ShippingRate {
+	rate, _ := r.Context().Value(shippingRateCtx).(*store.ShippingRate)
+	return rate
+}
+
+func (app *Application) taxRateContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rateID, err := strconv.Atoi(chi.URLParam(r, "taxRateID"))
+		if err != nil {
+			app.notFoundError(w, r, err)
+			return
+		}
+		ctx := r.Context()
+		rate, err := app.store.TaxRates.GetByID(ctx, rateID)
+		if err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		ctx = context.WithValue(ctx, taxRateCtx, rate)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getTaxRateFromContext(r *http.Request) *store.TaxRate {
+	rate, _ := r.Context().Value(taxRateCtx).(*store.TaxRate)
+	return rate
+}
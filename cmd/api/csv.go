@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// csvWriter writes CSV rows that are safe to open in a spreadsheet: text
+// starting like a formula gets a leading ' so it is shown instead of run.
+type csvWriter struct {
+	*csv.Writer
+}
+
+func (cw *csvWriter) Write(record []string) error {
+	escaped := make([]string, len(record))
+	for i, field := range record {
+		escaped[i] = escapeCSVField(field)
+	}
+	return cw.Writer.Write(escaped)
+}
+
+func (cw *csvWriter) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// escapeCSVField defuses fields a spreadsheet would read as a formula.
+// Numbers, negative ones included, are left as they are.
+func escapeCSVField(field string) string {
+	if field == "" || !strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return field
+	}
+	if _, err := strconv.ParseFloat(field, 64); err == nil {
+		return field
+	}
+	return "'" + field
+}
+
+// csvResponse starts a CSV download and returns a writer for its rows. The
+// caller must Flush the writer once every row is written.
+func csvResponse(w http.ResponseWriter, filename string, header []string) (*csvWriter, error) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	cw := &csvWriter{Writer: csv.NewWriter(w)}
+	return cw, cw.Write(header)
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -32,3 +32,8 @@ func (app *Application) forbiddenError(w http.ResponseWriter, r *http.Request) {
 	app.logger.Errorw("forbidden error :", "method", r.Method, "path", r.URL.Path, "error", "lower level role")
 	writeJsonError(w, http.StatusForbidden, "lower level role , not allowed")
 }
+
+func (app *Application) unprocessableEntityError(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Errorw("unprocessable entity error :", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	writeJsonError(w, http.StatusUnprocessableEntity, err.Error())
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+)
+
+const idempotencyHeader = "Idempotency-Key"
+
+// idempotencyRecorder captures the status and body written by the wrapped
+// handler so they can be stored and replayed.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotencyMiddleware makes POST requests carrying an Idempotency-Key header
+// safe to retry. The first request with a key runs normally and its response
+// is stored; repeats with the same body get the stored response replayed and
+// repeats with a different body are rejected with 422. Server errors release
+// the key so the client can try again.
+//
+// Keys of signed in users are scoped to the user. Anonymous clients share no
+// namespace, so their keys are scoped to the request itself: only a repeat of
+// the exact same request is replayed and a different body simply runs as a
+// new request.
+func (app *Application) idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyValue := r.Header.Get(idempotencyHeader)
+		if keyValue == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(keyValue) > 255 {
+			app.badRequestError(w, r, fmt.Errorf("%s header must be at most 255 characters", idempotencyHeader))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1_048_578))
+		if err != nil {
+			app.badRequestError(w, r, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+		hash.Write(body)
+
+		key := &store.IdempotencyKey{
+			Key:         keyValue,
+			RequestHash: hex.EncodeToString(hash.Sum(nil)),
+		}
+		if user := getUserFromContext(r); user != nil {
+			key.UserID = user.ID
+		} else {
+			scoped := sha256.Sum256([]byte(key.RequestHash + "\n" + keyValue))
+			key.Key = hex.EncodeToString(scoped[:])
+		}
+
+		ctx := r.Context()
+		reserved, err := app.store.IdempotencyKeys.Reserve(ctx, key, app.cfg.idempotencyTTL)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !reserved {
+			app.replayIdempotentResponse(w, r, key)
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		// settle the key even if the client has gone away, or retries would
+		// find it still processing until it expires
+		ctx = context.WithoutCancel(ctx)
+
+		if rec.status >= http.StatusInternalServerError || rec.status == 0 {
+			if err := app.store.IdempotencyKeys.Delete(ctx, key.ID); err != nil {
+				app.logger.Errorw("error releasing idempotency key", "key", key.Key, "error", err)
+			}
+			return
+		}
+		key.ResponseStatus = rec.status
+		key.ResponseContentType = rec.Header().Get("Content-Type")
+		key.ResponseBody = rec.body.Bytes()
+		if err := app.store.IdempotencyKeys.Complete(ctx, key); err != nil {
+			app.logger.Errorw("error storing idempotent response", "key", key.Key, "error", err)
+		}
+	})
+}
+
+func (app *Application) replayIdempotentResponse(w http.ResponseWriter, r *http.Request, key *store.IdempotencyKey) {
+	stored, err := app.store.IdempotencyKeys.Get(r.Context(), key.UserID, key.Key)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if stored.RequestHash != key.RequestHash {
+		app.unprocessableEntityError(w, r, fmt.Errorf("%s was already used with a different request", idempotencyHeader))
+		return
+	}
+	if !stored.Completed() {
+		app.conflictError(w, r, fmt.Errorf("a request with this %s is still being processed", idempotencyHeader))
+		return
+	}
+	if stored.ResponseContentType != "" {
+		w.Header().Set("Content-Type", stored.ResponseContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.ResponseStatus)
+	w.Write(stored.ResponseBody)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AmiyoKm/book_store/internal/pdf"
+	"github.com/AmiyoKm/book_store/internal/store"
+)
+
+// column offsets, in points from the left margin
+var (
+	invoiceColumns     = []float64{0, 270, 310, 385, 430}
+	packingSlipColumns = []float64{0, 40, 150}
+)
+
+// getInvoiceHandler godoc
+//
+//	@Summary		Download the invoice of an order
+//	@Description	Renders the order's invoice as a PDF. The invoice number is issued the first time the invoice is requested, once the order has been paid.
+//	@Tags			order
+//	@Produce		application/pdf
+//	@Param			orderID	path		int		true	"Order ID"
+//	@Success		200		{file}		file	"Invoice PDF"
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"Order has not been paid"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/orders/{orderID}/invoice.pdf [get]
+func (app *Application) getInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	if !canAccessOrder(user, order) {
+		app.notFoundError(w, r, store.ErrorNotFound)
+		return
+	}
+	ctx := r.Context()
+	invoice, err := app.store.Invoices.GetOrIssue(ctx, order.ID)
+	if err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		case store.ErrOrderNotPaid:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	books, err := app.store.Orders.GetBooks(ctx, order.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	doc := app.renderInvoice(order, invoice, books)
+	app.writePDF(w, r, invoice.Code()+".pdf", doc)
+}
+
+// getPackingSlipHandler godoc
+//
+//	@Summary		Download the packing slip of an order
+//	@Description	Renders a PDF listing the books and quantities to pack and the address to ship them to
+//	@Tags			order
+//	@Produce		application/pdf
+//	@Param			orderID	path		int		true	"Order ID"
+//	@Success		200		{file}		file	"Packing slip PDF"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/orders/{orderID}/packing-slip.pdf [get]
+func (app *Application) getPackingSlipHandler(w http.ResponseWriter, r *http.Request) {
+	order := getOrderFromContext(r)
+
+	books, err := app.store.Orders.GetBooks(r.Context(), order.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	doc := pdf.New()
+	doc.Text(18, true, "PACKING SLIP")
+	doc.Space(6)
+	doc.Text(10, false, fmt.Sprintf("Order #%d", order.ID))
+	doc.Text(10, false, "Placed: "+order.PlacedAt.Format("02 Jan 2006"))
+	doc.Space(8)
+	writeShippingAddress(doc, order)
+	doc.Space(8)
+
+	doc.Columns(10, true, packingSlipColumns, []string{"Qty", "ISBN", "Title"})
+	doc.Rule()
+	var copies int
+	for _, item := range order.Items {
+		isbn, title := "", fmt.Sprintf("Book #%d", item.BookID)
+		if book, ok := books[item.BookID]; ok {
+			isbn, title = book.ISBN, truncate(book.Title+" - "+book.Author, 60)
+		}
+		doc.Columns(10, false, packingSlipColumns, []string{strconv.Itoa(item.Quantity), isbn, title})
+		copies += item.Quantity
+	}
+	doc.Rule()
+	doc.Text(10, true, fmt.Sprintf("%d items, %d copies", len(order.Items), copies))
+
+	app.writePDF(w, r, fmt.Sprintf("packing-slip-%d.pdf", order.ID), doc)
+}
+
+func (app *Application) renderInvoice(order *store.Order, invoice *store.Invoice, books map[int]*store.Book) *pdf.Document {
+	currency := app.cfg.payment.currency
+	money := func(amount float64) string {
+		return fmt.Sprintf("%.2f %s", amount, currency)
+	}
+
+	doc := pdf.New()
+	doc.Text(18, true, "INVOICE")
+	doc.Space(6)
+	doc.Text(10, false, "Invoice number: "+invoice.Code())
+	doc.Text(10, false, "Issued: "+invoice.IssuedAt.Format("02 Jan 2006"))
+	doc.Text(10, false, fmt.Sprintf("Order #%d placed %s", order.ID, order.PlacedAt.Format("02 Jan 2006")))
+	doc.Text(10, false, "Payment method: "+order.PaymentMethod)
+	doc.Space(8)
+	writeShippingAddress(doc, order)
+	doc.Space(8)
+
+	doc.Columns(10, true, invoiceColumns, []string{"Item", "Qty", "Unit price", "Discount", "Amount"})
+	doc.Rule()
+	for _, item := range order.Items {
+		title := fmt.Sprintf("Book #%d", item.BookID)
+		if book, ok := books[item.BookID]; ok {
+			title = truncate(book.Title, 45)
+		}
+		amount := item.Price*float64(item.Quantity) - item.DiscountAmount
+		doc.Columns(10, false, invoiceColumns, []string{
+			title,
+			strconv.Itoa(item.Quantity),
+			fmt.Sprintf("%.2f", item.Price),
+			fmt.Sprintf("%.2f", item.DiscountAmount),
+			fmt.Sprintf("%.2f", amount),
+		})
+	}
+	doc.Rule()
+
+	totals := []struct {
+		label  string
+		amount float64
+	}{
+		{"Subtotal", order.SubtotalAmount},
+		{"Discount", -order.DiscountAmount},
+		{"Shipping", order.ShippingAmount},
+		{"Tax", order.TaxAmount},
+	}
+	for _, t := range totals {
+		doc.Columns(10, false, []float64{310, 430}, []string{t.label, money(t.amount)})
+	}
+	if order.CouponCode != "" {
+		doc.Columns(10, false, []float64{310, 430}, []string{"Coupon", order.CouponCode})
+	}
+	doc.Columns(11, true, []float64{310, 430}, []string{"Total", money(order.TotalAmount)})
+	if order.RefundedAmount > 0 {
+		doc.Columns(10, false, []float64{310, 430}, []string{"Refunded", money(order.RefundedAmount)})
+	}
+	return doc
+}
+
+func writeShippingAddress(doc *pdf.Document, order *store.Order) {
+	doc.Text(10, true, "Ship to")
+	if a := order.ShippingDetails; a != nil {
+		doc.Text(10, false, a.Recipient)
+		doc.Text(10, false, a.Line1)
+		if a.Line2 != "" {
+			doc.Text(10, false, a.Line2)
+		}
+		doc.Text(10, false, strings.Join(strings.Fields(a.City+" "+a.Region+" "+a.PostalCode), " "))
+		doc.Text(10, false, a.Country)
+		doc.Text(10, false, "Phone: "+a.Phone)
+		return
+	}
+	doc.Text(10, false, order.ShippingAddress)
+}
+
+func (app *Application) writePDF(w http.ResponseWriter, r *http.Request, filename string, doc *pdf.Document) {
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		app.logger.Errorw("writing pdf", "error", err.Error())
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type locationCTX string
+
+const locationCtx locationCTX = "location"
+
+type createLocationPayload struct {
+	Code     string `json:"code" validate:"required,alphanum,max=20"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Region   string `json:"region" validate:"omitempty,max=100"`
+	Priority int    `json:"priority"`
+}
+
+// createLocationHandler godoc
+//
+//	@Summary		Create a stock location
+//	@Description	Adds a warehouse or shop that holds stock. Orders take stock from locations in priority order (lowest first), unless the allocation strategy says otherwise.
+//	@Tags			stock
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		createLocationPayload	true	"Location Payload"
+//	@Success		201		{object}	store.StockLocation		"Created Location"
+//	@Failure		400		{object}	error
+//	@Failure		409		{object}	error	"Code already in use"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/locations [post]
+func (app *Application) createLocationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload createLocationPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	location := &store.StockLocation{
+		Code:     payload.Code,
+		Name:     payload.Name,
+		Region:   payload.Region,
+		Priority: payload.Priority,
+	}
+
+	if err := app.store.Locations.Create(r.Context(), location); err != nil {
+		switch err {
+		case store.ErrDuplicateLocationCode:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, location); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// listLocationsHandler godoc
+//
+//	@Summary		List stock locations
+//	@Description	Lists stock locations in priority order
+//	@Tags			stock
+//	@Produce		json
+//	@Success		200	{array}		store.StockLocation	"Locations"
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/locations [get]
+func (app *Application) listLocationsHandler(w http.ResponseWriter, r *http.Request) {
+	locations, err := app.store.Locations.List(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, locations); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getLocationHandler godoc
+//
+//	@Summary		Get a stock location
+//	@Description	Get a stock location by its ID
+//	@Tags			stock
+//	@Produce		json
+//	@Param			locationID	path		int					true	"Location ID"
+//	@Success		200			{object}	store.StockLocation	"Location"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/locations/{locationID} [get]
+func (app *Application) getLocationHandler(w http.ResponseWriter, r *http.Request) {
+	location := getLocationFromContext(r)
+
+	if err := jsonResponse(w, http.StatusOK, location); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type updateLocationPayload struct {
+	Code     *string `json:"code" validate:"omitempty,alphanum,max=20"`
+	Name     *string `json:"name" validate:"omitempty,max=100"`
+	Region   *string `json:"region" validate:"omitempty,max=100"`
+	Priority *int    `json:"priority"`
+}
+
+// updateLocationHandler godoc
+//
+//	@Summary		Update a stock location
+//	@Description	Update a stock location by its ID
+//	@Tags			stock
+//	@Accept			json
+//	@Produce		json
+//	@Param			locationID	path		int						true	"Location ID"
+//	@Param			payload		body		updateLocationPayload	true	"Update Location Payload"
+//	@Success		200			{object}	store.StockLocation		"Updated Location"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Code already in use"
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/locations/{locationID} [patch]
+func (app *Application) updateLocationHandler(w http.ResponseWriter, r *http.Request) {
+	location := getLocationFromContext(r)
+
+	var payload updateLocationPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	if payload.Code != nil {
+		location.Code = *payload.Code
+	}
+	if payload.Name != nil {
+		location.Name = *payload.Name
+	}
+	if payload.Region != nil {
+		location.Region = *payload.Region
+	}
+	if payload.Priority != nil {
+		location.Priority = *payload.Priority
+	}
+
+	if err := app.store.Locations.Update(r.Context(), location); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		case store.ErrDuplicateLocationCode:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, location); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type AdminBookResponse struct {
+	*store.Book
+	Locations []*store.LocationStock `json:"locations"`
+}
+
+// getAdminBookHandler godoc
+//
+//	@Summary		Get a book with its stock per location
+//	@Description	Get a book by its ID along with its stock at every location. Stock is the sum over all locations.
+//	@Tags			stock
+//	@Produce		json
+//	@Param			bookID	path		int					true	"Book ID"
+//	@Success		200		{object}	AdminBookResponse	"Book"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/books/{bookID} [get]
+func (app *Application) getAdminBookHandler(w http.ResponseWriter, r *http.Request) {
+	book := getBookFromContext(r)
+
+	locations, err := app.store.Locations.GetBookStock(r.Context(), book.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, AdminBookResponse{Book: book, Locations: locations}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type createStockTransferPayload struct {
+	FromLocationID int    `json:"from_location_id" validate:"required,min=1"`
+	ToLocationID   int    `json:"to_location_id" validate:"required,min=1,nefield=FromLocationID"`
+	Quantity       int    `json:"quantity" validate:"required,min=1"`
+	Note           string `json:"note" validate:"omitempty,max=500"`
+}
+
+// createStockTransferHandler godoc
+//
+//	@Summary		Transfer stock between locations
+//	@Description	Moves copies of the book from one location to another. The book's total stock does not change.
+//	@Tags			stock
+//	@Accept			json
+//	@Produce		json
+//	@Param			bookID	path		int							true	"Book ID"
+//	@Param			payload	body		createStockTransferPayload	true	"Transfer"
+//	@Success		201		{object}	store.StockTransfer			"Recorded transfer"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"Not enough copies at the source location"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/books/{bookID}/transfers [post]
+func (app *Application) createStockTransferHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	book := getBookFromContext(r)
+
+	var payload createStockTransferPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	for _, locationID := range []int{payload.FromLocationID, payload.ToLocationID} {
+		if _, err := app.store.Locations.GetByID(ctx, locationID); err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+	}
+
+	transfer := &store.StockTransfer{
+		BookID:         book.ID,
+		FromLocationID: payload.FromLocationID,
+		ToLocationID:   payload.ToLocationID,
+		Quantity:       payload.Quantity,
+		Note:           payload.Note,
+		ActorID:        user.ID,
+	}
+	if err := app.store.StockMovements.Transfer(ctx, transfer); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNegativeStock):
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, transfer); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getStockTransfersHandler godoc
+//
+//	@Summary		List stock transfers of a book
+//	@Description	Lists the book's transfers between locations, newest first
+//	@Tags			stock
+//	@Produce		json
+//	@Param			bookID	path		int	true	"Book ID"
+//	@Success		200		{array}		store.StockTransfer
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/books/{bookID}/transfers [get]
+func (app *Application) getStockTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	book := getBookFromContext(r)
+
+	transfers, err := app.store.StockMovements.GetTransfers(r.Context(), book.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, transfers); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+func (app *Application) locationContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locationID, err := strconv.Atoi(chi.URLParam(r, "locationID"))
+		if err != nil {
+			app.notFoundError(w, r, err)
+			return
+		}
+		ctx := r.Context()
+		location, err := app.store.Locations.GetByID(ctx, locationID)
+		if err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		ctx = context.WithValue(ctx, locationCtx, location)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getLocationFromContext(r *http.Request) *store.StockLocation {
+	location, _ := r.Context().Value(locationCtx).(*store.StockLocation)
+	return location
+}
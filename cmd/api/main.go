@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"time"
 
 	"github.com/AmiyoKm/book_store/internal/auth"
 	"github.com/AmiyoKm/book_store/internal/db"
 	"github.com/AmiyoKm/book_store/internal/env"
 	mailer "github.com/AmiyoKm/book_store/internal/mail"
+	"github.com/AmiyoKm/book_store/internal/payment"
 	"github.com/AmiyoKm/book_store/internal/store"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -62,14 +64,28 @@ func main() {
 		},
 	}
 	config := Config{
-		db:          dbConfig,
-		env:         env.GetString("ENVIRONMENT", "DEVELOPMENT"),
-		addr:        env.GetString("ADDR", ":8080"),
-		apiUrl:      env.GetString("API_URL", "localhost:8080"),
-		frontendURL: env.GetString("FRONT_END_URL_PROD", "http://localhost:5173"),
-		mail:        mailCgf,
-		auth:        authConfig,
+		db:                 dbConfig,
+		env:                env.GetString("ENVIRONMENT", "DEVELOPMENT"),
+		addr:               env.GetString("ADDR", ":8080"),
+		apiUrl:             env.GetString("API_URL", "localhost:8080"),
+		frontendURL:        env.GetString("FRONT_END_URL_PROD", "http://localhost:5173"),
+		mail:               mailCgf,
+		auth:               authConfig,
+		idempotencyTTL:     env.GetDuration("IDEMPOTENCY_TTL", time.Hour*24),
+		cartReservationTTL: time.Minute * 15,
+		searchLanguage:     env.GetString("SEARCH_LANGUAGE", "english"),
+		payment: paymentConfig{
+			provider:          env.GetString("PAYMENT_PROVIDER", paymentProviderNone),
+			currency:          env.GetString("PAYMENT_CURRENCY", "BDT"),
+			fakeWebhookSecret: env.GetString("FAKE_PAYMENT_WEBHOOK_SECRET", ""),
+		},
+	}
+
+	store.StockAllocation = env.GetString("STOCK_ALLOCATION", store.AllocateByPriority)
+	if !store.IsAllocationStrategy(store.StockAllocation) {
+		logger.Fatalf("unknown stock allocation strategy %q", store.StockAllocation)
 	}
+	store.LowStockThreshold = env.GetInt("LOW_STOCK_THRESHOLD", 5)
 
 	db, err := db.New(config.db.addr, config.db.maxConnOpen, config.db.maxIdleConn, config.db.maxIdleTime)
 	if err != nil {
@@ -79,18 +95,39 @@ func main() {
 	logger.Info("DB connection pool established")
 
 	store := store.NewStorage(db)
+	if err := store.Books.SetSearchLanguage(context.Background(), config.searchLanguage); err != nil {
+		logger.Fatal(err)
+	}
 	mailClient, err := mailer.NewGoMailClient(config.mail.apiKey, config.mail.fromEmail)
 	if err != nil {
 		logger.Fatal(err)
 	}
 	JWTAuthenticator := auth.NewJWTAuthenticator(config.auth.token.secret, config.auth.token.iss, config.auth.token.iss)
+	// the fake provider accepts any webhook signed with its secret, so it is
+	// never mounted in production
+	payments := map[string]payment.Provider{}
+	if config.env != "PRODUCTION" && config.payment.fakeWebhookSecret != "" {
+		fakePayments := payment.NewFakeProvider(config.payment.fakeWebhookSecret)
+		payments[fakePayments.Name()] = fakePayments
+	}
+	if _, ok := payments[config.payment.provider]; !ok && config.payment.provider != paymentProviderNone {
+		logger.Warnf("payment provider %q is not available in %s, online payments are disabled", config.payment.provider, config.env)
+		config.payment.provider = paymentProviderNone
+	}
 	app := &Application{
-		cfg:    config,
-		logger: logger,
-		store:  store,
-		mail:   mailClient,
-		auth:   JWTAuthenticator,
+		cfg:      config,
+		logger:   logger,
+		store:    store,
+		mail:     mailClient,
+		auth:     JWTAuthenticator,
+		payments: payments,
 	}
+	go app.reindexSearch()
+	go app.runReservationSweeper(time.Minute)
+	go app.runIdempotencyKeySweeper(time.Hour)
+	go app.runStockAlertNotifier(time.Minute)
+	go app.runPriceAlertNotifier(time.Minute)
+
 	mux := app.mount()
 	logger.Fatal(app.run(mux))
 
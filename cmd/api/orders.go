@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	mailer "github.com/AmiyoKm/book_store/internal/mail"
 	"github.com/AmiyoKm/book_store/internal/store"
 	"github.com/go-chi/chi/v5"
 )
@@ -15,27 +17,30 @@ type orderCTX string
 const orderCtx orderCTX = "order"
 
 type createOrderPayload struct {
-	TotalAmount     float64            `json:"total_amount" validate:"required,gt=0"`
 	PaymentMethod   string             `json:"payment_method" validate:"required,oneof=cash_on_delivery Bkash credit_card"`
-	ShippingAddress string             `json:"shipping_address" validate:"required,min=5"`
+	AddressID       *int               `json:"address_id" validate:"omitempty,min=1"`
+	ShippingAddress string             `json:"shipping_address" validate:"required_without=AddressID,omitempty,min=5"`
+	ShippingRegion  string             `json:"shipping_region" validate:"omitempty,max=100"`
+	CouponCode      string             `json:"coupon_code" validate:"omitempty,max=50"`
 	Items           []OrderItemPayload `json:"items" validate:"required,dive"`
 }
 type OrderItemPayload struct {
-	BookID   int     `json:"book_id" validate:"required,min=1"`
-	Quantity int     `json:"quantity" validate:"required,min=1"`
-	Price    float64 `json:"price" validate:"required,gt=0"`
+	BookID   int `json:"book_id" validate:"required,min=1"`
+	Quantity int `json:"quantity" validate:"required,min=1"`
 }
 
 // createOrderHandler godoc
 //
 //	@Summary		Create an order
-//	@Description	Create an order
+//	@Description	Create an order. Line prices and the total are taken from the catalog and stock is reserved atomically.
 //	@Tags			order
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body		createOrderPayload	true	"Create Order Payload"
 //	@Success		201		{object}	store.Order			"Creates an order"
 //	@Failure		400		{object}	error				"Invalid request"
+//	@Failure		404		{object}	error				"Book not found"
+//	@Failure		409		{object}	error				"Insufficient stock"
 //	@Failure		500		{object}	error				"Server error"
 //	@Security		ApiKeyAuth
 //	@Router			/orders [post]
@@ -54,9 +59,11 @@ func (app *Application) createOrderHandler(w http.ResponseWriter, r *http.Reques
 	}
 	order := &store.Order{
 		UserID:          user.ID,
-		TotalAmount:     payload.TotalAmount,
 		PaymentMethod:   payload.PaymentMethod,
+		AddressID:       payload.AddressID,
 		ShippingAddress: payload.ShippingAddress,
+		ShippingRegion:  payload.ShippingRegion,
+		CouponCode:      payload.CouponCode,
 		Items:           make([]store.OrderItem, len(payload.Items)),
 	}
 
@@ -64,13 +71,12 @@ func (app *Application) createOrderHandler(w http.ResponseWriter, r *http.Reques
 		order.Items[i] = store.OrderItem{
 			BookID:   item.BookID,
 			Quantity: item.Quantity,
-			Price:    item.Price,
 		}
 	}
 
 	ctx := r.Context()
 	if err := app.store.Orders.Create(ctx, order); err != nil {
-		app.internalServerError(w, r, err)
+		app.orderCreationError(w, r, err)
 		return
 	}
 	if err := jsonResponse(w, http.StatusCreated, order); err != nil {
@@ -79,10 +85,89 @@ func (app *Application) createOrderHandler(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+type quoteOrderPayload struct {
+	AddressID      *int               `json:"address_id" validate:"omitempty,min=1"`
+	ShippingRegion string             `json:"shipping_region" validate:"omitempty,max=100"`
+	CouponCode     string             `json:"coupon_code" validate:"omitempty,max=50"`
+	Items          []OrderItemPayload `json:"items" validate:"required,min=1,dive"`
+}
+
+type OrderQuoteResponse struct {
+	SubtotalAmount float64           `json:"subtotal_amount"`
+	DiscountAmount float64           `json:"discount_amount"`
+	ShippingAmount float64           `json:"shipping_amount"`
+	TaxAmount      float64           `json:"tax_amount"`
+	TotalAmount    float64           `json:"total_amount"`
+	CouponCode     string            `json:"coupon_code"`
+	ShippingRegion string            `json:"shipping_region"`
+	Items          []store.OrderItem `json:"order_items"`
+}
+
+// quoteOrderHandler godoc
+//
+//	@Summary		Quote an order
+//	@Description	Returns the subtotal, discount, shipping, tax and total a prospective order would be charged, without placing it
+//	@Tags			order
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		quoteOrderPayload	true	"Quote Payload"
+//	@Success		200		{object}	OrderQuoteResponse	"Price breakdown"
+//	@Failure		400		{object}	error				"Invalid request or coupon"
+//	@Failure		404		{object}	error				"Book not found"
+//	@Failure		409		{object}	error				"Insufficient stock"
+//	@Failure		500		{object}	error				"Server error"
+//	@Security		ApiKeyAuth
+//	@Router			/orders/quote [post]
+func (app *Application) quoteOrderHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload quoteOrderPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	order := &store.Order{
+		UserID:         user.ID,
+		AddressID:      payload.AddressID,
+		ShippingRegion: payload.ShippingRegion,
+		CouponCode:     payload.CouponCode,
+		Items:          make([]store.OrderItem, len(payload.Items)),
+	}
+	for i, item := range payload.Items {
+		order.Items[i] = store.OrderItem{
+			BookID:   item.BookID,
+			Quantity: item.Quantity,
+		}
+	}
+
+	if err := app.store.Orders.Quote(r.Context(), order); err != nil {
+		app.orderCreationError(w, r, err)
+		return
+	}
+	quote := OrderQuoteResponse{
+		SubtotalAmount: order.SubtotalAmount,
+		DiscountAmount: order.DiscountAmount,
+		ShippingAmount: order.ShippingAmount,
+		TaxAmount:      order.TaxAmount,
+		TotalAmount:    order.TotalAmount,
+		CouponCode:     order.CouponCode,
+		ShippingRegion: order.ShippingRegion,
+		Items:          order.Items,
+	}
+	if err := jsonResponse(w, http.StatusOK, quote); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
 // getOrderHandler godoc
 //
 //	@Summary		Get a order
-//	@Description	Get a order by its ID
+//	@Description	Get a order by its ID, including the tracking info of its shipments
 //	@Tags			order
 //	@Accept			json
 //	@Produce		json
@@ -93,12 +178,19 @@ func (app *Application) createOrderHandler(w http.ResponseWriter, r *http.Reques
 //	@Security		ApiKeyAuth
 //	@Router			/orders/{id} [get]
 func (app *Application) getOrderHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
 	order := getOrderFromContext(r)
 
-	if order == nil {
-		app.notFoundError(w, r, nil)
+	if order == nil || !canAccessOrder(user, order) {
+		app.notFoundError(w, r, store.ErrorNotFound)
 		return
 	}
+	shipments, err := app.store.Shipments.GetByOrderID(r.Context(), order.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	order.Shipments = shipments
 	if err := jsonResponse(w, http.StatusAccepted, order); err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -153,6 +245,7 @@ type updateOrderPayload struct {
 //	@Param			payload	body		updateOrderPayload	true	"Update Order Payload by User"
 //	@Success		200		{object}	store.Order			"Updated Order"
 //	@Failure		400		{object}	error				"Invalid request"
+//	@Failure		409		{object}	error				"Order can no longer be edited"
 //	@Failure		500		{object}	error				"Server error"
 //	@Security		ApiKeyAuth
 //	@Router			/orders/{id} [patch]
@@ -179,8 +272,16 @@ func (app *Application) updateOderHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !store.IsOrderEditable(order.Status) {
+		app.conflictError(w, r, fmt.Errorf("order can no longer be edited in status %q", order.Status))
+		return
+	}
+
 	if payload.ShippingAddress != nil {
+		// a typed address replaces the address book snapshot
 		order.ShippingAddress = *payload.ShippingAddress
+		order.AddressID = nil
+		order.ShippingDetails = nil
 	}
 
 	err := app.store.Orders.Update(r.Context(), order)
@@ -205,6 +306,7 @@ type updateOrderAdminPayload struct {
 	ShippingAddress *string `json:"shipping_address" validate:"omitempty,min=1"`
 	PaymentMethod   *string `json:"payment_method" validate:"omitempty,oneof=cash_on_delivery Bkash credit_card"`
 	Status          *string `json:"status" validate:"omitempty,oneof=pending processing shipped delivered cancelled returned failed refunded"`
+	Note            *string `json:"note" validate:"omitempty,max=500"`
 }
 
 // updateAdminOrderHandler godoc
@@ -218,6 +320,7 @@ type updateOrderAdminPayload struct {
 //	@Param			payload	body		updateOrderAdminPayload	true	"Update Order Payload by Admin"
 //	@Success		200		{object}	store.Order				"Updated Order"
 //	@Failure		400		{object}	error					"Invalid request"
+//	@Failure		409		{object}	error					"Illegal status transition"
 //	@Failure		500		{object}	error					"Server error"
 //	@Security		ApiKeyAuth
 //	@Router			/admin/orders/{id} [patch]
@@ -244,17 +347,36 @@ func (app *Application) updateAdminOrderHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	ctx := r.Context()
+	if payload.Status != nil && *payload.Status != order.Status {
+		var note string
+		if payload.Note != nil {
+			note = *payload.Note
+		}
+		err := app.store.Orders.UpdateStatus(ctx, order, *payload.Status, user.ID, note)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrInvalidStatusTransition):
+				app.conflictError(w, r, err)
+			case errors.Is(err, store.ErrorNotFound):
+				app.notFoundError(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+	}
+
 	if payload.ShippingAddress != nil {
 		order.ShippingAddress = *payload.ShippingAddress
-	}
-	if payload.Status != nil {
-		order.Status = *payload.Status
+		order.AddressID = nil
+		order.ShippingDetails = nil
 	}
 	if payload.PaymentMethod != nil {
 		order.PaymentMethod = *payload.PaymentMethod
 	}
 
-	err := app.store.Orders.Update(r.Context(), order)
+	err := app.store.Orders.Update(ctx, order)
 	if err != nil {
 		switch err {
 		case store.ErrorNotFound:
@@ -271,6 +393,122 @@ func (app *Application) updateAdminOrderHandler(w http.ResponseWriter, r *http.R
 	}
 }
 
+// getOrderHistoryHandler godoc
+//
+//	@Summary		Get order status history
+//	@Description	Lists every status change of an order, oldest first
+//	@Tags			order
+//	@Produce		json
+//	@Param			id	path		int						true	"Order ID"
+//	@Success		200	{array}		store.OrderStatusChange	"Status history"
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/orders/{id}/history [get]
+func (app *Application) getOrderHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	if !canAccessOrder(user, order) {
+		app.notFoundError(w, r, store.ErrorNotFound)
+		return
+	}
+	history, err := app.store.Orders.GetStatusHistory(r.Context(), order.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, history); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// cancelOrderHandler godoc
+//
+//	@Summary		Cancel an order
+//	@Description	Lets the owner cancel an order that is still pending or processing and has not started shipping. Every item is returned to stock.
+//	@Tags			order
+//	@Produce		json
+//	@Param			id	path		int			true	"Order ID"
+//	@Success		200	{object}	store.Order	"Cancelled Order"
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error	"Order can no longer be cancelled or has started shipping"
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/orders/{id}/cancel [post]
+func (app *Application) cancelOrderHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	if order.UserID != user.ID {
+		app.notFoundError(w, r, store.ErrorNotFound)
+		return
+	}
+	ctx := r.Context()
+	shipments, err := app.store.Shipments.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if len(shipments) > 0 {
+		app.conflictError(w, r, fmt.Errorf("order has already started shipping"))
+		return
+	}
+	err = app.store.Orders.UpdateStatus(ctx, order, store.OrderStatusCancelled, user.ID, "cancelled by customer")
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidStatusTransition):
+			app.conflictError(w, r, err)
+		case errors.Is(err, store.ErrorNotFound):
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	isProdEnv := app.cfg.env == "PRODUCTION"
+	vars := struct {
+		Username    string
+		OrderID     int
+		TotalAmount string
+		OrderURL    string
+	}{
+		Username:    user.Username,
+		OrderID:     order.ID,
+		TotalAmount: fmt.Sprintf("%.2f", order.TotalAmount),
+		OrderURL:    fmt.Sprintf("%s/orders/%d", app.cfg.frontendURL, order.ID),
+	}
+	if _, err := app.mail.Send(mailer.OrderCancelledTemplate, user.Username, user.Email, vars, !isProdEnv); err != nil {
+		app.logger.Errorw("error sending order cancellation email", "order", order.ID, "error", err)
+	}
+
+	if err := jsonResponse(w, http.StatusOK, order); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// canAccessOrder reports whether the user owns the order or is staff.
+func canAccessOrder(user *store.User, order *store.Order) bool {
+	return order.UserID == user.ID || user.Role.Level > 1
+}
+
+func (app *Application) orderCreationError(w http.ResponseWriter, r *http.Request, err error) {
+	var stockErr *store.InsufficientStockError
+	switch {
+	case errors.As(err, &stockErr):
+		app.conflictError(w, r, err)
+	case errors.Is(err, store.ErrCouponNotApplicable):
+		app.badRequestError(w, r, err)
+	case errors.Is(err, store.ErrorNotFound):
+		app.notFoundError(w, r, err)
+	default:
+		app.internalServerError(w, r, err)
+	}
+}
+
 func (app *Application) orderContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		idParam := chi.URLParam(r, "orderID")
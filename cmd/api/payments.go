@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/AmiyoKm/book_store/internal/payment"
+	"github.com/AmiyoKm/book_store/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// paymentProviderNone turns online payments off. Orders are then settled by
+// staff through the order status.
+const paymentProviderNone = "none"
+
+type PaymentIntentResponse struct {
+	Payment      *store.Payment `json:"payment"`
+	ClientSecret string         `json:"client_secret"`
+}
+
+// createPaymentHandler godoc
+//
+//	@Summary		Start paying for an order
+//	@Description	Creates a payment intent with the configured provider for the order total
+//	@Tags			payment
+//	@Produce		json
+//	@Param			orderID	path		int						true	"Order ID"
+//	@Success		201		{object}	PaymentIntentResponse	"Created Payment"
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"Order is not awaiting payment"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/orders/{orderID}/payments [post]
+func (app *Application) createPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	if order.UserID != user.ID {
+		app.notFoundError(w, r, store.ErrorNotFound)
+		return
+	}
+	if order.Status != store.OrderStatusPending || order.PaymentMethod == "cash_on_delivery" {
+		app.conflictError(w, r, fmt.Errorf("order is not awaiting an online payment"))
+		return
+	}
+	provider, ok := app.payments[app.cfg.payment.provider]
+	if !ok {
+		app.internalServerError(w, r, fmt.Errorf("payment provider %q is not configured", app.cfg.payment.provider))
+		return
+	}
+
+	ctx := r.Context()
+	intent, err := provider.CreateIntent(ctx, order.ID, order.TotalAmount, app.cfg.payment.currency)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	p := &store.Payment{
+		OrderID:           order.ID,
+		Provider:          provider.Name(),
+		ProviderReference: intent.ID,
+		Amount:            intent.Amount,
+		Currency:          intent.Currency,
+	}
+	if err := app.store.Payments.Create(ctx, p); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, PaymentIntentResponse{Payment: p, ClientSecret: intent.ClientSecret}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getPaymentsHandler godoc
+//
+//	@Summary		List payments of an order
+//	@Description	Lists the payments attempted for an order
+//	@Tags			payment
+//	@Produce		json
+//	@Param			orderID	path		int				true	"Order ID"
+//	@Success		200		{array}		store.Payment	"Payments"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/orders/{orderID}/payments [get]
+func (app *Application) getPaymentsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	if !canAccessOrder(user, order) {
+		app.notFoundError(w, r, store.ErrorNotFound)
+		return
+	}
+	payments, err := app.store.Payments.GetByOrderID(r.Context(), order.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, payments); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// capturePaymentHandler godoc
+//
+//	@Summary		Capture an order payment
+//	@Description	Captures the pending payment of an order with its provider
+//	@Tags			payment
+//	@Produce		json
+//	@Param			orderID	path		int				true	"Order ID"
+//	@Success		200		{object}	store.Payment	"Captured Payment"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/orders/{orderID}/payments/capture [post]
+func (app *Application) capturePaymentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+	ctx := r.Context()
+
+	p, provider, err := app.findOrderPayment(ctx, order.ID, store.PaymentStatusPending)
+	if err != nil {
+		app.paymentError(w, r, err)
+		return
+	}
+	if _, err := provider.Capture(ctx, p.ProviderReference); err != nil {
+		app.paymentError(w, r, err)
+		return
+	}
+	if err := app.store.Payments.UpdateStatus(ctx, p, store.PaymentStatusSucceeded, user.ID); err != nil {
+		app.paymentError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, p); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// paymentWebhookHandler godoc
+//
+//	@Summary		Payment provider webhook
+//	@Description	Receives signed payment notifications and advances the order status. Events whose amount or currency differ from the payment are rejected, as are events that would move a final payment back. A payment that succeeds after its order stopped awaiting payment is flagged with needs_refund.
+//	@Tags			payment
+//	@Accept			json
+//	@Produce		json
+//	@Param			provider	path		string			true	"Provider name"
+//	@Success		200			{object}	store.Payment	"Updated Payment"
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error	"Invalid signature"
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Payment can no longer move to the event's status"
+//	@Failure		500			{object}	error
+//	@Router			/payments/webhooks/{provider} [post]
+func (app *Application) paymentWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	provider, ok := app.payments[chi.URLParam(r, "provider")]
+	if !ok {
+		app.notFoundError(w, r, fmt.Errorf("unknown payment provider"))
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1_048_578))
+	if err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	event, err := provider.VerifyWebhook(body, r.Header)
+	if err != nil {
+		switch {
+		case errors.Is(err, payment.ErrInvalidSignature):
+			app.unauthorizedError(w, r, err)
+		default:
+			app.badRequestError(w, r, err)
+		}
+		return
+	}
+
+	var status string
+	switch event.Type {
+	case payment.EventPaymentSucceeded:
+		status = store.PaymentStatusSucceeded
+	case payment.EventPaymentFailed:
+		status = store.PaymentStatusFailed
+	case payment.EventPaymentRefunded:
+		status = store.PaymentStatusRefunded
+	default:
+		app.badRequestError(w, r, fmt.Errorf("unsupported event type %q", event.Type))
+		return
+	}
+
+	ctx := r.Context()
+	p, err := app.store.Payments.GetByReference(ctx, provider.Name(), event.IntentID)
+	if err != nil {
+		app.paymentError(w, r, err)
+		return
+	}
+	if math.Round(event.Amount*100) != math.Round(p.Amount*100) || !strings.EqualFold(event.Currency, p.Currency) {
+		app.badRequestError(w, r, fmt.Errorf("event for %.2f %s does not match payment of %.2f %s", event.Amount, event.Currency, p.Amount, p.Currency))
+		return
+	}
+	if err := app.store.Payments.UpdateStatus(ctx, p, status, 0); err != nil {
+		app.paymentError(w, r, err)
+		return
+	}
+	if p.NeedsRefund {
+		app.logger.Warnw("payment succeeded on an order that no longer awaits it and needs a refund", "order", p.OrderID, "payment", p.ID)
+	}
+	if err := jsonResponse(w, http.StatusOK, p); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// paymentsEnabled reports whether customers can pay online through the
+// configured provider.
+func (app *Application) paymentsEnabled() bool {
+	_, ok := app.payments[app.cfg.payment.provider]
+	return ok
+}
+
+// refundOrderPayment sends a refund to the provider that collected the
+// order's payment. Orders without a captured payment, such as cash on
+// delivery, are left alone.
+func (app *Application) refundOrderPayment(ctx context.Context, orderID int, amount float64) error {
+	p, provider, err := app.findOrderPayment(ctx, orderID, store.PaymentStatusSucceeded)
+	if err != nil {
+		if errors.Is(err, store.ErrorNotFound) {
+			return nil
+		}
+		return err
+	}
+	return provider.Refund(ctx, p.ProviderReference, amount)
+}
+
+// findOrderPayment returns the latest payment of the order in the given
+// status together with the provider that handles it.
+func (app *Application) findOrderPayment(ctx context.Context, orderID int, status string) (*store.Payment, payment.Provider, error) {
+	payments, err := app.store.Payments.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := len(payments) - 1; i >= 0; i-- {
+		if payments[i].Status != status {
+			continue
+		}
+		provider, ok := app.payments[payments[i].Provider]
+		if !ok {
+			return nil, nil, fmt.Errorf("payment provider %q is not configured", payments[i].Provider)
+		}
+		return &payments[i], provider, nil
+	}
+	return nil, nil, fmt.Errorf("no %s payment for order %d: %w", status, orderID, store.ErrorNotFound)
+}
+
+func (app *Application) paymentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrorNotFound), errors.Is(err, payment.ErrIntentNotFound):
+		app.notFoundError(w, r, err)
+	case errors.Is(err, store.ErrInvalidStatusTransition), errors.Is(err, store.ErrInvalidPaymentTransition):
+		app.conflictError(w, r, err)
+	default:
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mailer "github.com/AmiyoKm/book_store/internal/mail"
+	"github.com/AmiyoKm/book_store/internal/store"
+)
+
+const priceAlertBatchSize = 100
+
+// runPriceAlertNotifier emails the price alerts queued by book price drops
+// every interval. Like stock alerts they are claimed before sending, so a
+// failed email is logged rather than retried.
+func (app *Application) runPriceAlertNotifier(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		alerts, err := app.store.WishLists.ClaimPriceAlerts(ctx, priceAlertBatchSize)
+		if err != nil {
+			app.logger.Errorw("claiming price alerts", "error", err.Error())
+			continue
+		}
+		for _, alert := range alerts {
+			app.sendPriceAlert(alert)
+		}
+	}
+}
+
+func (app *Application) sendPriceAlert(alert *store.PriceAlert) {
+	money := func(amount float32) string {
+		return fmt.Sprintf("%.2f %s", amount, app.cfg.payment.currency)
+	}
+	vars := struct {
+		Username    string
+		Title       string
+		Author      string
+		OldPrice    string
+		NewPrice    string
+		TargetPrice string
+		BookURL     string
+	}{
+		Username: alert.Recipient.Username,
+		Title:    alert.Title,
+		Author:   alert.Author,
+		OldPrice: money(alert.OldPrice),
+		NewPrice: money(alert.NewPrice),
+		BookURL:  fmt.Sprintf("%s/books/%d", app.cfg.frontendURL, alert.BookID),
+	}
+	if alert.TargetPrice != nil {
+		vars.TargetPrice = money(*alert.TargetPrice)
+	}
+
+	isProdEnv := app.cfg.env == "PRODUCTION"
+	if _, err := app.mail.Send(mailer.PriceDropTemplate, alert.Recipient.Username, alert.Recipient.Email, vars, !isProdEnv); err != nil {
+		app.logger.Errorw("error sending price alert email", "alert", alert.ID, "user", alert.Recipient.UserID, "error", err)
+	}
+}
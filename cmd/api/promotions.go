@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type promotionCTX string
+
+const promotionCtx promotionCTX = "promotion"
+
+type createPromotionPayload struct {
+	Code              string     `json:"code" validate:"required,alphanum,min=3,max=50"`
+	Description       string     `json:"description" validate:"max=1000"`
+	DiscountType      string     `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	DiscountValue     float64    `json:"discount_value" validate:"required,gt=0"`
+	Scope             string     `json:"scope" validate:"omitempty,oneof=order tag book"`
+	Tags              []string   `json:"tags" validate:"dive,max=30"`
+	BookIDs           []int64    `json:"book_ids" validate:"dive,min=1"`
+	MinOrderValue     float64    `json:"min_order_value" validate:"gte=0"`
+	UsageLimit        *int       `json:"usage_limit" validate:"omitempty,min=1"`
+	UsageLimitPerUser *int       `json:"usage_limit_per_user" validate:"omitempty,min=1"`
+	StartsAt          *time.Time `json:"starts_at"`
+	EndsAt            *time.Time `json:"ends_at"`
+	IsActive          *bool      `json:"is_active"`
+}
+
+// createPromotionHandler godoc
+//
+//	@Summary		Create a promotion
+//	@Description	Creates a coupon backed promotion with a percentage or fixed discount
+//	@Tags			promotion
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		createPromotionPayload	true	"Promotion Payload"
+//	@Success		201		{object}	store.Promotion			"Created Promotion"
+//	@Failure		400		{object}	error
+//	@Failure		409		{object}	error	"Duplicate coupon code"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/promotions [post]
+func (app *Application) createPromotionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload createPromotionPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	promotion := &store.Promotion{
+		Code:              payload.Code,
+		Description:       payload.Description,
+		DiscountType:      payload.DiscountType,
+		DiscountValue:     payload.DiscountValue,
+		Scope:             payload.Scope,
+		Tags:              payload.Tags,
+		BookIDs:           payload.BookIDs,
+		MinOrderValue:     payload.MinOrderValue,
+		UsageLimit:        payload.UsageLimit,
+		UsageLimitPerUser: payload.UsageLimitPerUser,
+		StartsAt:          payload.StartsAt,
+		EndsAt:            payload.EndsAt,
+		IsActive:          true,
+		CreatedBy:         user.ID,
+	}
+	if promotion.Scope == "" {
+		promotion.Scope = store.PromotionScopeOrder
+	}
+	if payload.IsActive != nil {
+		promotion.IsActive = *payload.IsActive
+	}
+	if err := validatePromotion(promotion); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	if err := app.store.Promotions.Create(r.Context(), promotion); err != nil {
+		switch err {
+		case store.ErrDuplicateCouponCode:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, promotion); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// listPromotionsHandler godoc
+//
+//	@Summary		List promotions
+//	@Description	Lists every promotion, newest first
+//	@Tags			promotion
+//	@Produce		json
+//	@Success		200	{array}		store.Promotion	"Promotions"
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/promotions [get]
+func (app *Application) listPromotionsHandler(w http.ResponseWriter, r *http.Request) {
+	promotions, err := app.store.Promotions.List(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, promotions); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getPromotionHandler godoc
+//
+//	@Summary		Get a promotion
+//	@Description	Get a promotion by its ID
+//	@Tags			promotion
+//	@Produce		json
+//	@Param			promotionID	path		int				true	"Promotion ID"
+//	@Success		200			{object}	store.Promotion	"Promotion"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/promotions/{promotionID} [get]
+func (app *Application) getPromotionHandler(w http.ResponseWriter, r *http.Request) {
+	promotion := getPromotionFromContext(r)
+
+	if err := jsonResponse(w, http.StatusOK, promotion); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type updatePromotionPayload struct {
+	Code              *string    `json:"code" validate:"omitempty,alphanum,min=3,max=50"`
+	Description       *string    `json:"description" validate:"omitempty,max=1000"`
+	DiscountType      *string    `json:"discount_type" validate:"omitempty,oneof=percentage fixed"`
+	DiscountValue     *float64   `json:"discount_value" validate:"omitempty,gt=0"`
+	Scope             *string    `json:"scope" validate:"omitempty,oneof=order tag book"`
+	Tags              *[]string  `json:"tags" validate:"omitempty,dive,max=30"`
+	BookIDs           *[]int64   `json:"book_ids" validate:"omitempty,dive,min=1"`
+	MinOrderValue     *float64   `json:"min_order_value" validate:"omitempty,gte=0"`
+	UsageLimit        *int       `json:"usage_limit" validate:"omitempty,min=1"`
+	UsageLimitPerUser *int       `json:"usage_limit_per_user" validate:"omitempty,min=1"`
+	StartsAt          *time.Time `json:"starts_at"`
+	EndsAt            *time.Time `json:"ends_at"`
+	IsActive          *bool      `json:"is_active"`
+}
+
+// updatePromotionHandler godoc
+//
+//	@Summary		Update a promotion
+//	@Description	Update a promotion by its ID
+//	@Tags			promotion
+//	@Accept			json
+//	@Produce		json
+//	@Param			promotionID	path		int						true	"Promotion ID"
+//	@Param			payload		body		updatePromotionPayload	true	"Update Promotion Payload"
+//	@Success		200			{object}	store.Promotion			"Updated Promotion"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Duplicate coupon code"
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/promotions/{promotionID} [patch]
+func (app *Application) updatePromotionHandler(w http.ResponseWriter, r *http.Request) {
+	promotion := getPromotionFromContext(r)
+
+	var payload updatePromotionPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	if payload.Code != nil {
+		promotion.Code = *payload.Code
+	}
+	if payload.Description != nil {
+		promotion.Description = *payload.Description
+	}
+	if payload.DiscountType != nil {
+		promotion.DiscountType = *payload.DiscountType
+	}
+	if payload.DiscountValue != nil {
+		promotion.DiscountValue = *payload.DiscountValue
+	}
+	if payload.Scope != nil {
+		promotion.Scope = *payload.Scope
+	}
+	if payload.Tags != nil {
+		promotion.Tags = *payload.Tags
+	}
+	if payload.BookIDs != nil {
+		promotion.BookIDs = *payload.BookIDs
+	}
+	if payload.MinOrderValue != nil {
+		promotion.MinOrderValue = *payload.MinOrderValue
+	}
+	if payload.UsageLimit != nil {
+		promotion.UsageLimit = payload.UsageLimit
+	}
+	if payload.UsageLimitPerUser != nil {
+		promotion.UsageLimitPerUser = payload.UsageLimitPerUser
+	}
+	if payload.StartsAt != nil {
+		promotion.StartsAt = payload.StartsAt
+	}
+	if payload.EndsAt != nil {
+		promotion.EndsAt = payload.EndsAt
+	}
+	if payload.IsActive != nil {
+		promotion.IsActive = *payload.IsActive
+	}
+	if err := validatePromotion(promotion); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	if err := app.store.Promotions.Update(r.Context(), promotion); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		case store.ErrDuplicateCouponCode:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, promotion); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// deletePromotionHandler godoc
+//
+//	@Summary		Delete a promotion
+//	@Description	Delete a promotion by its ID
+//	@Tags			promotion
+//	@Param			promotionID	path	int	true	"Promotion ID"
+//	@Success		204			"Promotion deleted"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/promotions/{promotionID} [delete]
+func (app *Application) deletePromotionHandler(w http.ResponseWriter, r *http.Request) {
+	promotion := getPromotionFromContext(r)
+
+	if err := app.store.Promotions.Delete(r.Context(), promotion.ID); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validatePromotion checks the rules that span several promotion fields.
+func validatePromotion(p *store.Promotion) error {
+	switch {
+	case p.DiscountType == store.DiscountTypePercentage && p.DiscountValue > 100:
+		return errors.New("percentage discount cannot exceed 100")
+	case p.Scope == store.PromotionScopeTag && len(p.Tags) == 0:
+		return errors.New("tag scoped promotions need at least one tag")
+	case p.Scope == store.PromotionScopeBook && len(p.BookIDs) == 0:
+		return errors.New("book scoped promotions need at least one book")
+	case p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt):
+		return errors.New("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+func (app *Application) promotionContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idParam := chi.URLParam(r, "promotionID")
+		promotionID, err := strconv.Atoi(idParam)
+		if err != nil {
+			app.notFoundError(w, r, err)
+			return
+		}
+		ctx := r.Context()
+		promotion, err := app.store.Promotions.GetByID(ctx, promotionID)
+		if err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+				return
+			default:
+				app.internalServerError(w, r, err)
+				return
+			}
+		}
+		ctx = context.WithValue(ctx, promotionCtx, promotion)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getPromotionFromContext(r *http.Request) *store.Promotion {
+	promotion, _ := r.Context().Value(promotionCtx).(*store.Promotion)
+	return promotion
+}
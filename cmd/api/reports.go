@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+)
+
+const (
+	defaultReportLimit     = 10
+	reportPeriodDateLayout = "2006-01-02"
+)
+
+type reportQuery struct {
+	Period string `validate:"omitempty,oneof=day week month"`
+	RankBy string `validate:"omitempty,oneof=units revenue"`
+	Format string `validate:"omitempty,oneof=json csv"`
+	Limit  int    `validate:"omitempty,max=100"`
+	store.ReportRange
+}
+
+// readReportQuery parses the query parameters shared by the reports: from
+// and to bound the placed_at date the same way as the admin order listing.
+func (app *Application) readReportQuery(w http.ResponseWriter, r *http.Request) (*reportQuery, bool) {
+	q := r.URL.Query()
+	params := &reportQuery{
+		Period: q.Get("period"),
+		RankBy: q.Get("by"),
+		Format: q.Get("format"),
+	}
+
+	var err error
+	if params.Limit, err = parseIntParam(q.Get("limit")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("limit: %w", err))
+		return nil, false
+	}
+	if params.From, _, err = parseDateParam(q.Get("from")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("from: %w", err))
+		return nil, false
+	}
+	var dateOnly bool
+	if params.To, dateOnly, err = parseDateParam(q.Get("to")); err != nil {
+		app.badRequestError(w, r, fmt.Errorf("to: %w", err))
+		return nil, false
+	}
+	if params.To != nil && dateOnly {
+		end := params.To.AddDate(0, 0, 1)
+		params.To = &end
+	}
+	if err := validate.Struct(params); err != nil {
+		app.badRequestError(w, r, err)
+		return nil, false
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultReportLimit
+	}
+	return params, true
+}
+
+// writeReport answers with the report as JSON, or as a CSV file named after
+// the report when format=csv was asked for.
+func (app *Application) writeReport(w http.ResponseWriter, r *http.Request, params *reportQuery, name string, data any, header []string, rows [][]string) {
+	if params.Format != "csv" {
+		if err := jsonResponse(w, http.StatusOK, data); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	cw, err := csvResponse(w, name+"-"+time.Now().Format("20060102")+".csv", header)
+	if err == nil {
+		err = cw.WriteAll(rows)
+	}
+	if err != nil {
+		app.logger.Errorw("writing report", "path", r.URL.Path, "error", err.Error())
+	}
+}
+
+// getRevenueReportHandler godoc
+//
+//	@Summary		Revenue report
+//	@Description	Sums orders, units sold and revenue per day, week or month. Only paid orders count: pending, cancelled and failed orders are left out. Refunds count once settled.
+//	@Tags			report
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			period	query		string	false	"day (default), week or month"
+//	@Param			from	query		string	false	"Placed at or after, RFC3339 or YYYY-MM-DD"
+//	@Param			to		query		string	false	"Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)"
+//	@Param			format	query		string	false	"json (default) or csv"
+//	@Success		200		{array}		store.RevenuePoint
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/revenue [get]
+func (app *Application) getRevenueReportHandler(w http.ResponseWriter, r *http.Request) {
+	params, ok := app.readReportQuery(w, r)
+	if !ok {
+		return
+	}
+	if params.Period == "" {
+		params.Period = store.ReportPeriodDay
+	}
+
+	points, err := app.store.Reports.Revenue(r.Context(), params.Period, params.ReportRange)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	rows := make([][]string, len(points))
+	for i, p := range points {
+		rows[i] = []string{
+			p.Period.Format(reportPeriodDateLayout),
+			strconv.Itoa(p.Orders),
+			strconv.Itoa(p.Units),
+			formatAmount(p.Gross),
+			formatAmount(p.Refunded),
+			formatAmount(p.Net),
+		}
+	}
+	header := []string{params.Period, "orders", "units", "gross_revenue", "refunded", "net_revenue"}
+	app.writeReport(w, r, params, "revenue-by-"+params.Period, points, header, rows)
+}
+
+// getTopBooksReportHandler godoc
+//
+//	@Summary		Best selling books
+//	@Description	Ranks books by units sold or by revenue
+//	@Tags			report
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			by		query		string	false	"units (default) or revenue"
+//	@Param			limit	query		int		false	"Number of books, at most 100"
+//	@Param			from	query		string	false	"Placed at or after, RFC3339 or YYYY-MM-DD"
+//	@Param			to		query		string	false	"Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)"
+//	@Param			format	query		string	false	"json (default) or csv"
+//	@Success		200		{array}		store.TopBook
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/top-books [get]
+func (app *Application) getTopBooksReportHandler(w http.ResponseWriter, r *http.Request) {
+	params, ok := app.readReportQuery(w, r)
+	if !ok {
+		return
+	}
+
+	books, err := app.store.Reports.TopBooks(r.Context(), params.RankBy, params.Limit, params.ReportRange)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	rows := make([][]string, len(books))
+	for i, b := range books {
+		rows[i] = []string{
+			strconv.Itoa(b.BookID),
+			b.Title,
+			b.Author,
+			b.ISBN,
+			strconv.Itoa(b.Units),
+			formatAmount(b.Revenue),
+		}
+	}
+	header := []string{"book_id", "title", "author", "isbn", "units", "revenue"}
+	app.writeReport(w, r, params, "top-books", books, header, rows)
+}
+
+// getTopAuthorsReportHandler godoc
+//
+//	@Summary		Best selling authors
+//	@Description	Ranks authors by units sold or by revenue across all of their books
+//	@Tags			report
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			by		query		string	false	"units (default) or revenue"
+//	@Param			limit	query		int		false	"Number of authors, at most 100"
+//	@Param			from	query		string	false	"Placed at or after, RFC3339 or YYYY-MM-DD"
+//	@Param			to		query		string	false	"Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)"
+//	@Param			format	query		string	false	"json (default) or csv"
+//	@Success		200		{array}		store.TopAuthor
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/top-authors [get]
+func (app *Application) getTopAuthorsReportHandler(w http.ResponseWriter, r *http.Request) {
+	params, ok := app.readReportQuery(w, r)
+	if !ok {
+		return
+	}
+
+	authors, err := app.store.Reports.TopAuthors(r.Context(), params.RankBy, params.Limit, params.ReportRange)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	rows := make([][]string, len(authors))
+	for i, a := range authors {
+		rows[i] = []string{a.Author, strconv.Itoa(a.Books), strconv.Itoa(a.Units), formatAmount(a.Revenue)}
+	}
+	header := []string{"author", "books", "units", "revenue"}
+	app.writeReport(w, r, params, "top-authors", authors, header, rows)
+}
+
+// getOrderValueReportHandler godoc
+//
+//	@Summary		Average order value
+//	@Description	Counts orders and averages their value, net of settled refunds, and their number of copies. Only paid orders count: pending, cancelled and failed orders are left out.
+//	@Tags			report
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			from	query		string	false	"Placed at or after, RFC3339 or YYYY-MM-DD"
+//	@Param			to		query		string	false	"Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)"
+//	@Param			format	query		string	false	"json (default) or csv"
+//	@Success		200		{object}	store.OrderValueSummary
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/order-value [get]
+func (app *Application) getOrderValueReportHandler(w http.ResponseWriter, r *http.Request) {
+	params, ok := app.readReportQuery(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := app.store.Reports.OrderValue(r.Context(), params.ReportRange)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	rows := [][]string{{
+		strconv.Itoa(summary.Orders),
+		formatAmount(summary.Revenue),
+		formatAmount(summary.AverageOrderValue),
+		formatAmount(summary.AverageItems),
+	}}
+	header := []string{"orders", "revenue", "average_order_value", "average_items"}
+	app.writeReport(w, r, params, "order-value", summary, header, rows)
+}
+
+// getStatusReportHandler godoc
+//
+//	@Summary		Orders by status
+//	@Description	Counts orders and sums their totals per status
+//	@Tags			report
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			from	query		string	false	"Placed at or after, RFC3339 or YYYY-MM-DD"
+//	@Param			to		query		string	false	"Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)"
+//	@Param			format	query		string	false	"json (default) or csv"
+//	@Success		200		{array}		store.StatusCount
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/status [get]
+func (app *Application) getStatusReportHandler(w http.ResponseWriter, r *http.Request) {
+	params, ok := app.readReportQuery(w, r)
+	if !ok {
+		return
+	}
+
+	counts, err := app.store.Reports.StatusBreakdown(r.Context(), params.ReportRange)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	rows := make([][]string, len(counts))
+	for i, c := range counts {
+		rows[i] = []string{c.Status, strconv.Itoa(c.Orders), formatAmount(c.Revenue)}
+	}
+	header := []string{"status", "orders", "total_amount"}
+	app.writeReport(w, r, params, "orders-by-status", counts, header, rows)
+}
+
+// getLowStockReportHandler godoc
+//
+//	@Summary		Low stock books
+//	@Description	Lists books with fewer than threshold copies in stock, emptiest first. The threshold defaults to the one low stock alerts use.
+//	@Tags			report
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			threshold	query		int		false	"Stock threshold, defaults to LOW_STOCK_THRESHOLD"
+//	@Param			format		query		string	false	"json (default) or csv"
+//	@Success		200			{array}		store.LowStockBook
+//	@Failure		400			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/reports/low-stock [get]
+func (app *Application) getLowStockReportHandler(w http.ResponseWriter, r *http.Request) {
+	params, ok := app.readReportQuery(w, r)
+	if !ok {
+		return
+	}
+	threshold := store.LowStockThreshold
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		var err error
+		if threshold, err = parseIntParam(value); err != nil {
+			app.badRequestError(w, r, fmt.Errorf("threshold: %w", err))
+			return
+		}
+	}
+
+	books, err := app.store.Reports.LowStock(r.Context(), threshold)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	rows := make([][]string, len(books))
+	for i, b := range books {
+		rows[i] = []string{strconv.Itoa(b.BookID), b.Title, b.Author, b.ISBN, strconv.Itoa(b.Stock)}
+	}
+	header := []string{"book_id", "title", "author", "isbn", "stock"}
+	app.writeReport(w, r, params, "low-stock", books, header, rows)
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type returnCTX string
+
+const returnCtx returnCTX = "return"
+
+type createReturnPayload struct {
+	OrderItemID int    `json:"order_item_id" validate:"required,min=1"`
+	Quantity    int    `json:"quantity" validate:"required,min=1"`
+	Reason      string `json:"reason" validate:"required,min=5,max=1000"`
+	PhotosURL   string `json:"photos_url" validate:"omitempty,url"`
+}
+
+// createReturnHandler godoc
+//
+//	@Summary		Request a return
+//	@Description	Opens a return request for an item of a delivered order
+//	@Tags			return
+//	@Accept			json
+//	@Produce		json
+//	@Param			orderID	path		int					true	"Order ID"
+//	@Param			payload	body		createReturnPayload	true	"Return Request Payload"
+//	@Success		201		{object}	store.ReturnRequest	"Created Return Request"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"Order is not eligible for returns"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/orders/{orderID}/returns [post]
+func (app *Application) createReturnHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	var payload createReturnPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	ret := &store.ReturnRequest{
+		OrderID:     order.ID,
+		OrderItemID: payload.OrderItemID,
+		UserID:      user.ID,
+		Quantity:    payload.Quantity,
+		Reason:      payload.Reason,
+		PhotosURL:   payload.PhotosURL,
+	}
+	if err := app.store.Returns.Create(r.Context(), ret); err != nil {
+		app.returnError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, ret); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getOrderReturnsHandler godoc
+//
+//	@Summary		List returns of an order
+//	@Description	Lists the return requests opened against an order
+//	@Tags			return
+//	@Produce		json
+//	@Param			orderID	path		int					true	"Order ID"
+//	@Success		200		{array}		store.ReturnRequest	"Return Requests"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/orders/{orderID}/returns [get]
+func (app *Application) getOrderReturnsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	if !canAccessOrder(user, order) {
+		app.notFoundError(w, r, store.ErrorNotFound)
+		return
+	}
+	returns, err := app.store.Returns.GetByOrderID(r.Context(), order.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, returns); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// listReturnsHandler godoc
+//
+//	@Summary		List return requests
+//	@Description	Lists every return request, optionally filtered by status
+//	@Tags			return
+//	@Produce		json
+//	@Param			status	query		string				false	"Return status"	Enums(requested, approved, rejected, received)
+//	@Success		200		{array}		store.ReturnRequest	"Return Requests"
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/returns [get]
+func (app *Application) listReturnsHandler(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if err := validate.Var(status, "omitempty,oneof=requested approved rejected received"); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	returns, err := app.store.Returns.List(r.Context(), status)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, returns); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getReturnHandler godoc
+//
+//	@Summary		Get a return request
+//	@Description	Get a return request by its ID
+//	@Tags			return
+//	@Produce		json
+//	@Param			returnID	path		int					true	"Return Request ID"
+//	@Success		200			{object}	store.ReturnRequest	"Return Request"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/returns/{returnID} [get]
+func (app *Application) getReturnHandler(w http.ResponseWriter, r *http.Request) {
+	ret := getReturnFromContext(r)
+
+	if err := jsonResponse(w, http.StatusOK, ret); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type reviewReturnPayload struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+	Note   string `json:"note" validate:"max=1000"`
+}
+
+// reviewReturnHandler godoc
+//
+//	@Summary		Approve or reject a return
+//	@Description	Approves or rejects a return request that is still awaiting review
+//	@Tags			return
+//	@Accept			json
+//	@Produce		json
+//	@Param			returnID	path		int					true	"Return Request ID"
+//	@Param			payload		body		reviewReturnPayload	true	"Review Payload"
+//	@Success		200			{object}	store.ReturnRequest	"Reviewed Return Request"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Return request was already reviewed"
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/returns/{returnID} [patch]
+func (app *Application) reviewReturnHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ret := getReturnFromContext(r)
+
+	var payload reviewReturnPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	approve := payload.Status == store.ReturnStatusApproved
+	if err := app.store.Returns.Review(r.Context(), ret, approve, user.ID, payload.Note); err != nil {
+		app.returnError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, ret); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type receiveReturnPayload struct {
+	Restock      bool     `json:"restock"`
+	RefundAmount *float64 `json:"refund_amount" validate:"omitempty,gte=0"`
+}
+
+type receiveReturnResponse struct {
+	Return *store.ReturnRequest `json:"return"`
+	Refund *store.Refund        `json:"refund"`
+}
+
+// receiveReturnHandler godoc
+//
+//	@Summary		Receive a returned item
+//	@Description	Marks an approved return as received, optionally restocks the copies and refunds the customer. The refund defaults to the price paid for the returned copies, less their share of the line discount. If the payment provider refuses the refund, the return stays received and the refund is recorded as failed.
+//	@Tags			return
+//	@Accept			json
+//	@Produce		json
+//	@Param			returnID	path		int						true	"Return Request ID"
+//	@Param			payload		body		receiveReturnPayload	true	"Receive Payload"
+//	@Success		200			{object}	receiveReturnResponse	"Received Return"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Return request is not approved"
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/returns/{returnID}/receive [post]
+func (app *Application) receiveReturnHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ret := getReturnFromContext(r)
+
+	var payload receiveReturnPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	ctx := r.Context()
+
+	var refundAmount float64
+	if payload.RefundAmount != nil {
+		refundAmount = *payload.RefundAmount
+	} else {
+		order, err := app.store.Orders.GetByID(ctx, ret.OrderID)
+		if err != nil {
+			app.returnError(w, r, err)
+			return
+		}
+		for _, item := range order.Items {
+			if item.ID == ret.OrderItemID {
+				// the line's discount is shared evenly by its copies
+				discount := item.DiscountAmount * float64(ret.Quantity) / float64(item.Quantity)
+				refundAmount = item.Price*float64(ret.Quantity) - discount
+			}
+		}
+	}
+
+	refund, err := app.store.Returns.Receive(ctx, ret, payload.Restock, refundAmount, user.ID)
+	if err != nil {
+		app.returnError(w, r, err)
+		return
+	}
+	if refund != nil {
+		if err := app.sendRefund(ctx, refund); err != nil {
+			app.paymentError(w, r, err)
+			return
+		}
+	}
+	if err := jsonResponse(w, http.StatusOK, receiveReturnResponse{Return: ret, Refund: refund}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type createRefundPayload struct {
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+	Reason string  `json:"reason" validate:"required,max=1000"`
+}
+
+// createRefundHandler godoc
+//
+//	@Summary		Refund an order
+//	@Description	Refunds part or all of an order through its payment provider. A refund the provider refuses is recorded as failed.
+//	@Tags			return
+//	@Accept			json
+//	@Produce		json
+//	@Param			orderID	path		int					true	"Order ID"
+//	@Param			payload	body		createRefundPayload	true	"Refund Payload"
+//	@Success		201		{object}	store.Refund		"Created Refund"
+//	@Failure		400		{object}	error				"Refund exceeds the amount left to refund"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/orders/{orderID}/refunds [post]
+func (app *Application) createRefundHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	var payload createRefundPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	refund := &store.Refund{
+		OrderID:   order.ID,
+		Amount:    payload.Amount,
+		Reason:    payload.Reason,
+		CreatedBy: user.ID,
+	}
+	ctx := r.Context()
+	if err := app.store.Returns.CreateRefund(ctx, refund); err != nil {
+		app.returnError(w, r, err)
+		return
+	}
+	if err := app.sendRefund(ctx, refund); err != nil {
+		app.paymentError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, refund); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getRefundsHandler godoc
+//
+//	@Summary		List refunds of an order
+//	@Description	Lists the refunds recorded against an order
+//	@Tags			return
+//	@Produce		json
+//	@Param			orderID	path		int				true	"Order ID"
+//	@Success		200		{array}		store.Refund	"Refunds"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/orders/{orderID}/refunds [get]
+func (app *Application) getRefundsHandler(w http.ResponseWriter, r *http.Request) {
+	order := getOrderFromContext(r)
+
+	refunds, err := app.store.Returns.GetRefunds(r.Context(), order.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, refunds); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// sendRefund pays out a refund reserved in the store and settles it, or
+// releases it again when the provider refuses. The outcome is recorded even
+// if the client has gone away.
+func (app *Application) sendRefund(ctx context.Context, refund *store.Refund) error {
+	sendErr := app.refundOrderPayment(ctx, refund.OrderID, refund.Amount)
+	if err := app.store.Returns.CompleteRefund(context.WithoutCancel(ctx), refund, sendErr == nil); err != nil {
+		return errors.Join(sendErr, err)
+	}
+	return sendErr
+}
+
+func (app *Application) returnError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrorNotFound):
+		app.notFoundError(w, r, err)
+	case errors.Is(err, store.ErrReturnQuantityExceeded), errors.Is(err, store.ErrRefundExceedsOrderAmount):
+		app.badRequestError(w, r, err)
+	case errors.Is(err, store.ErrReturnNotAllowed),
+		errors.Is(err, store.ErrInvalidReturnTransition),
+		errors.Is(err, store.ErrInvalidStatusTransition):
+		app.conflictError(w, r, err)
+	default:
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *Application) returnContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idParam := chi.URLParam(r, "returnID")
+		returnID, err := strconv.Atoi(idParam)
+		if err != nil {
+			app.notFoundError(w, r, err)
+			return
+		}
+		ctx := r.Context()
+		ret, err := app.store.Returns.GetByID(ctx, returnID)
+		if err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+				return
+			default:
+				app.internalServerError(w, r, err)
+				return
+			}
+		}
+		ctx = context.WithValue(ctx, returnCtx, ret)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getReturnFromContext(r *http.Request) *store.ReturnRequest {
+	ret, _ := r.Context().Value(returnCtx).(*store.ReturnRequest)
+	return ret
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+type createShipmentPayload struct {
+	Carrier        string                `json:"carrier" validate:"required,max=100"`
+	TrackingNumber string                `json:"tracking_number" validate:"required,max=100"`
+	TrackingURL    string                `json:"tracking_url" validate:"omitempty,url"`
+	Items          []shipmentItemPayload `json:"items" validate:"unique=BookID,dive"`
+}
+
+type shipmentItemPayload struct {
+	BookID   int `json:"book_id" validate:"required,min=1"`
+	Quantity int `json:"quantity" validate:"required,min=1"`
+}
+
+// createShipmentHandler godoc
+//
+//	@Summary		Ship an order
+//	@Description	Records a parcel with its carrier and tracking number. Leave items empty to ship everything not shipped yet; otherwise each book may appear once. The order becomes shipped once all items are covered.
+//	@Tags			shipment
+//	@Accept			json
+//	@Produce		json
+//	@Param			orderID	path		int						true	"Order ID"
+//	@Param			payload	body		createShipmentPayload	true	"Shipment Payload"
+//	@Success		201		{object}	store.Shipment			"Created Shipment"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"Order cannot be shipped"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/orders/{orderID}/shipments [post]
+func (app *Application) createShipmentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	var payload createShipmentPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	shipment := &store.Shipment{
+		OrderID:        order.ID,
+		Carrier:        payload.Carrier,
+		TrackingNumber: payload.TrackingNumber,
+		TrackingURL:    payload.TrackingURL,
+		CreatedBy:      user.ID,
+	}
+	for _, item := range payload.Items {
+		shipment.Items = append(shipment.Items, store.ShipmentItem{
+			BookID:   item.BookID,
+			Quantity: item.Quantity,
+		})
+	}
+
+	if err := app.store.Shipments.Create(r.Context(), shipment); err != nil {
+		app.shipmentError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, shipment); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getShipmentsHandler godoc
+//
+//	@Summary		Track an order
+//	@Description	Lists the parcels of an order with carrier, tracking number and delivery state
+//	@Tags			shipment
+//	@Produce		json
+//	@Param			orderID	path		int				true	"Order ID"
+//	@Success		200		{array}		store.Shipment	"Shipments"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/orders/{orderID}/shipments [get]
+func (app *Application) getShipmentsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	if !canAccessOrder(user, order) {
+		app.notFoundError(w, r, store.ErrorNotFound)
+		return
+	}
+	shipments, err := app.store.Shipments.GetByOrderID(r.Context(), order.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, shipments); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// deliverShipmentHandler godoc
+//
+//	@Summary		Mark a shipment delivered
+//	@Description	Records the delivery of a parcel. The order becomes delivered once every parcel has arrived.
+//	@Tags			shipment
+//	@Produce		json
+//	@Param			orderID		path		int				true	"Order ID"
+//	@Param			shipmentID	path		int				true	"Shipment ID"
+//	@Success		200			{object}	store.Shipment	"Delivered Shipment"
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Already delivered"
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/orders/{orderID}/shipments/{shipmentID}/deliver [post]
+func (app *Application) deliverShipmentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getOrderFromContext(r)
+
+	shipmentID, err := strconv.Atoi(chi.URLParam(r, "shipmentID"))
+	if err != nil {
+		app.notFoundError(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	shipment, err := app.store.Shipments.GetByID(ctx, shipmentID)
+	if err != nil {
+		app.shipmentError(w, r, err)
+		return
+	}
+	if shipment.OrderID != order.ID {
+		app.notFoundError(w, r, store.ErrorNotFound)
+		return
+	}
+
+	if err := app.store.Shipments.MarkDelivered(ctx, shipment, user.ID); err != nil {
+		app.shipmentError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, shipment); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+func (app *Application) shipmentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrShipmentQuantityExceeded), errors.Is(err, store.ErrShipmentItemNotInOrder):
+		app.badRequestError(w, r, err)
+	case errors.Is(err, store.ErrShipmentNotAllowed),
+		errors.Is(err, store.ErrShipmentNothingToShip),
+		errors.Is(err, store.ErrShipmentAlreadyDelivered),
+		errors.Is(err, store.ErrInvalidStatusTransition):
+		app.conflictError(w, r, err)
+	case errors.Is(err, store.ErrorNotFound):
+		app.notFoundError(w, r, err)
+	default:
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/AmiyoKm/book_store/internal/store"
+)
+
+const (
+	defaultStockHistoryLimit = 50
+	maxStockHistoryLimit     = 500
+)
+
+type createStockAdjustmentPayload struct {
+	LocationID int    `json:"location_id" validate:"omitempty,min=1"`
+	Kind       string `json:"kind" validate:"omitempty,oneof=receipt adjustment"`
+	Quantity   int    `json:"quantity" validate:"required"`
+	Reason     string `json:"reason" validate:"required,max=500"`
+}
+
+// createStockAdjustmentHandler godoc
+//
+//	@Summary		Adjust the stock of a book
+//	@Description	Adds (positive quantity) or removes (negative quantity) copies and records the movement in the stock ledger. Kind is receipt for deliveries and adjustment, the default, for corrections such as stock counts or damaged copies. Without a location_id copies are added to the default location and removed from the locations in priority order.
+//	@Tags			stock
+//	@Accept			json
+//	@Produce		json
+//	@Param			bookID	path		int								true	"Book ID"
+//	@Param			payload	body		createStockAdjustmentPayload	true	"Adjustment"
+//	@Success		201		{object}	store.StockMovement				"Recorded movement"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"Stock would go below zero"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/books/{bookID}/stock-adjustments [post]
+func (app *Application) createStockAdjustmentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	book := getBookFromContext(r)
+
+	var payload createStockAdjustmentPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	if payload.LocationID != 0 {
+		if _, err := app.store.Locations.GetByID(r.Context(), payload.LocationID); err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+	}
+
+	movement := &store.StockMovement{
+		BookID:     book.ID,
+		LocationID: payload.LocationID,
+		Kind:       payload.Kind,
+		Quantity:   payload.Quantity,
+		Reason:     payload.Reason,
+		ActorID:    user.ID,
+	}
+	if movement.Kind == "" {
+		movement.Kind = store.StockMovementAdjustment
+	}
+	if movement.Kind == store.StockMovementReceipt && movement.Quantity < 0 {
+		app.badRequestError(w, r, errors.New("a receipt must add stock"))
+		return
+	}
+
+	if err := app.store.StockMovements.Record(r.Context(), movement); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNegativeStock):
+			app.conflictError(w, r, err)
+		case errors.Is(err, store.ErrorNotFound):
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusCreated, movement); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getStockMovementsHandler godoc
+//
+//	@Summary		Stock history of a book
+//	@Description	Lists the book's stock movements newest first. Pass the smallest ID of a page as before to get the next one.
+//	@Tags			stock
+//	@Produce		json
+//	@Param			bookID	path		int	true	"Book ID"
+//	@Param			limit	query		int	false	"Page size, at most 500"
+//	@Param			before	query		int	false	"Only movements with a smaller ID"
+//	@Success		200		{array}		store.StockMovement
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/books/{bookID}/stock-movements [get]
+func (app *Application) getStockMovementsHandler(w http.ResponseWriter, r *http.Request) {
+	book := getBookFromContext(r)
+	q := r.URL.Query()
+
+	limit, err := parseIntParam(q.Get("limit"))
+	if err != nil {
+		app.badRequestError(w, r, fmt.Errorf("limit: %w", err))
+		return
+	}
+	if limit == 0 {
+		limit = defaultStockHistoryLimit
+	}
+	limit = min(limit, maxStockHistoryLimit)
+
+	before, err := parseIntParam(q.Get("before"))
+	if err != nil {
+		app.badRequestError(w, r, fmt.Errorf("before: %w", err))
+		return
+	}
+
+	movements, err := app.store.StockMovements.GetByBookID(r.Context(), book.ID, before, limit)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, movements); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
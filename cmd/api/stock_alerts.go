@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	mailer "github.com/AmiyoKm/book_store/internal/mail"
+	"github.com/AmiyoKm/book_store/internal/store"
+)
+
+const stockAlertBatchSize = 100
+
+// subscribeStockHandler godoc
+//
+//	@Summary		Get notified when a book is back in stock
+//	@Description	Subscribes the authenticated user to a one-time email for when the out-of-stock book is restocked
+//	@Tags			book
+//	@Produce		json
+//	@Param			bookID	path		int						true	"Book ID"
+//	@Success		201		{object}	store.StockSubscription	"Subscription"
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"Book is in stock"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/books/{bookID}/stock-subscription [post]
+func (app *Application) subscribeStockHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	book := getBookFromContext(r)
+
+	sub := &store.StockSubscription{UserID: user.ID, BookID: book.ID}
+	if err := app.store.StockNotifications.Subscribe(r.Context(), sub); err != nil {
+		switch err {
+		case store.ErrBookInStock:
+			app.conflictError(w, r, err)
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, sub); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// unsubscribeStockHandler godoc
+//
+//	@Summary		Stop waiting for a book
+//	@Description	Cancels the authenticated user's pending back in stock notification for the book
+//	@Tags			book
+//	@Param			bookID	path	int	true	"Book ID"
+//	@Success		204		"Subscription cancelled"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/books/{bookID}/stock-subscription [delete]
+func (app *Application) unsubscribeStockHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	book := getBookFromContext(r)
+
+	if err := app.store.StockNotifications.Unsubscribe(r.Context(), user.ID, book.ID); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runStockAlertNotifier emails the stock alerts queued by stock movements
+// every interval: low stock alerts go to moderators and admins, back in stock
+// alerts to the book's subscribers. Alerts are claimed before sending, so a
+// failed email is logged rather than retried and nobody is mailed twice.
+func (app *Application) runStockAlertNotifier(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		alerts, err := app.store.StockNotifications.ClaimAlerts(ctx, stockAlertBatchSize)
+		if err != nil {
+			app.logger.Errorw("claiming stock alerts", "error", err.Error())
+			continue
+		}
+		for _, alert := range alerts {
+			app.sendStockAlert(ctx, alert)
+		}
+	}
+}
+
+func (app *Application) sendStockAlert(ctx context.Context, alert *store.StockAlert) {
+	var (
+		template   string
+		recipients []*store.Recipient
+		err        error
+	)
+	switch alert.Kind {
+	case store.StockAlertLowStock:
+		template = mailer.LowStockTemplate
+		recipients, err = app.store.StockNotifications.GetStaff(ctx)
+	case store.StockAlertBackInStock:
+		template = mailer.BackInStockTemplate
+		recipients, err = app.store.StockNotifications.ClaimSubscribers(ctx, alert.BookID)
+	default:
+		return
+	}
+	if err != nil {
+		app.logger.Errorw("finding stock alert recipients", "alert", alert.ID, "error", err.Error())
+		return
+	}
+
+	isProdEnv := app.cfg.env == "PRODUCTION"
+	for _, recipient := range recipients {
+		vars := struct {
+			Username  string
+			Title     string
+			Author    string
+			Stock     int
+			Threshold int
+			BookURL   string
+		}{
+			Username:  recipient.Username,
+			Title:     alert.Title,
+			Author:    alert.Author,
+			Stock:     alert.Stock,
+			Threshold: store.LowStockThreshold,
+			BookURL:   fmt.Sprintf("%s/books/%d", app.cfg.frontendURL, alert.BookID),
+		}
+		if _, err := app.mail.Send(template, recipient.Username, recipient.Email, vars, !isProdEnv); err != nil {
+			app.logger.Errorw("error sending stock alert email", "alert", alert.ID, "user", recipient.UserID, "error", err)
+		}
+	}
+}
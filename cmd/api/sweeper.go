@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// runReservationSweeper releases expired cart reservations every interval.
+// Availability checks already ignore expired reservations, so the sweeper
+// only keeps the table small; a failed run is logged and retried on the next
+// tick.
+func (app *Application) runReservationSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		released, err := app.store.Carts.ReleaseExpiredReservations(context.Background())
+		if err != nil {
+			app.logger.Errorw("releasing expired cart reservations", "error", err.Error())
+			continue
+		}
+		if released > 0 {
+			app.logger.Infow("released expired cart reservations", "count", released)
+		}
+	}
+}
+
+// runIdempotencyKeySweeper deletes idempotency keys that have outlived the
+// replay window every interval. Expired keys are already taken over by new
+// requests, so like the reservation sweeper it only keeps the table small.
+func (app *Application) runIdempotencyKeySweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deleted, err := app.store.IdempotencyKeys.DeleteExpired(context.Background(), app.cfg.idempotencyTTL)
+		if err != nil {
+			app.logger.Errorw("deleting expired idempotency keys", "error", err.Error())
+			continue
+		}
+		if deleted > 0 {
+			app.logger.Infow("deleted expired idempotency keys", "count", deleted)
+		}
+	}
+}
+
+// searchReindexBatchSize is how many books reindexSearch rebuilds per
+// statement, small enough for each batch to finish well within the query
+// timeout.
+const searchReindexBatchSize = 1000
+
+// reindexSearch brings the search index of every book up to the configured
+// search language in the background, so a change of SEARCH_LANGUAGE does not
+// hold up startup. Until it is done books indexed in the old language keep
+// matching with their old vectors.
+func (app *Application) reindexSearch() {
+	reindexed, err := app.store.Books.ReindexSearch(context.Background(), searchReindexBatchSize)
+	if err != nil {
+		app.logger.Errorw("reindexing book search", "reindexed", reindexed, "error", err.Error())
+		return
+	}
+	if reindexed > 0 {
+		app.logger.Infow("reindexed book search", "language", app.cfg.searchLanguage, "count", reindexed)
+	}
+}
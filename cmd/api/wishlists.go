@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,25 +10,37 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+type wishlistCTX string
+
+const wishlistCtx wishlistCTX = "wishlist"
+
 type addToWishlistPayload struct {
-	BookID int `json:"book_id" validate:"required"`
+	BookID      int      `json:"book_id" validate:"required"`
+	Note        string   `json:"note" validate:"omitempty,max=500"`
+	Priority    int      `json:"priority" validate:"min=0,max=5"`
+	PriceAlert  bool     `json:"price_alert"`
+	TargetPrice *float32 `json:"target_price" validate:"omitempty,gte=0"`
 }
 
 // addToWishlistHandler godoc
 //
 //	@Summary		Add Book to Wishlist
-//	@Description	Add Book to Wishlist
+//	@Description	Add Book to the default wishlist or to a named one. With price_alert the user is emailed when the price drops to target_price, or below the current price if no target is given. Priority goes from 0 to 5, highest first.
 //	@Tags			wishlist
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		addToWishlistPayload	true	"Book ID"
-//	@Success		201		{object}	store.Wishlist			"Create Wishlist"
-//	@Failure		400		{object}	error
-//	@Failure		500		{object}	error
+//	@Param			wishlistID	path		int						false	"Wishlist ID"
+//	@Param			payload		body		addToWishlistPayload	true	"Book ID"
+//	@Success		201			{object}	store.WishlistItem		"Create Wishlist"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Book is already on the wishlist"
+//	@Failure		500			{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/wishlist [post]
+//	@Router			/wishlists/{wishlistID}/items [post]
 func (app *Application) addToWishlistHandler(w http.ResponseWriter, r *http.Request) {
-	user := getUserFromContext(r)
+	wishlist := getWishlistFromContext(r)
 	var payload addToWishlistPayload
 
 	if err := readJson(w, r, &payload); err != nil {
@@ -37,17 +51,28 @@ func (app *Application) addToWishlistHandler(w http.ResponseWriter, r *http.Requ
 		app.badRequestError(w, r, err)
 		return
 	}
-	wishlist := &store.Wishlist{
-		UserID: user.ID,
-		BookID: payload.BookID,
+	item := &store.WishlistItem{
+		WishlistID:   wishlist.ID,
+		BookID:       payload.BookID,
+		Note:         payload.Note,
+		Priority:     payload.Priority,
+		AlertEnabled: payload.PriceAlert,
+		TargetPrice:  payload.TargetPrice,
 	}
 	ctx := r.Context()
-	err := app.store.WishLists.Create(ctx, wishlist)
+	err := app.store.WishLists.AddItem(ctx, item)
 	if err != nil {
-		app.internalServerError(w, r, err)
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		case store.ErrDuplicateWishlistItem:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
 		return
 	}
-	if err := jsonResponse(w, http.StatusCreated, wishlist); err != nil {
+	if err := jsonResponse(w, http.StatusCreated, item); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
@@ -56,7 +81,7 @@ func (app *Application) addToWishlistHandler(w http.ResponseWriter, r *http.Requ
 // getWishlistHandler godoc
 //
 //	@Summary		Get Book Wishlist
-//	@Description	Get Book Wishlist
+//	@Description	Get the books on the default wishlist
 //	@Tags			wishlist
 //	@Accept			json
 //	@Produce		json
@@ -66,21 +91,103 @@ func (app *Application) addToWishlistHandler(w http.ResponseWriter, r *http.Requ
 //	@Security		ApiKeyAuth
 //	@Router			/wishlist [get]
 func (app *Application) getWishlistHandler(w http.ResponseWriter, r *http.Request) {
-	user := getUserFromContext(r)
+	wishlist := getWishlistFromContext(r)
 
-	books, err := app.store.WishLists.GetWishlistBooks(r.Context(), user.ID)
+	items, err := app.store.WishLists.GetItems(r.Context(), wishlist.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	books := make([]*store.Book, 0, len(items))
+	for _, item := range items {
+		books = append(books, item.Book)
+	}
+	if err := jsonResponse(w, http.StatusOK, books); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type updateWishlistItemPayload struct {
+	Note        *string  `json:"note" validate:"omitempty,max=500"`
+	Priority    *int     `json:"priority" validate:"omitempty,min=0,max=5"`
+	PriceAlert  *bool    `json:"price_alert"`
+	TargetPrice *float32 `json:"target_price" validate:"omitempty,gte=0"`
+	ClearTarget bool     `json:"clear_target"`
+}
 
+// updateWishlistItemHandler godoc
+//
+//	@Summary		Update a wishlisted book
+//	@Description	Changes the note and priority of a wishlisted book, turns its price drop email on or off and sets or clears its target price. Without a target the alert fires when the price drops below the price at the time of wishlisting.
+//	@Tags			wishlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			wishlistID	path		int							false	"Wishlist ID"
+//	@Param			bookID		path		int							true	"BOOK ID"
+//	@Param			payload		body		updateWishlistItemPayload	true	"Item settings"
+//	@Success		200			{object}	store.WishlistItem			"Updated Wishlist"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/wishlist/{bookID} [patch]
+//	@Router			/wishlists/{wishlistID}/items/{bookID} [patch]
+func (app *Application) updateWishlistItemHandler(w http.ResponseWriter, r *http.Request) {
+	wishlist := getWishlistFromContext(r)
+	bookID, err := strconv.Atoi(chi.URLParam(r, "bookID"))
+	if err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	var payload updateWishlistItemPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	item, err := app.store.WishLists.GetItem(ctx, wishlist.ID, bookID)
 	if err != nil {
 		switch err {
 		case store.ErrorNotFound:
 			app.notFoundError(w, r, err)
-			return
 		default:
 			app.internalServerError(w, r, err)
-			return
 		}
+		return
 	}
-	if err := jsonResponse(w, http.StatusOK, books); err != nil {
+	if payload.Note != nil {
+		item.Note = *payload.Note
+	}
+	if payload.Priority != nil {
+		item.Priority = *payload.Priority
+	}
+	if payload.PriceAlert != nil {
+		item.AlertEnabled = *payload.PriceAlert
+	}
+	if payload.TargetPrice != nil {
+		item.TargetPrice = payload.TargetPrice
+	}
+	if payload.ClearTarget {
+		item.TargetPrice = nil
+	}
+
+	if err := app.store.WishLists.UpdateItem(ctx, item); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, item); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
@@ -94,25 +201,28 @@ type removeWishlistResponse struct {
 // removeFromWishlistHandler godoc
 //
 //	@Summary		Delete Book From Wishlist
-//	@Description	Delete Book From Wishlist
+//	@Description	Delete Book From the default wishlist or from a named one
 //	@Tags			wishlist
 //	@Accept			json
 //	@Produce		json
-//	@Param			bookID	path		int						true	"BOOK ID"
-//	@Success		200		{object}	removeWishlistResponse	"DELETED RESPONSE"
-//	@Failure		400		{object}	error
-//	@Failure		500		{object}	error
+//	@Param			wishlistID	path		int						false	"Wishlist ID"
+//	@Param			bookID		path		int						true	"BOOK ID"
+//	@Success		200			{object}	removeWishlistResponse	"DELETED RESPONSE"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/wishlist/{bookID} [delete]
+//	@Router			/wishlists/{wishlistID}/items/{bookID} [delete]
 func (app *Application) removeFromWishlistHandler(w http.ResponseWriter, r *http.Request) {
-	user := getUserFromContext(r)
+	wishlist := getWishlistFromContext(r)
 	paramID := chi.URLParam(r, "bookID")
 	bookID, err := strconv.Atoi(paramID)
 	if err != nil {
 		app.badRequestError(w, r, err)
 		return
 	}
-	err = app.store.WishLists.Delete(r.Context(), user.ID, bookID)
+	err = app.store.WishLists.RemoveItem(r.Context(), wishlist.ID, bookID)
 	if err != nil {
 		switch err {
 		case store.ErrorNotFound:
@@ -132,3 +242,410 @@ func (app *Application) removeFromWishlistHandler(w http.ResponseWriter, r *http
 		return
 	}
 }
+
+type createWishlistPayload struct {
+	Name       string `json:"name" validate:"required,max=100"`
+	Visibility string `json:"visibility" validate:"omitempty,oneof=private unlisted public"`
+}
+
+// createWishlistHandler godoc
+//
+//	@Summary		Create a wishlist
+//	@Description	Creates a named wishlist. Private lists, the default, are only visible to their owner; unlisted ones to anybody with the share link; public ones are also listed on the owner's profile.
+//	@Tags			wishlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		createWishlistPayload	true	"Wishlist"
+//	@Success		201		{object}	store.Wishlist			"Created Wishlist"
+//	@Failure		400		{object}	error
+//	@Failure		409		{object}	error	"Name already in use"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/wishlists [post]
+func (app *Application) createWishlistHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload createWishlistPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	wishlist := &store.Wishlist{
+		UserID:     user.ID,
+		Name:       payload.Name,
+		Visibility: payload.Visibility,
+	}
+	if wishlist.Visibility == "" {
+		wishlist.Visibility = store.WishlistPrivate
+	}
+
+	if err := app.store.WishLists.CreateList(r.Context(), wishlist); err != nil {
+		switch err {
+		case store.ErrDuplicateWishlistName:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusCreated, wishlist); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// listWishlistsHandler godoc
+//
+//	@Summary		List my wishlists
+//	@Description	Lists the authenticated user's wishlists, the default one first
+//	@Tags			wishlist
+//	@Produce		json
+//	@Success		200	{array}		store.Wishlist
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/wishlists [get]
+func (app *Application) listWishlistsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	if _, err := app.store.WishLists.GetDefaultList(ctx, user.ID); err != nil && err != store.ErrorNotFound {
+		app.internalServerError(w, r, err)
+		return
+	}
+	wishlists, err := app.store.WishLists.GetLists(ctx, user.ID, false)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, wishlists); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getPublicWishlistsHandler godoc
+//
+//	@Summary		List a user's public wishlists
+//	@Description	Lists the wishlists the user made public. Open one through its share token.
+//	@Tags			wishlist
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		200		{array}		store.Wishlist
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/wishlists [get]
+func (app *Application) getPublicWishlistsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	wishlists, err := app.store.WishLists.GetLists(r.Context(), userID, true)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, wishlists); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type WishlistResponse struct {
+	*store.Wishlist
+	Items []*store.WishlistItem `json:"items"`
+}
+
+// getWishlistByIDHandler godoc
+//
+//	@Summary		Get a wishlist
+//	@Description	Get one of the authenticated user's wishlists with its books, highest priority first
+//	@Tags			wishlist
+//	@Produce		json
+//	@Param			wishlistID	path		int					true	"Wishlist ID"
+//	@Success		200			{object}	WishlistResponse	"Wishlist"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/wishlists/{wishlistID} [get]
+func (app *Application) getWishlistByIDHandler(w http.ResponseWriter, r *http.Request) {
+	wishlist := getWishlistFromContext(r)
+
+	items, err := app.store.WishLists.GetItems(r.Context(), wishlist.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, WishlistResponse{Wishlist: wishlist, Items: items}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type updateWishlistPayload struct {
+	Name            *string `json:"name" validate:"omitempty,max=100"`
+	Visibility      *string `json:"visibility" validate:"omitempty,oneof=private unlisted public"`
+	RegenerateToken bool    `json:"regenerate_share_token"`
+}
+
+// updateWishlistHandler godoc
+//
+//	@Summary		Update a wishlist
+//	@Description	Renames a wishlist or changes who can see it. With regenerate_share_token the list gets a new share token and links handed out earlier stop working.
+//	@Tags			wishlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			wishlistID	path		int						true	"Wishlist ID"
+//	@Param			payload		body		updateWishlistPayload	true	"Wishlist"
+//	@Success		200			{object}	store.Wishlist			"Updated Wishlist"
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Name already in use"
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/wishlists/{wishlistID} [patch]
+func (app *Application) updateWishlistHandler(w http.ResponseWriter, r *http.Request) {
+	wishlist := getWishlistFromContext(r)
+
+	var payload updateWishlistPayload
+	if err := readJson(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if err := validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err)
+		return
+	}
+	if payload.Name != nil {
+		wishlist.Name = *payload.Name
+	}
+	if payload.Visibility != nil {
+		wishlist.Visibility = *payload.Visibility
+	}
+
+	if err := app.store.WishLists.UpdateList(r.Context(), wishlist, payload.RegenerateToken); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		case store.ErrDuplicateWishlistName:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	if err := jsonResponse(w, http.StatusOK, wishlist); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// deleteWishlistHandler godoc
+//
+//	@Summary		Delete a wishlist
+//	@Description	Deletes a named wishlist and its books. The default wishlist cannot be deleted.
+//	@Tags			wishlist
+//	@Param			wishlistID	path	int	true	"Wishlist ID"
+//	@Success		204			"Wishlist deleted"
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"Default wishlist"
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/wishlists/{wishlistID} [delete]
+func (app *Application) deleteWishlistHandler(w http.ResponseWriter, r *http.Request) {
+	wishlist := getWishlistFromContext(r)
+
+	if err := app.store.WishLists.DeleteList(r.Context(), wishlist.ID); err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		case store.ErrDefaultWishlist:
+			app.conflictError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type moveToCartFailure struct {
+	BookID int    `json:"book_id"`
+	Reason string `json:"reason"`
+}
+
+type moveToCartResponse struct {
+	Moved  []int               `json:"moved"`
+	Failed []moveToCartFailure `json:"failed"`
+}
+
+// moveWishlistToCartHandler godoc
+//
+//	@Summary		Move a wishlist to the cart
+//	@Description	Adds one copy of every book on the wishlist to the cart and takes the books that were added off the list, one book at a time. Books already in the cart only leave the list and keep their quantity. Books that are out of stock or no longer sold stay on the list and are reported as failed.
+//	@Tags			wishlist
+//	@Produce		json
+//	@Param			wishlistID	path		int					true	"Wishlist ID"
+//	@Success		200			{object}	moveToCartResponse	"Moved and failed books"
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/wishlists/{wishlistID}/move-to-cart [post]
+func (app *Application) moveWishlistToCartHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	wishlist := getWishlistFromContext(r)
+	ctx := r.Context()
+
+	cart, err := app.store.Carts.GetOrCreateCart(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	items, err := app.store.WishLists.GetItems(ctx, wishlist.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	res := moveToCartResponse{Moved: []int{}, Failed: []moveToCartFailure{}}
+	for _, item := range items {
+		err := app.store.Carts.MoveFromWishlist(ctx, cart.ID, wishlist.ID, item.BookID, app.cfg.cartReservationTTL)
+		var stockErr *store.InsufficientStockError
+		switch {
+		case err == nil:
+			res.Moved = append(res.Moved, item.BookID)
+		case errors.As(err, &stockErr), errors.Is(err, store.ErrorNotFound):
+			res.Failed = append(res.Failed, moveToCartFailure{BookID: item.BookID, Reason: err.Error()})
+		default:
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := jsonResponse(w, http.StatusOK, res); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type sharedWishlistItem struct {
+	Book     *store.Book `json:"book"`
+	Note     string      `json:"note,omitempty"`
+	Priority int         `json:"priority"`
+}
+
+type SharedWishlistResponse struct {
+	Name  string               `json:"name"`
+	Owner string               `json:"owner"`
+	Items []sharedWishlistItem `json:"items"`
+}
+
+// getSharedWishlistHandler godoc
+//
+//	@Summary		View a shared wishlist
+//	@Description	Read-only view of an unlisted or public wishlist for gift-givers. No login is needed; the token comes from the owner's share link.
+//	@Tags			wishlist
+//	@Produce		json
+//	@Param			token	path		string					true	"Share token"
+//	@Success		200		{object}	SharedWishlistResponse	"Wishlist"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/wishlists/shared/{token} [get]
+func (app *Application) getSharedWishlistHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	wishlist, err := app.store.WishLists.GetListByToken(ctx, chi.URLParam(r, "token"))
+	if err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	owner, err := app.store.Users.GetByID(ctx, wishlist.UserID)
+	if err != nil {
+		switch err {
+		case store.ErrorNotFound:
+			app.notFoundError(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	items, err := app.store.WishLists.GetItems(ctx, wishlist.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	res := SharedWishlistResponse{Name: wishlist.Name, Owner: owner.Username, Items: make([]sharedWishlistItem, 0, len(items))}
+	for _, item := range items {
+		res.Items = append(res.Items, sharedWishlistItem{Book: item.Book, Note: item.Note, Priority: item.Priority})
+	}
+	if err := jsonResponse(w, http.StatusOK, res); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// wishlistContextMiddleware loads one of the authenticated user's wishlists.
+// Other users' lists are not found.
+func (app *Application) wishlistContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromContext(r)
+		wishlistID, err := strconv.Atoi(chi.URLParam(r, "wishlistID"))
+		if err != nil {
+			app.notFoundError(w, r, err)
+			return
+		}
+		ctx := r.Context()
+		wishlist, err := app.store.WishLists.GetListByID(ctx, wishlistID)
+		if err == nil && wishlist.UserID != user.ID {
+			err = store.ErrorNotFound
+		}
+		if err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		ctx = context.WithValue(ctx, wishlistCtx, wishlist)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// defaultWishlistMiddleware loads the authenticated user's default wishlist,
+// which the /wishlist routes work on.
+func (app *Application) defaultWishlistMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromContext(r)
+		ctx := r.Context()
+		wishlist, err := app.store.WishLists.GetDefaultList(ctx, user.ID)
+		if err != nil {
+			switch err {
+			case store.ErrorNotFound:
+				app.notFoundError(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		ctx = context.WithValue(ctx, wishlistCtx, wishlist)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getWishlistFromContext(r *http.Request) *store.Wishlist {
+	wishlist, _ := r.Context().Value(wishlistCtx).(*store.Wishlist)
+	return wishlist
+}
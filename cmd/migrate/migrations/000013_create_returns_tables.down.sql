@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS refunds;
+DROP TABLE IF EXISTS return_requests;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS refunded_amount;
@@ -0,0 +1,10 @@
+ALTER TABLE order_items
+DROP COLUMN IF EXISTS discount_amount;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS coupon_code,
+DROP COLUMN IF EXISTS discount_amount,
+DROP COLUMN IF EXISTS subtotal_amount;
+
+DROP TABLE IF EXISTS promotion_redemptions;
+DROP TABLE IF EXISTS promotions;
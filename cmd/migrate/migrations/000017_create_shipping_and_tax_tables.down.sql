@@ -0,0 +1,7 @@
+ALTER TABLE orders
+DROP COLUMN IF EXISTS tax_amount,
+DROP COLUMN IF EXISTS shipping_amount,
+DROP COLUMN IF EXISTS shipping_region;
+
+DROP TABLE IF EXISTS tax_rates;
+DROP TABLE IF EXISTS shipping_rates;
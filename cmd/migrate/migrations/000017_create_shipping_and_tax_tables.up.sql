@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS shipping_rates (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    region VARCHAR(100) NOT NULL DEFAULT '',
+    method VARCHAR(20) NOT NULL CHECK (method IN ('flat', 'per_item', 'page_count')),
+    base_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (base_amount >= 0),
+    per_unit_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (per_unit_amount >= 0),
+    unit_size INT NOT NULL DEFAULT 1 CHECK (unit_size > 0),
+    free_over DECIMAL(10,2),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipping_rates_region ON shipping_rates(region);
+
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id BIGSERIAL PRIMARY KEY,
+    region VARCHAR(100) NOT NULL UNIQUE,
+    rate DECIMAL(6,4) NOT NULL CHECK (rate >= 0 AND rate < 1),
+    applies_to_shipping BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE orders
+ADD COLUMN IF NOT EXISTS shipping_region VARCHAR(100) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS shipping_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE orders
+DROP COLUMN IF EXISTS shipping_details,
+DROP COLUMN IF EXISTS address_id;
+
+DROP TABLE IF EXISTS user_addresses;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    book_id BIGINT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('receipt', 'sale', 'return', 'adjustment')),
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    stock_after INT NOT NULL CHECK (stock_after >= 0),
+    reason TEXT,
+    order_id BIGINT REFERENCES orders(id) ON DELETE SET NULL,
+    actor_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_book_id ON stock_movements(book_id, id);
+
+INSERT INTO stock_movements (book_id, kind, quantity, stock_after, reason)
+SELECT id, 'receipt', stock, stock, 'opening balance'
+FROM books
+WHERE stock > 0;
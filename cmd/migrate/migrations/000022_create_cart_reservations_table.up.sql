@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS cart_reservations (
+    cart_id BIGINT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    book_id BIGINT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cart_id, book_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cart_reservations_book_id ON cart_reservations(book_id, expires_at);
+CREATE INDEX IF NOT EXISTS idx_cart_reservations_expires_at ON cart_reservations(expires_at);
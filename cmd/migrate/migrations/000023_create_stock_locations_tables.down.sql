@@ -0,0 +1,10 @@
+DELETE FROM stock_movements WHERE kind = 'transfer';
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_kind_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_kind_check
+    CHECK (kind IN ('receipt', 'sale', 'return', 'adjustment'));
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS location_id;
+
+DROP TABLE IF EXISTS stock_transfers;
+DROP TABLE IF EXISTS order_item_allocations;
+DROP TABLE IF EXISTS book_stock;
+DROP TABLE IF EXISTS stock_locations;
@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS stock_locations (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    region VARCHAR(100) NOT NULL DEFAULT '',
+    priority INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO stock_locations (code, name, priority) VALUES ('MAIN', 'Main warehouse', 0);
+
+CREATE TABLE IF NOT EXISTS book_stock (
+    book_id BIGINT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    location_id BIGINT NOT NULL REFERENCES stock_locations(id) ON DELETE RESTRICT,
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    PRIMARY KEY (book_id, location_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_stock_location_id ON book_stock(location_id);
+
+INSERT INTO book_stock (book_id, location_id, quantity)
+SELECT b.id, l.id, b.stock
+FROM books b, stock_locations l
+WHERE l.code = 'MAIN' AND b.stock > 0;
+
+CREATE TABLE IF NOT EXISTS order_item_allocations (
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    location_id BIGINT NOT NULL REFERENCES stock_locations(id) ON DELETE RESTRICT,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (order_item_id, location_id)
+);
+
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    book_id BIGINT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    from_location_id BIGINT NOT NULL REFERENCES stock_locations(id) ON DELETE RESTRICT,
+    to_location_id BIGINT NOT NULL REFERENCES stock_locations(id) ON DELETE RESTRICT,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    note TEXT,
+    actor_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (from_location_id <> to_location_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_book_id ON stock_transfers(book_id, id);
+
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS location_id BIGINT REFERENCES stock_locations(id) ON DELETE RESTRICT;
+
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_kind_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_kind_check
+    CHECK (kind IN ('receipt', 'sale', 'return', 'adjustment', 'transfer'));
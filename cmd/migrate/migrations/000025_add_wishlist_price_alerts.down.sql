@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS price_alerts;
+
+ALTER TABLE wishlists DROP COLUMN IF EXISTS last_alerted_price;
+ALTER TABLE wishlists DROP COLUMN IF EXISTS target_price;
+ALTER TABLE wishlists DROP COLUMN IF EXISTS alert_enabled;
+ALTER TABLE wishlists DROP COLUMN IF EXISTS price_at_add;
@@ -0,0 +1,19 @@
+ALTER TABLE wishlists ADD COLUMN IF NOT EXISTS price_at_add DECIMAL(10,2);
+ALTER TABLE wishlists ADD COLUMN IF NOT EXISTS alert_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE wishlists ADD COLUMN IF NOT EXISTS target_price DECIMAL(10,2) CHECK (target_price >= 0);
+ALTER TABLE wishlists ADD COLUMN IF NOT EXISTS last_alerted_price DECIMAL(10,2);
+
+UPDATE wishlists w SET price_at_add = b.price FROM books b WHERE b.id = w.book_id AND w.price_at_add IS NULL;
+
+ALTER TABLE wishlists ALTER COLUMN price_at_add SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS price_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    wishlist_id BIGINT NOT NULL REFERENCES wishlists(id) ON DELETE CASCADE,
+    old_price DECIMAL(10,2) NOT NULL,
+    new_price DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_alerts_pending ON price_alerts(id) WHERE processed_at IS NULL;
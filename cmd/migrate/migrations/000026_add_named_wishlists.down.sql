@@ -0,0 +1,16 @@
+ALTER TABLE price_alerts RENAME COLUMN wishlist_item_id TO wishlist_id;
+
+DROP INDEX IF EXISTS idx_wishlist_items_book_id;
+
+DELETE FROM wishlist_items i
+WHERE i.id NOT IN (SELECT MIN(id) FROM wishlist_items GROUP BY user_id, book_id);
+
+ALTER TABLE wishlist_items DROP CONSTRAINT IF EXISTS wishlist_items_wishlist_id_book_id_key;
+ALTER TABLE wishlist_items ADD CONSTRAINT wishlists_user_id_book_id_key UNIQUE (user_id, book_id);
+ALTER TABLE wishlist_items DROP COLUMN IF EXISTS priority;
+ALTER TABLE wishlist_items DROP COLUMN IF EXISTS note;
+ALTER TABLE wishlist_items DROP COLUMN IF EXISTS wishlist_id;
+
+DROP TABLE IF EXISTS wishlists;
+
+ALTER TABLE wishlist_items RENAME TO wishlists;
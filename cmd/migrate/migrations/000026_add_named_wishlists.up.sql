@@ -0,0 +1,34 @@
+ALTER TABLE wishlists RENAME TO wishlist_items;
+
+CREATE TABLE IF NOT EXISTS wishlists (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    visibility VARCHAR(10) NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'unlisted', 'public')),
+    share_token VARCHAR(64) NOT NULL UNIQUE DEFAULT replace(gen_random_uuid()::text, '-', ''),
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wishlists_default ON wishlists(user_id) WHERE is_default;
+
+INSERT INTO wishlists (user_id, name, is_default)
+SELECT DISTINCT user_id, 'My Wishlist', TRUE FROM wishlist_items WHERE user_id IS NOT NULL;
+
+ALTER TABLE wishlist_items ADD COLUMN IF NOT EXISTS wishlist_id BIGINT REFERENCES wishlists(id) ON DELETE CASCADE;
+ALTER TABLE wishlist_items ADD COLUMN IF NOT EXISTS note VARCHAR(500);
+ALTER TABLE wishlist_items ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 5);
+
+UPDATE wishlist_items i SET wishlist_id = w.id FROM wishlists w WHERE w.user_id = i.user_id AND w.is_default;
+
+DELETE FROM wishlist_items WHERE wishlist_id IS NULL;
+
+ALTER TABLE wishlist_items ALTER COLUMN wishlist_id SET NOT NULL;
+ALTER TABLE wishlist_items DROP CONSTRAINT IF EXISTS wishlists_user_id_book_id_key;
+ALTER TABLE wishlist_items ADD CONSTRAINT wishlist_items_wishlist_id_book_id_key UNIQUE (wishlist_id, book_id);
+
+CREATE INDEX IF NOT EXISTS idx_wishlist_items_book_id ON wishlist_items(book_id);
+
+ALTER TABLE price_alerts RENAME COLUMN wishlist_id TO wishlist_item_id;
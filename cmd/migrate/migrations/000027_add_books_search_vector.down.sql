@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_books_search_vector;
+
+DROP TRIGGER IF EXISTS books_search_vector_trigger ON books;
+
+ALTER TABLE books DROP COLUMN IF EXISTS search_language;
+ALTER TABLE books DROP COLUMN IF EXISTS search_vector;
+
+DROP FUNCTION IF EXISTS books_search_vector_update();
+DROP FUNCTION IF EXISTS books_search_vector(REGCONFIG, TEXT, TEXT, TEXT[], TEXT);
+
+DROP TABLE IF EXISTS search_settings;
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS search_settings (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    language REGCONFIG NOT NULL DEFAULT 'english'
+);
+
+INSERT INTO search_settings (id) VALUES (TRUE) ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION books_search_vector(lang REGCONFIG, title TEXT, author TEXT, tags TEXT[], description TEXT)
+RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector(lang, COALESCE(title, '')), 'A')
+        || setweight(to_tsvector(lang, COALESCE(author, '')), 'B')
+        || setweight(to_tsvector(lang, COALESCE(array_to_string(tags, ' '), '')), 'C')
+        || setweight(to_tsvector(lang, COALESCE(description, '')), 'D')
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION books_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_language := (SELECT language FROM search_settings);
+    NEW.search_vector := books_search_vector(
+        NEW.search_language,
+        NEW.title, NEW.author, NEW.tags, NEW.description
+    );
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+-- search_language is the configuration search_vector was built with, so a
+-- change of language can be reindexed in batches
+ALTER TABLE books ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS search_language REGCONFIG;
+
+UPDATE books SET search_vector = books_search_vector(s.language, title, author, tags, description), search_language = s.language
+FROM search_settings s;
+
+CREATE TRIGGER books_search_vector_trigger
+BEFORE INSERT OR UPDATE OF title, author, tags, description ON books
+FOR EACH ROW EXECUTE FUNCTION books_search_vector_update();
+
+CREATE INDEX IF NOT EXISTS idx_books_search_vector ON books USING GIN (search_vector);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_books_title_id;
+DROP INDEX IF EXISTS idx_books_created_at_id;
+DROP INDEX IF EXISTS idx_books_price_id;
+DROP INDEX IF EXISTS idx_reviews_book_id;
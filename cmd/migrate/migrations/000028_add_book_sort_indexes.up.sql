@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_reviews_book_id ON reviews(book_id);
+CREATE INDEX IF NOT EXISTS idx_books_price_id ON books(price, id);
+CREATE INDEX IF NOT EXISTS idx_books_created_at_id ON books(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_books_title_id ON books(title, id);
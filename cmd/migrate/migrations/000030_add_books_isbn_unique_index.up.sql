@@ -0,0 +1,21 @@
+-- books.isbn was never unique, so refuse to build the index over duplicates
+-- and name them instead of failing on the first conflicting row
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(isbn || ' (books ' || ids || ')', ', ')
+    INTO duplicates
+    FROM (
+        SELECT isbn, string_agg(id::TEXT, ', ' ORDER BY id) AS ids
+        FROM books
+        GROUP BY isbn
+        HAVING COUNT(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'books share an ISBN, merge or correct them before migrating: %', duplicates;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn ON books(isbn);
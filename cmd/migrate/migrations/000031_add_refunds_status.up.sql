@@ -0,0 +1,4 @@
+ALTER TABLE refunds
+ADD COLUMN IF NOT EXISTS status VARCHAR(50) NOT NULL DEFAULT 'settled';
+
+ALTER TABLE refunds ALTER COLUMN status SET DEFAULT 'pending';
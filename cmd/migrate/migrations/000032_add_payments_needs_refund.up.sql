@@ -0,0 +1,2 @@
+ALTER TABLE payments
+ADD COLUMN IF NOT EXISTS needs_refund BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_idempotency_keys_created_at;
+
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS response_content_type;
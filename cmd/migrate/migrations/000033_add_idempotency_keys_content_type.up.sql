@@ -0,0 +1,4 @@
+ALTER TABLE idempotency_keys
+ADD COLUMN IF NOT EXISTS response_content_type VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
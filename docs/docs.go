@@ -24,99 +24,56 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/admin/orders/{id}": {
-            "patch": {
+        "/admin/books/import": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update an order by Admin",
+                "description": "Creates or updates books in bulk from a CSV file with a header row or from NDJSON, one createBookPayload object per line. Columns and fields are named like those of createBookPayload and validated the same way; CSV tags are separated by |. Books are matched by ISBN: new ones are created, existing ones updated and their stock set to the imported level. The format comes from the format parameter or the Content-Type (text/csv or application/x-ndjson). Rows that fail are reported and do not stop the others. Rows are committed batch_size at a time; dry_run reports what would change without writing anything.",
                 "consumes": [
-                    "application/json"
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "order"
+                    "book"
                 ],
-                "summary": "Update an order by Admin",
+                "summary": "Import books",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Order ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "csv or ndjson",
+                        "name": "format",
+                        "in": "query"
                     },
                     {
-                        "description": "Update Order Payload by Admin",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.updateOrderAdminPayload"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Updated Order",
-                        "schema": {
-                            "$ref": "#/definitions/store.Order"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request",
-                        "schema": {}
+                        "type": "integer",
+                        "description": "Rows per transaction, defaults to 500",
+                        "name": "batch_size",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "Server error",
-                        "schema": {}
-                    }
-                }
-            }
-        },
-        "/authentication/token": {
-            "post": {
-                "description": "Creates a token for a user",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "authentication"
-                ],
-                "summary": "Creates a token",
-                "parameters": [
                     {
-                        "description": "User credentials",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.loginUserPayload"
-                        }
+                        "type": "boolean",
+                        "description": "Report what would change without writing",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Token",
+                        "description": "Per-row report",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/main.BookImportReport"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {}
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -124,39 +81,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/authentication/user": {
-            "post": {
-                "description": "Registers a user",
-                "consumes": [
-                    "application/json"
+        "/admin/books/{bookID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Get a book by its ID along with its stock at every location. Stock is the sum over all locations.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "authentication"
+                    "stock"
                 ],
-                "summary": "Registers a user",
+                "summary": "Get a book with its stock per location",
                 "parameters": [
                     {
-                        "description": "User credentials",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.createUserPayload"
-                        }
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User registered",
+                    "200": {
+                        "description": "Book",
                         "schema": {
-                            "$ref": "#/definitions/main.UserWithToken"
+                            "$ref": "#/definitions/main.AdminBookResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {}
                     },
                     "500": {
@@ -166,14 +123,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/books": {
+        "/admin/books/{bookID}/stock-adjustments": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a book",
+                "description": "Adds (positive quantity) or removes (negative quantity) copies and records the movement in the stock ledger. Kind is receipt for deliveries and adjustment, the default, for corrections such as stock counts or damaged copies. Without a location_id copies are added to the default location and removed from the locations in priority order.",
                 "consumes": [
                     "application/json"
                 ],
@@ -181,31 +138,46 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "book"
+                    "stock"
                 ],
-                "summary": "Creates a book",
+                "summary": "Adjust the stock of a book",
                 "parameters": [
                     {
-                        "description": "Book details",
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Adjustment",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.createBookPayload"
+                            "$ref": "#/definitions/main.createStockAdjustmentPayload"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Book created",
+                        "description": "Recorded movement",
                         "schema": {
-                            "$ref": "#/definitions/store.Book"
+                            "$ref": "#/definitions/store.StockMovement"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Stock would go below zero",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -213,69 +185,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/books/search": {
+        "/admin/books/{bookID}/stock-movements": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Search for books using a general query or specific filters such as title, author, tags, price range, and stock status.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists the book's stock movements newest first. Pass the smallest ID of a page as before to get the next one.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "book"
+                    "stock"
                 ],
-                "summary": "Search books",
+                "summary": "Stock history of a book",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Free-text search query",
-                        "name": "query",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by book title",
-                        "name": "title",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by author name",
-                        "name": "author",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Filter by tags",
-                        "name": "tag",
-                        "in": "query"
-                    },
-                    {
-                        "type": "number",
-                        "description": "Minimum price filter",
-                        "name": "min_price",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "number",
-                        "description": "Maximum price filter",
-                        "name": "max_price",
+                        "type": "integer",
+                        "description": "Page size, at most 500",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
-                        "type": "boolean",
-                        "description": "Filter by stock status (true for in-stock, false for out-of-stock)",
-                        "name": "in_stock",
+                        "type": "integer",
+                        "description": "Only movements with a smaller ID",
+                        "name": "before",
                         "in": "query"
                     }
                 ],
@@ -285,10 +227,18 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/store.Book"
+                                "$ref": "#/definitions/store.StockMovement"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -296,24 +246,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/books/{bookID}/reviews": {
+        "/admin/books/{bookID}/transfers": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get all reviews by Book ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists the book's transfers between locations, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "review"
+                    "stock"
                 ],
-                "summary": "Get all Reviews",
+                "summary": "List stock transfers of a book",
                 "parameters": [
                     {
                         "type": "integer",
@@ -325,20 +272,20 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Get all Review",
+                        "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/store.Review"
+                                "$ref": "#/definitions/store.StockTransfer"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Server error",
+                        "description": "Internal Server Error",
                         "schema": {}
                     }
                 }
@@ -349,7 +296,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create an Review",
+                "description": "Moves copies of the book from one location to another. The book's total stock does not change.",
                 "consumes": [
                     "application/json"
                 ],
@@ -357,9 +304,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "review"
+                    "stock"
                 ],
-                "summary": "Create an Review",
+                "summary": "Transfer stock between locations",
                 "parameters": [
                     {
                         "type": "integer",
@@ -369,85 +316,79 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Create Review Payload",
+                        "description": "Transfer",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.CreateReviewPayload"
+                            "$ref": "#/definitions/main.createStockTransferPayload"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Creates an Review",
+                        "description": "Recorded transfer",
                         "schema": {
-                            "$ref": "#/definitions/store.Review"
+                            "$ref": "#/definitions/store.StockTransfer"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Not enough copies at the source location",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Server error",
+                        "description": "Internal Server Error",
                         "schema": {}
                     }
                 }
             }
         },
-        "/books/{bookID}/reviews/{reviewID}": {
-            "delete": {
+        "/admin/locations": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a review for a book by ID",
-                "consumes": [
+                "description": "Lists stock locations in priority order",
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "review"
-                ],
-                "summary": "Delete a Review",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Book ID",
-                        "name": "bookID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Review ID",
-                        "name": "reviewID",
-                        "in": "path",
-                        "required": true
-                    }
+                    "stock"
                 ],
+                "summary": "List stock locations",
                 "responses": {
-                    "204": {
-                        "description": "Review deleted successfully"
-                    },
-                    "400": {
-                        "description": "Invalid request",
-                        "schema": {}
+                    "200": {
+                        "description": "Locations",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.StockLocation"
+                            }
+                        }
                     },
                     "500": {
-                        "description": "Server error",
+                        "description": "Internal Server Error",
                         "schema": {}
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update a review",
+                "description": "Adds a warehouse or shop that holds stock. Orders take stock from locations in priority order (lowest first), unless the allocation strategy says otherwise.",
                 "consumes": [
                     "application/json"
                 ],
@@ -455,88 +396,75 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "review"
+                    "stock"
                 ],
-                "summary": "Update a review",
+                "summary": "Create a stock location",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Review ID",
-                        "name": "reviewID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Book ID",
-                        "name": "bookID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Update Review Payload",
+                        "description": "Location Payload",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.updateReviewPayload"
+                            "$ref": "#/definitions/main.createLocationPayload"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Updated Review",
+                    "201": {
+                        "description": "Created Location",
                         "schema": {
-                            "$ref": "#/definitions/store.Review"
+                            "$ref": "#/definitions/store.StockLocation"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Code already in use",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Server error",
+                        "description": "Internal Server Error",
                         "schema": {}
                     }
                 }
             }
         },
-        "/books/{id}": {
+        "/admin/locations/{locationID}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get a book by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get a stock location by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "book"
+                    "stock"
                 ],
-                "summary": "Get a book",
+                "summary": "Get a stock location",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Book ID",
-                        "name": "id",
+                        "description": "Location ID",
+                        "name": "locationID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Get Book ",
+                        "description": "Location",
                         "schema": {
-                            "$ref": "#/definitions/store.Book"
+                            "$ref": "#/definitions/store.StockLocation"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {}
                     },
                     "500": {
@@ -551,7 +479,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update a book by its ID",
+                "description": "Update a stock location by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -559,46 +487,46 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "book"
+                    "stock"
                 ],
-                "summary": "Update a book",
+                "summary": "Update a stock location",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Book ID",
-                        "name": "id",
+                        "description": "Location ID",
+                        "name": "locationID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Update Book Payload",
+                        "description": "Update Location Payload",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.updateBookPayload"
+                            "$ref": "#/definitions/main.updateLocationPayload"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Updated Location",
                         "schema": {
-                            "$ref": "#/definitions/store.Book"
+                            "$ref": "#/definitions/store.StockLocation"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {}
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {}
                     },
+                    "409": {
+                        "description": "Code already in use",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -606,74 +534,101 @@ const docTemplate = `{
                 }
             }
         },
-        "/books{id}": {
-            "delete": {
+        "/admin/orders": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "deletes a book by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists orders of every user with filters, sorting and cursor pagination. Pass format=csv to download every matching order as CSV.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "book"
+                    "order"
                 ],
-                "summary": "deletes a book",
+                "summary": "List all orders",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment method",
+                        "name": "payment_method",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Book ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "Book deleted",
-                        "schema": {
-                            "type": "string"
-                        }
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {}
+                    {
+                        "type": "string",
+                        "description": "Placed at or after, RFC3339 or YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {}
-                    }
-                }
-            }
-        },
-        "/carts": {
-            "get": {
-                "security": [
                     {
-                        "ApiKeyAuth": []
+                        "type": "string",
+                        "description": "Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum total amount",
+                        "name": "min_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum total amount",
+                        "name": "max_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "placed_at (default), total_amount or id",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc or desc (default)",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or csv",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
-                "description": "Get Cart Items",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "cart"
-                ],
-                "summary": "Get Cart Items",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Orders",
                         "schema": {
-                            "$ref": "#/definitions/main.CartResponse"
+                            "$ref": "#/definitions/store.OrderPage"
                         }
                     },
                     "400": {
@@ -685,14 +640,16 @@ const docTemplate = `{
                         "schema": {}
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/orders/{id}": {
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Add book to cart",
+                "description": "Update an order by Admin",
                 "consumes": [
                     "application/json"
                 ],
@@ -700,59 +657,78 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cart"
+                    "order"
                 ],
-                "summary": "Add book to cart",
+                "summary": "Update an order by Admin",
                 "parameters": [
                     {
-                        "description": "Add to Cart Payload",
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Order Payload by Admin",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.addToCartPayload"
+                            "$ref": "#/definitions/main.updateOrderAdminPayload"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "Updated Order",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/store.Order"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Illegal status transition",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Server error",
                         "schema": {}
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/orders/{orderID}/packing-slip.pdf": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete all items from the authenticated user's cart",
+                "description": "Renders a PDF listing the books and quantities to pack and the address to ship them to",
                 "produces": [
-                    "application/json"
+                    "application/pdf"
                 ],
                 "tags": [
-                    "cart"
+                    "order"
+                ],
+                "summary": "Download the packing slip of an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Delete entire cart",
                 "responses": {
-                    "204": {
-                        "description": "Cart deleted successfully",
+                    "200": {
+                        "description": "Packing slip PDF",
                         "schema": {
-                            "type": "string"
+                            "type": "file"
                         }
                     },
                     "404": {
@@ -766,35 +742,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/carts/items/{itemID}": {
-            "delete": {
+        "/admin/orders/{orderID}/payments/capture": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a specific item from the user's cart by item ID",
+                "description": "Captures the pending payment of an order with its provider",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cart"
+                    "payment"
                 ],
-                "summary": "Delete item from cart",
+                "summary": "Capture an order payment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Cart Item ID",
-                        "name": "itemID",
+                        "description": "Order ID",
+                        "name": "orderID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Item deleted successfully",
+                    "200": {
+                        "description": "Captured Payment",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/store.Payment"
                         }
                     },
                     "404": {
@@ -806,44 +782,40 @@ const docTemplate = `{
                         "schema": {}
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/admin/orders/{orderID}/refunds": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update Cart Item Quantity",
+                "description": "Lists the refunds recorded against an order",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cart"
+                    "return"
                 ],
-                "summary": "Update Cart Item Quantity",
+                "summary": "List refunds of an order",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Item ID",
-                        "name": "itemID",
+                        "description": "Order ID",
+                        "name": "orderID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Quantity Payload",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.updateItemPayload"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "CartItem Updated successfully",
+                        "description": "Refunds",
                         "schema": {
-                            "$ref": "#/definitions/store.CartItem"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Refund"
+                            }
                         }
                     },
                     "404": {
@@ -855,16 +827,14 @@ const docTemplate = `{
                         "schema": {}
                     }
                 }
-            }
-        },
-        "/orders": {
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create an order",
+                "description": "Refunds part or all of an order through its payment provider. A refund the provider refuses is recorded as failed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -872,74 +842,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "order"
+                    "return"
                 ],
-                "summary": "Create an order",
+                "summary": "Refund an order",
                 "parameters": [
                     {
-                        "description": "Create Order Payload",
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Refund Payload",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.createOrderPayload"
+                            "$ref": "#/definitions/main.createRefundPayload"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Creates an order",
+                        "description": "Created Refund",
                         "schema": {
-                            "$ref": "#/definitions/store.Order"
+                            "$ref": "#/definitions/store.Refund"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
-                        "schema": {}
-                    },
-                    "500": {
-                        "description": "Server error",
+                        "description": "Refund exceeds the amount left to refund",
                         "schema": {}
-                    }
-                }
-            }
-        },
-        "/orders/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Get a order by its ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "order"
-                ],
-                "summary": "Get a order",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "order ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Get Order",
-                        "schema": {
-                            "$ref": "#/definitions/store.Order"
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {}
                     },
                     "500": {
@@ -947,14 +883,16 @@ const docTemplate = `{
                         "schema": {}
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/admin/orders/{orderID}/shipments": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update an order by User",
+                "description": "Records a parcel with its carrier and tracking number. Leave items empty to ship everything not shipped yet; otherwise each book may appear once. The order becomes shipped once all items are covered.",
                 "consumes": [
                     "application/json"
                 ],
@@ -962,81 +900,97 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "order"
+                    "shipment"
                 ],
-                "summary": "Update an order by User",
+                "summary": "Ship an order",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "Order ID",
-                        "name": "id",
+                        "name": "orderID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Update Order Payload by User",
+                        "description": "Shipment Payload",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.updateOrderPayload"
+                            "$ref": "#/definitions/main.createShipmentPayload"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Updated Order",
+                    "201": {
+                        "description": "Created Shipment",
                         "schema": {
-                            "$ref": "#/definitions/store.Order"
+                            "$ref": "#/definitions/store.Shipment"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Order cannot be shipped",
                         "schema": {}
                     },
                     "500": {
-                        "description": "Server error",
+                        "description": "Internal Server Error",
                         "schema": {}
                     }
                 }
             }
         },
-        "/password/request/verify": {
-            "get": {
+        "/admin/orders/{orderID}/shipments/{shipmentID}/deliver": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Verifies the password reset request sent by the email",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Records the delivery of a parcel. The order becomes delivered once every parcel has arrived.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "password"
+                    "shipment"
                 ],
-                "summary": "Verify the password reset request",
+                "summary": "Mark a shipment delivered",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Password reset token",
-                        "name": "token",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Shipment ID",
+                        "name": "shipmentID",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Reset Password Request Response",
+                        "description": "Delivered Shipment",
                         "schema": {
-                            "$ref": "#/definitions/main.PasswordResetVerifyResponse"
+                            "$ref": "#/definitions/store.Shipment"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Already delivered",
                         "schema": {}
                     },
                     "500": {
@@ -1046,61 +1000,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/password/reset": {
-            "post": {
+        "/admin/promotions": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Reset the password",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists every promotion, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "password"
-                ],
-                "summary": "Reset the password",
-                "parameters": [
-                    {
-                        "description": "Reset Password Payload",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.passwordResetPayload"
-                        }
-                    }
+                    "promotion"
                 ],
+                "summary": "List promotions",
                 "responses": {
                     "200": {
-                        "description": "Reset Password Request Response",
+                        "description": "Promotions",
                         "schema": {
-                            "$ref": "#/definitions/main.passwordResetResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Promotion"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {}
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
                     }
                 }
-            }
-        },
-        "/password/reset-request": {
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Send a Reset Password Request by sending a mail to the user",
+                "description": "Creates a coupon backed promotion with a percentage or fixed discount",
                 "consumes": [
                     "application/json"
                 ],
@@ -1108,31 +1045,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "password"
+                    "promotion"
                 ],
-                "summary": "Send Reset Password Request",
+                "summary": "Create a promotion",
                 "parameters": [
                     {
-                        "description": "Password Reset Request Payload",
+                        "description": "Promotion Payload",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.passwordResetRequestPayload"
+                            "$ref": "#/definitions/main.createPromotionPayload"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Reset Password Request Response",
+                        "description": "Created Promotion",
                         "schema": {
-                            "$ref": "#/definitions/main.TokenResponse"
+                            "$ref": "#/definitions/store.Promotion"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "409": {
+                        "description": "Duplicate coupon code",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -1140,33 +1081,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/activate/{token}": {
+        "/admin/promotions/{promotionID}": {
             "get": {
-                "description": "Activates a user account using a provided token.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Get a promotion by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "promotion"
                 ],
-                "summary": "Activate user account",
+                "summary": "Get a promotion",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Activation Token",
-                        "name": "token",
+                        "type": "integer",
+                        "description": "Promotion ID",
+                        "name": "promotionID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User account activated",
+                        "description": "Promotion",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/store.Promotion"
                         }
                     },
                     "404": {
@@ -1178,35 +1121,33 @@ const docTemplate = `{
                         "schema": {}
                     }
                 }
-            }
-        },
-        "/users/me": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves details of the authenticated user.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Delete a promotion by its ID",
                 "tags": [
-                    "user"
+                    "promotion"
+                ],
+                "summary": "Delete a promotion",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Promotion ID",
+                        "name": "promotionID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get current user details",
                 "responses": {
-                    "200": {
-                        "description": "User details",
-                        "schema": {
-                            "$ref": "#/definitions/store.User"
-                        }
+                    "204": {
+                        "description": "Promotion deleted"
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {}
                     },
                     "500": {
@@ -1221,7 +1162,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates the authenticated user's details.",
+                "description": "Update a promotion by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1229,39 +1170,46 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "promotion"
                 ],
-                "summary": "Update user details",
+                "summary": "Update a promotion",
                 "parameters": [
                     {
-                        "description": "User update payload",
-                        "name": "body",
+                        "type": "integer",
+                        "description": "Promotion ID",
+                        "name": "promotionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Promotion Payload",
+                        "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.updateUserPayload"
+                            "$ref": "#/definitions/main.updatePromotionPayload"
                         }
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "User updated",
+                    "200": {
+                        "description": "Updated Promotion",
                         "schema": {
-                            "$ref": "#/definitions/store.User"
+                            "$ref": "#/definitions/store.Promotion"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {}
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {}
                     },
+                    "409": {
+                        "description": "Duplicate coupon code",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -1269,38 +1217,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{userID}": {
+        "/admin/reports/low-stock": {
             "get": {
                 "security": [
                     {
-                        "ApiAuthKey": []
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get User by ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists books with fewer than threshold copies in stock, emptiest first. The threshold defaults to the one low stock alerts use.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "user"
+                    "report"
                 ],
-                "summary": "Get User by ID",
+                "summary": "Low stock books",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "path",
-                        "required": true
+                        "description": "Stock threshold, defaults to LOW_STOCK_THRESHOLD",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or csv",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/store.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.LowStockBook"
+                            }
                         }
                     },
                     "400": {
@@ -1314,31 +1268,109 @@ const docTemplate = `{
                 }
             }
         },
-        "/wishlist": {
+        "/admin/reports/order-value": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get Book Wishlist",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Counts orders and averages their value, net of settled refunds, and their number of copies. Only paid orders count: pending, cancelled and failed orders are left out.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "wishlist"
+                    "report"
                 ],
-                "summary": "Get Book Wishlist",
-                "responses": {
-                    "200": {
-                        "description": "Book List",
+                "summary": "Average order value",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Placed at or after, RFC3339 or YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.OrderValueSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/admin/reports/revenue": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sums orders, units sold and revenue per day, week or month. Only paid orders count: pending, cancelled and failed orders are left out. Refunds count once settled.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "report"
+                ],
+                "summary": "Revenue report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "day (default), week or month",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Placed at or after, RFC3339 or YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/store.Book"
+                                "$ref": "#/definitions/store.RevenuePoint"
                             }
                         }
                     },
@@ -1351,40 +1383,52 @@ const docTemplate = `{
                         "schema": {}
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/reports/status": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Add Book to Wishlist",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Counts orders and sums their totals per status",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "wishlist"
+                    "report"
                 ],
-                "summary": "Add Book to Wishlist",
+                "summary": "Orders by status",
                 "parameters": [
                     {
-                        "description": "Book ID",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.addToWishlistPayload"
-                        }
+                        "type": "string",
+                        "description": "Placed at or after, RFC3339 or YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or csv",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Create Wishlist",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/store.Wishlist"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.StatusCount"
+                            }
                         }
                     },
                     "400": {
@@ -1398,38 +1442,62 @@ const docTemplate = `{
                 }
             }
         },
-        "/wishlist/{bookID}": {
-            "delete": {
+        "/admin/reports/top-authors": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete Book From Wishlist",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Ranks authors by units sold or by revenue across all of their books",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "wishlist"
+                    "report"
                 ],
-                "summary": "Delete Book From Wishlist",
+                "summary": "Best selling authors",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "units (default) or revenue",
+                        "name": "by",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "BOOK ID",
-                        "name": "bookID",
-                        "in": "path",
-                        "required": true
+                        "description": "Number of authors, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Placed at or after, RFC3339 or YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or csv",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "DELETED RESPONSE",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.removeWishlistResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.TopAuthor"
+                            }
                         }
                     },
                     "400": {
@@ -1442,328 +1510,5538 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "main.CartResponse": {
-            "type": "object",
-            "properties": {
-                "cart_id": {
-                    "type": "integer"
-                },
-                "items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/store.CartItemWithBook"
+        },
+        "/admin/reports/top-books": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ranks books by units sold or by revenue",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "report"
+                ],
+                "summary": "Best selling books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "units (default) or revenue",
+                        "name": "by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Placed at or after, RFC3339 or YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Placed before, RFC3339 or YYYY-MM-DD (the whole day is included)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default) or csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.TopBook"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
                     }
                 }
             }
         },
-        "main.CreateReviewPayload": {
-            "type": "object",
-            "required": [
-                "content",
-                "rating"
-            ],
-            "properties": {
-                "content": {
-                    "type": "string",
-                    "minLength": 1
-                },
-                "rating": {
-                    "type": "integer",
-                    "maximum": 5,
-                    "minimum": 1
+        "/admin/returns": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every return request, optionally filtered by status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "return"
+                ],
+                "summary": "List return requests",
+                "parameters": [
+                    {
+                        "enum": [
+                            "requested",
+                            "approved",
+                            "rejected",
+                            "received"
+                        ],
+                        "type": "string",
+                        "description": "Return status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Return Requests",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ReturnRequest"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
                 }
             }
         },
-        "main.OrderItemPayload": {
-            "type": "object",
-            "required": [
-                "book_id",
-                "price",
-                "quantity"
-            ],
-            "properties": {
-                "book_id": {
-                    "type": "integer",
-                    "minimum": 1
+        "/admin/returns/{returnID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a return request by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "return"
+                ],
+                "summary": "Get a return request",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Return Request ID",
+                        "name": "returnID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Return Request",
+                        "schema": {
+                            "$ref": "#/definitions/store.ReturnRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Approves or rejects a return request that is still awaiting review",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "return"
+                ],
+                "summary": "Approve or reject a return",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Return Request ID",
+                        "name": "returnID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Review Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.reviewReturnPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reviewed Return Request",
+                        "schema": {
+                            "$ref": "#/definitions/store.ReturnRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Return request was already reviewed",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/admin/returns/{returnID}/receive": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Marks an approved return as received, optionally restocks the copies and refunds the customer. The refund defaults to the price paid for the returned copies, less their share of the line discount. If the payment provider refuses the refund, the return stays received and the refund is recorded as failed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "return"
+                ],
+                "summary": "Receive a returned item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Return Request ID",
+                        "name": "returnID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Receive Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.receiveReturnPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Received Return",
+                        "schema": {
+                            "$ref": "#/definitions/main.receiveReturnResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Return request is not approved",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/admin/shipping-rates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every shipping rate grouped by region",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipping"
+                ],
+                "summary": "List shipping rates",
+                "responses": {
+                    "200": {
+                        "description": "Shipping Rates",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ShippingRate"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a flat, per item or page count based shipping rate for a region. An empty region applies everywhere else.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipping"
+                ],
+                "summary": "Create a shipping rate",
+                "parameters": [
+                    {
+                        "description": "Shipping Rate Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createShippingRatePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created Shipping Rate",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShippingRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/admin/shipping-rates/{shippingRateID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a shipping rate by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipping"
+                ],
+                "summary": "Get a shipping rate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shipping Rate ID",
+                        "name": "shippingRateID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Shipping Rate",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShippingRate"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a shipping rate by its ID",
+                "tags": [
+                    "shipping"
+                ],
+                "summary": "Delete a shipping rate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shipping Rate ID",
+                        "name": "shippingRateID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Shipping rate deleted"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a shipping rate by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipping"
+                ],
+                "summary": "Update a shipping rate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shipping Rate ID",
+                        "name": "shippingRateID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Shipping Rate Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateShippingRatePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Shipping Rate",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShippingRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/admin/tax-rates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the tax rate of every region",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tax"
+                ],
+                "summary": "List tax rates",
+                "responses": {
+                    "200": {
+                        "description": "Tax Rates",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.TaxRate"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates the tax rate of a region as a fraction, e.g. 0.15 for 15%. An empty region applies everywhere else.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tax"
+                ],
+                "summary": "Create a tax rate",
+                "parameters": [
+                    {
+                        "description": "Tax Rate Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createTaxRatePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created Tax Rate",
+                        "schema": {
+                            "$ref": "#/definitions/store.TaxRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Region already has a tax rate",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/admin/tax-rates/{taxRateID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a tax rate by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tax"
+                ],
+                "summary": "Get a tax rate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tax Rate ID",
+                        "name": "taxRateID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tax Rate",
+                        "schema": {
+                            "$ref": "#/definitions/store.TaxRate"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a tax rate by its ID",
+                "tags": [
+                    "tax"
+                ],
+                "summary": "Delete a tax rate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tax Rate ID",
+                        "name": "taxRateID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Tax rate deleted"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a tax rate by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tax"
+                ],
+                "summary": "Update a tax rate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tax Rate ID",
+                        "name": "taxRateID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Tax Rate Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateTaxRatePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Tax Rate",
+                        "schema": {
+                            "$ref": "#/definitions/store.TaxRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Region already has a tax rate",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/authentication/token": {
+            "post": {
+                "description": "Creates a token for a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authentication"
+                ],
+                "summary": "Creates a token",
+                "parameters": [
+                    {
+                        "description": "User credentials",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.loginUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/authentication/user": {
+            "post": {
+                "description": "Registers a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authentication"
+                ],
+                "summary": "Registers a user",
+                "parameters": [
+                    {
+                        "description": "User credentials",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User registered",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserWithToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/books": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a book",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "book"
+                ],
+                "summary": "Creates a book",
+                "parameters": [
+                    {
+                        "description": "Book details",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createBookPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Book created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "A book with this ISBN already exists",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/books/search": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Searches books by a free-text query and filters. Results come in pages, ranked by relevance or by the chosen sort.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "book"
+                ],
+                "summary": "Search books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Free-text query in web search syntax: quoted phrases, or, -exclude. Matches in the description come back as a snippet wrapped in \u003cmark\u003e tags",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by book title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by author name",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Filter by tags",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum price filter",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum price filter",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by stock status (true for in-stock, false for out-of-stock)",
+                        "name": "in_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Match the query by similarity to titles and authors. A query finding fewer than 3 books falls back to this by itself, flags the page as fuzzy and suggests a correction in did_you_mean; pass fuzzy=true for its next pages",
+                        "name": "fuzzy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "relevance (default with a query), price, newest, rating or title (default without a query)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc or desc; defaults to best match, lowest price, newest and best rated first, titles A to Z",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page, sent with the same filters and sort",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also count the matching books per tag, author, price range, average rating and availability",
+                        "name": "facets",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.BookSearchPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/books/suggest": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Suggests titles and authors for what the user has typed so far. Those starting with the text come first; similar ones are included so that typos still find something.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "book"
+                ],
+                "summary": "Autocomplete titles and authors",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Text typed so far",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of suggestions, at most 20",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.BookSuggestion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/books/{bookID}/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all reviews by Book ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "review"
+                ],
+                "summary": "Get all Reviews",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Get all Review",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Review"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create an Review",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "review"
+                ],
+                "summary": "Create an Review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create Review Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateReviewPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Creates an Review",
+                        "schema": {
+                            "$ref": "#/definitions/store.Review"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/books/{bookID}/reviews/{reviewID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a review for a book by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "review"
+                ],
+                "summary": "Delete a Review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Review ID",
+                        "name": "reviewID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Review deleted successfully"
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a review",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "review"
+                ],
+                "summary": "Update a review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Review ID",
+                        "name": "reviewID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Review Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateReviewPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Review",
+                        "schema": {
+                            "$ref": "#/definitions/store.Review"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/books/{bookID}/stock-subscription": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Subscribes the authenticated user to a one-time email for when the out-of-stock book is restocked",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "book"
+                ],
+                "summary": "Get notified when a book is back in stock",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Subscription",
+                        "schema": {
+                            "$ref": "#/definitions/store.StockSubscription"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Book is in stock",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cancels the authenticated user's pending back in stock notification for the book",
+                "tags": [
+                    "book"
+                ],
+                "summary": "Stop waiting for a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Subscription cancelled"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/books/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a book by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "book"
+                ],
+                "summary": "Get a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Get Book ",
+                        "schema": {
+                            "$ref": "#/definitions/store.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a book by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "book"
+                ],
+                "summary": "Update a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Book Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateBookPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Duplicate ISBN or negative stock",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/books{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "deletes a book by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "book"
+                ],
+                "summary": "deletes a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Book deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/carts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get Cart Items",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Get Cart Items",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add book to cart. The cart's copies of the book are reserved for a limited time so other customers cannot buy them meanwhile.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Add book to cart",
+                "parameters": [
+                    {
+                        "description": "Add to Cart Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.addToCartPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Book not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Not enough copies available",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete all items from the authenticated user's cart",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Delete entire cart",
+                "responses": {
+                    "204": {
+                        "description": "Cart deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/carts/checkout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Turns the authenticated user's cart into an order, validating stock and emptying the cart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Checkout the cart",
+                "parameters": [
+                    {
+                        "description": "Checkout Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.checkoutCartPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created Order",
+                        "schema": {
+                            "$ref": "#/definitions/store.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or empty cart",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Book not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Insufficient stock",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/carts/items/{itemID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a specific item from the user's cart by item ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Delete item from cart",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Cart Item ID",
+                        "name": "itemID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Item deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update Cart Item Quantity",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Update Cart Item Quantity",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "itemID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Quantity Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateItemPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CartItem Updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/store.CartItem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Not enough copies available",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create an order. Line prices and the total are taken from the catalog and stock is reserved atomically.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Create an order",
+                "parameters": [
+                    {
+                        "description": "Create Order Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createOrderPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Creates an order",
+                        "schema": {
+                            "$ref": "#/definitions/store.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Book not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Insufficient stock",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/orders/quote": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the subtotal, discount, shipping, tax and total a prospective order would be charged, without placing it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Quote an order",
+                "parameters": [
+                    {
+                        "description": "Quote Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.quoteOrderPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Price breakdown",
+                        "schema": {
+                            "$ref": "#/definitions/main.OrderQuoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or coupon",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Book not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Insufficient stock",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/orders/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a order by its ID, including the tracking info of its shipments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Get a order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Get Order",
+                        "schema": {
+                            "$ref": "#/definitions/store.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update an order by User",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Update an order by User",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Order Payload by User",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateOrderPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Order",
+                        "schema": {
+                            "$ref": "#/definitions/store.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Order can no longer be edited",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/orders/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lets the owner cancel an order that is still pending or processing and has not started shipping. Every item is returned to stock.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Cancel an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled Order",
+                        "schema": {
+                            "$ref": "#/definitions/store.Order"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Order can no longer be cancelled or has started shipping",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/orders/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every status change of an order, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Get order status history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status history",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.OrderStatusChange"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/orders/{orderID}/invoice.pdf": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Renders the order's invoice as a PDF. The invoice number is issued the first time the invoice is requested, once the order has been paid.",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Download the invoice of an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invoice PDF",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Order has not been paid",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/orders/{orderID}/payments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the payments attempted for an order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "List payments of an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Payments",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Payment"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a payment intent with the configured provider for the order total",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Start paying for an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created Payment",
+                        "schema": {
+                            "$ref": "#/definitions/main.PaymentIntentResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Order is not awaiting payment",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/orders/{orderID}/returns": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the return requests opened against an order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "return"
+                ],
+                "summary": "List returns of an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Return Requests",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ReturnRequest"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Opens a return request for an item of a delivered order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "return"
+                ],
+                "summary": "Request a return",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Return Request Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createReturnPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created Return Request",
+                        "schema": {
+                            "$ref": "#/definitions/store.ReturnRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Order is not eligible for returns",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/orders/{orderID}/shipments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the parcels of an order with carrier, tracking number and delivery state",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipment"
+                ],
+                "summary": "Track an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Shipments",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Shipment"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/password/request/verify": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Verifies the password reset request sent by the email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "password"
+                ],
+                "summary": "Verify the password reset request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Password reset token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reset Password Request Response",
+                        "schema": {
+                            "$ref": "#/definitions/main.PasswordResetVerifyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reset the password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "password"
+                ],
+                "summary": "Reset the password",
+                "parameters": [
+                    {
+                        "description": "Reset Password Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.passwordResetPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reset Password Request Response",
+                        "schema": {
+                            "$ref": "#/definitions/main.passwordResetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/password/reset-request": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send a Reset Password Request by sending a mail to the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "password"
+                ],
+                "summary": "Send Reset Password Request",
+                "parameters": [
+                    {
+                        "description": "Password Reset Request Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.passwordResetRequestPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Reset Password Request Response",
+                        "schema": {
+                            "$ref": "#/definitions/main.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/payments/webhooks/{provider}": {
+            "post": {
+                "description": "Receives signed payment notifications and advances the order status. Events whose amount or currency differ from the payment are rejected, as are events that would move a final payment back. A payment that succeeds after its order stopped awaiting payment is flagged with needs_refund.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Payment provider webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Payment",
+                        "schema": {
+                            "$ref": "#/definitions/store.Payment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Invalid signature",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Payment can no longer move to the event's status",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/activate/{token}": {
+            "get": {
+                "description": "Activates a user account using a provided token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Activate user account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Activation Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User account activated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves details of the authenticated user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get current user details",
+                "responses": {
+                    "200": {
+                        "description": "User details",
+                        "schema": {
+                            "$ref": "#/definitions/store.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates the authenticated user's details.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user details",
+                "parameters": [
+                    {
+                        "description": "User update payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "User updated",
+                        "schema": {
+                            "$ref": "#/definitions/store.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/me/addresses": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the authenticated user's address book, default address first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "List addresses",
+                "responses": {
+                    "200": {
+                        "description": "Addresses",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Address"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds an address to the authenticated user's address book. The first address becomes the default.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Add an address",
+                "parameters": [
+                    {
+                        "description": "Address Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createAddressPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created Address",
+                        "schema": {
+                            "$ref": "#/definitions/store.Address"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/me/addresses/{addressID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get an address of the authenticated user by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Get an address",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Address ID",
+                        "name": "addressID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Address",
+                        "schema": {
+                            "$ref": "#/definitions/store.Address"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes an address from the authenticated user's address book",
+                "tags": [
+                    "address"
+                ],
+                "summary": "Delete an address",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Address ID",
+                        "name": "addressID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Address deleted"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates an address of the authenticated user. Orders already placed keep the address they were placed with.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Update an address",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Address ID",
+                        "name": "addressID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Address Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateAddressPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Address",
+                        "schema": {
+                            "$ref": "#/definitions/store.Address"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiAuthKey": []
+                    }
+                ],
+                "description": "Get User by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get User by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User",
+                        "schema": {
+                            "$ref": "#/definitions/store.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/wishlists": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the wishlists the user made public. Open one through its share token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "List a user's public wishlists",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Wishlist"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wishlist": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the books on the default wishlist",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Get Book Wishlist",
+                "responses": {
+                    "200": {
+                        "description": "Book List",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Book"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add Book to the default wishlist or to a named one. With price_alert the user is emailed when the price drops to target_price, or below the current price if no target is given. Priority goes from 0 to 5, highest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Add Book to Wishlist",
+                "parameters": [
+                    {
+                        "description": "Book ID",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.addToWishlistPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Create Wishlist",
+                        "schema": {
+                            "$ref": "#/definitions/store.WishlistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Book is already on the wishlist",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wishlist/{bookID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete Book From the default wishlist or from a named one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Delete Book From Wishlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "BOOK ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "DELETED RESPONSE",
+                        "schema": {
+                            "$ref": "#/definitions/main.removeWishlistResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes the note and priority of a wishlisted book, turns its price drop email on or off and sets or clears its target price. Without a target the alert fires when the price drops below the price at the time of wishlisting.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Update a wishlisted book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "BOOK ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Item settings",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateWishlistItemPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Wishlist",
+                        "schema": {
+                            "$ref": "#/definitions/store.WishlistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wishlists": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the authenticated user's wishlists, the default one first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "List my wishlists",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Wishlist"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a named wishlist. Private lists, the default, are only visible to their owner; unlisted ones to anybody with the share link; public ones are also listed on the owner's profile.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Create a wishlist",
+                "parameters": [
+                    {
+                        "description": "Wishlist",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createWishlistPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created Wishlist",
+                        "schema": {
+                            "$ref": "#/definitions/store.Wishlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Name already in use",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wishlists/shared/{token}": {
+            "get": {
+                "description": "Read-only view of an unlisted or public wishlist for gift-givers. No login is needed; the token comes from the owner's share link.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "View a shared wishlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Wishlist",
+                        "schema": {
+                            "$ref": "#/definitions/main.SharedWishlistResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wishlists/{wishlistID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get one of the authenticated user's wishlists with its books, highest priority first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Get a wishlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Wishlist ID",
+                        "name": "wishlistID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Wishlist",
+                        "schema": {
+                            "$ref": "#/definitions/main.WishlistResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a named wishlist and its books. The default wishlist cannot be deleted.",
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Delete a wishlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Wishlist ID",
+                        "name": "wishlistID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Wishlist deleted"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Default wishlist",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Renames a wishlist or changes who can see it. With regenerate_share_token the list gets a new share token and links handed out earlier stop working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Update a wishlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Wishlist ID",
+                        "name": "wishlistID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Wishlist",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateWishlistPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Wishlist",
+                        "schema": {
+                            "$ref": "#/definitions/store.Wishlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Name already in use",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wishlists/{wishlistID}/items": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add Book to the default wishlist or to a named one. With price_alert the user is emailed when the price drops to target_price, or below the current price if no target is given. Priority goes from 0 to 5, highest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Add Book to Wishlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Wishlist ID",
+                        "name": "wishlistID",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Book ID",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.addToWishlistPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Create Wishlist",
+                        "schema": {
+                            "$ref": "#/definitions/store.WishlistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Book is already on the wishlist",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wishlists/{wishlistID}/items/{bookID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete Book From the default wishlist or from a named one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Delete Book From Wishlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Wishlist ID",
+                        "name": "wishlistID",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "BOOK ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "DELETED RESPONSE",
+                        "schema": {
+                            "$ref": "#/definitions/main.removeWishlistResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes the note and priority of a wishlisted book, turns its price drop email on or off and sets or clears its target price. Without a target the alert fires when the price drops below the price at the time of wishlisting.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Update a wishlisted book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Wishlist ID",
+                        "name": "wishlistID",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "BOOK ID",
+                        "name": "bookID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Item settings",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateWishlistItemPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Wishlist",
+                        "schema": {
+                            "$ref": "#/definitions/store.WishlistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wishlists/{wishlistID}/move-to-cart": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds one copy of every book on the wishlist to the cart and takes the books that were added off the list, one book at a time. Books already in the cart only leave the list and keep their quantity. Books that are out of stock or no longer sold stay on the list and are reported as failed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wishlist"
+                ],
+                "summary": "Move a wishlist to the cart",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Wishlist ID",
+                        "name": "wishlistID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Moved and failed books",
+                        "schema": {
+                            "$ref": "#/definitions/main.moveToCartResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.AdminBookResponse": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "cover_image_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.LocationStock"
+                    }
+                },
+                "pages": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.BookImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.BookImportRow"
+                    }
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.CartResponse": {
+            "type": "object",
+            "properties": {
+                "cart_id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.CartItemWithBook"
+                    }
+                }
+            }
+        },
+        "main.CreateReviewPayload": {
+            "type": "object",
+            "required": [
+                "content",
+                "rating"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "rating": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                }
+            }
+        },
+        "main.OrderItemPayload": {
+            "type": "object",
+            "required": [
+                "book_id",
+                "quantity"
+            ],
+            "properties": {
+                "book_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "main.OrderQuoteResponse": {
+            "type": "object",
+            "properties": {
+                "coupon_code": {
+                    "type": "string"
+                },
+                "discount_amount": {
+                    "type": "number"
+                },
+                "order_items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.OrderItem"
+                    }
+                },
+                "shipping_amount": {
+                    "type": "number"
+                },
+                "shipping_region": {
+                    "type": "string"
+                },
+                "subtotal_amount": {
+                    "type": "number"
+                },
+                "tax_amount": {
+                    "type": "number"
+                },
+                "total_amount": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.PasswordResetVerifyResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.PaymentIntentResponse": {
+            "type": "object",
+            "properties": {
+                "client_secret": {
+                    "type": "string"
+                },
+                "payment": {
+                    "$ref": "#/definitions/store.Payment"
+                }
+            }
+        },
+        "main.SharedWishlistResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.sharedWishlistItem"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.UserWithToken": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/store.User"
+                }
+            }
+        },
+        "main.WishlistResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_default": {
+                    "type": "boolean"
+                },
+                "item_count": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.WishlistItem"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "share_token": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.addToCartPayload": {
+            "type": "object",
+            "required": [
+                "book_id",
+                "quantity"
+            ],
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 1
+                }
+            }
+        },
+        "main.addToWishlistPayload": {
+            "type": "object",
+            "required": [
+                "book_id"
+            ],
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "price_alert": {
+                    "type": "boolean"
+                },
+                "priority": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 0
+                },
+                "target_price": {
+                    "type": "number",
+                    "minimum": 0
+                }
+            }
+        },
+        "main.checkoutCartPayload": {
+            "type": "object",
+            "required": [
+                "payment_method"
+            ],
+            "properties": {
+                "address_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "coupon_code": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "payment_method": {
+                    "type": "string",
+                    "enum": [
+                        "cash_on_delivery",
+                        "Bkash",
+                        "credit_card"
+                    ]
+                },
+                "shipping_address": {
+                    "type": "string",
+                    "minLength": 5
+                },
+                "shipping_region": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.createAddressPayload": {
+            "type": "object",
+            "required": [
+                "city",
+                "country",
+                "line1",
+                "phone",
+                "recipient"
+            ],
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "is_default": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "line1": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "line2": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30,
+                    "minLength": 5
+                },
+                "postal_code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "recipient": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.createBookPayload": {
+            "type": "object",
+            "required": [
+                "author",
+                "isbn",
+                "price",
+                "stock",
+                "title"
+            ],
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "cover_image_url": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "pages": {
+                    "type": "integer",
+                    "maximum": 100000,
+                    "minimum": 1
+                },
+                "price": {
+                    "type": "number",
+                    "maximum": 100000,
+                    "minimum": 0
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "main.createLocationPayload": {
+            "type": "object",
+            "required": [
+                "code",
+                "name"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.createOrderPayload": {
+            "type": "object",
+            "required": [
+                "items",
+                "payment_method"
+            ],
+            "properties": {
+                "address_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "coupon_code": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.OrderItemPayload"
+                    }
+                },
+                "payment_method": {
+                    "type": "string",
+                    "enum": [
+                        "cash_on_delivery",
+                        "Bkash",
+                        "credit_card"
+                    ]
+                },
+                "shipping_address": {
+                    "type": "string",
+                    "minLength": 5
+                },
+                "shipping_region": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.createPromotionPayload": {
+            "type": "object",
+            "required": [
+                "code",
+                "discount_type",
+                "discount_value"
+            ],
+            "properties": {
+                "book_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "code": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "discount_type": {
+                    "type": "string",
+                    "enum": [
+                        "percentage",
+                        "fixed"
+                    ]
+                },
+                "discount_value": {
+                    "type": "number"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "min_order_value": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "scope": {
+                    "type": "string",
+                    "enum": [
+                        "order",
+                        "tag",
+                        "book"
+                    ]
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "usage_limit": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "usage_limit_per_user": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "main.createRefundPayload": {
+            "type": "object",
+            "required": [
+                "amount",
+                "reason"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "main.createReturnPayload": {
+            "type": "object",
+            "required": [
+                "order_item_id",
+                "quantity",
+                "reason"
+            ],
+            "properties": {
+                "order_item_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "photos_url": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "minLength": 5
+                }
+            }
+        },
+        "main.createShipmentPayload": {
+            "type": "object",
+            "required": [
+                "carrier",
+                "tracking_number"
+            ],
+            "properties": {
+                "carrier": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "items": {
+                    "type": "array",
+                    "uniqueItems": true,
+                    "items": {
+                        "$ref": "#/definitions/main.shipmentItemPayload"
+                    }
+                },
+                "tracking_number": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "tracking_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.createShippingRatePayload": {
+            "type": "object",
+            "required": [
+                "method",
+                "name"
+            ],
+            "properties": {
+                "base_amount": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "free_over": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "flat",
+                        "per_item",
+                        "page_count"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "per_unit_amount": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "unit_size": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "main.createStockAdjustmentPayload": {
+            "type": "object",
+            "required": [
+                "quantity",
+                "reason"
+            ],
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "receipt",
+                        "adjustment"
+                    ]
+                },
+                "location_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "main.createStockTransferPayload": {
+            "type": "object",
+            "required": [
+                "from_location_id",
+                "quantity",
+                "to_location_id"
+            ],
+            "properties": {
+                "from_location_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "to_location_id": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "main.createTaxRatePayload": {
+            "type": "object",
+            "properties": {
+                "applies_to_shipping": {
+                    "type": "boolean"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "rate": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.createUserPayload": {
+            "type": "object",
+            "required": [
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 8
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "moderator",
+                        "admin"
+                    ]
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "main.createWishlistPayload": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "private",
+                        "unlisted",
+                        "public"
+                    ]
+                }
+            }
+        },
+        "main.loginUserPayload": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.moveToCartFailure": {
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.moveToCartResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.moveToCartFailure"
+                    }
+                },
+                "moved": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "main.passwordResetPayload": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "token",
+                "user_id"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string",
+                    "minLength": 5
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.passwordResetRequestPayload": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.passwordResetResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.quoteOrderPayload": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "address_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "coupon_code": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "items": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/main.OrderItemPayload"
+                    }
+                },
+                "shipping_region": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.receiveReturnPayload": {
+            "type": "object",
+            "properties": {
+                "refund_amount": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "restock": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.receiveReturnResponse": {
+            "type": "object",
+            "properties": {
+                "refund": {
+                    "$ref": "#/definitions/store.Refund"
+                },
+                "return": {
+                    "$ref": "#/definitions/store.ReturnRequest"
+                }
+            }
+        },
+        "main.removeWishlistResponse": {
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.reviewReturnPayload": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "approved",
+                        "rejected"
+                    ]
+                }
+            }
+        },
+        "main.sharedWishlistItem": {
+            "type": "object",
+            "properties": {
+                "book": {
+                    "$ref": "#/definitions/store.Book"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.shipmentItemPayload": {
+            "type": "object",
+            "required": [
+                "book_id",
+                "quantity"
+            ],
+            "properties": {
+                "book_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "main.updateAddressPayload": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                },
+                "is_default": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "line1": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1
+                },
+                "line2": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30,
+                    "minLength": 5
+                },
+                "postal_code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "recipient": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.updateBookPayload": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "cover_image_url": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "pages": {
+                    "type": "integer",
+                    "maximum": 100000,
+                    "minimum": 1
+                },
+                "price": {
+                    "type": "number",
+                    "maximum": 100000,
+                    "minimum": 0
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "main.updateItemPayload": {
+            "type": "object",
+            "required": [
+                "quantity"
+            ],
+            "properties": {
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "main.updateLocationPayload": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.updateOrderAdminPayload": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "payment_method": {
+                    "type": "string",
+                    "enum": [
+                        "cash_on_delivery",
+                        "Bkash",
+                        "credit_card"
+                    ]
+                },
+                "shipping_address": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "processing",
+                        "shipped",
+                        "delivered",
+                        "cancelled",
+                        "returned",
+                        "failed",
+                        "refunded"
+                    ]
+                }
+            }
+        },
+        "main.updateOrderPayload": {
+            "type": "object",
+            "properties": {
+                "shipping_address": {
+                    "type": "string",
+                    "minLength": 1
+                }
+            }
+        },
+        "main.updatePromotionPayload": {
+            "type": "object",
+            "properties": {
+                "book_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "code": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "discount_type": {
+                    "type": "string",
+                    "enum": [
+                        "percentage",
+                        "fixed"
+                    ]
+                },
+                "discount_value": {
+                    "type": "number"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "min_order_value": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "scope": {
+                    "type": "string",
+                    "enum": [
+                        "order",
+                        "tag",
+                        "book"
+                    ]
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "usage_limit": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "usage_limit_per_user": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "main.updateReviewPayload": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "rating": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                }
+            }
+        },
+        "main.updateShippingRatePayload": {
+            "type": "object",
+            "properties": {
+                "base_amount": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "free_over": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "flat",
+                        "per_item",
+                        "page_count"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "per_unit_amount": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "unit_size": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "main.updateTaxRatePayload": {
+            "type": "object",
+            "properties": {
+                "applies_to_shipping": {
+                    "type": "boolean"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "rate": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.updateUserPayload": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string",
+                    "minLength": 2
+                }
+            }
+        },
+        "main.updateWishlistItemPayload": {
+            "type": "object",
+            "properties": {
+                "clear_target": {
+                    "type": "boolean"
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "price_alert": {
+                    "type": "boolean"
+                },
+                "priority": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 0
+                },
+                "target_price": {
+                    "type": "number",
+                    "minimum": 0
+                }
+            }
+        },
+        "main.updateWishlistPayload": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "regenerate_share_token": {
+                    "type": "boolean"
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "private",
+                        "unlisted",
+                        "public"
+                    ]
+                }
+            }
+        },
+        "store.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_default": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "line1": {
+                    "type": "string"
+                },
+                "line2": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.Book": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "cover_image_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "pages": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.BookImportRow": {
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.BookSearchPage": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.BookSearchResult"
+                    }
+                },
+                "did_you_mean": {
+                    "type": "string"
+                },
+                "facets": {
+                    "$ref": "#/definitions/store.SearchFacets"
+                },
+                "fuzzy": {
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.BookSearchResult": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "cover_image_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "pages": {
+                    "type": "integer"
                 },
                 "price": {
                     "type": "number"
                 },
-                "quantity": {
-                    "type": "integer",
-                    "minimum": 1
+                "rank": {
+                    "type": "number"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "main.PasswordResetVerifyResponse": {
+        "store.BookSuggestion": {
             "type": "object",
             "properties": {
-                "message": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "kind": {
                     "type": "string"
                 },
-                "user_id": {
+                "value": {
                     "type": "string"
                 }
             }
         },
-        "main.TokenResponse": {
+        "store.CartItem": {
             "type": "object",
             "properties": {
-                "token": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "cart_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "main.UserWithToken": {
+        "store.CartItemWithBook": {
             "type": "object",
             "properties": {
-                "token": {
+                "author": {
                     "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/store.User"
+                "book_id": {
+                    "type": "integer"
+                },
+                "cart_id": {
+                    "type": "integer"
+                },
+                "cover_image_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reserved_until": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "main.addToCartPayload": {
+        "store.FacetCount": {
             "type": "object",
-            "required": [
-                "book_id",
-                "quantity"
-            ],
             "properties": {
-                "book_id": {
+                "count": {
                     "type": "integer"
                 },
-                "quantity": {
-                    "type": "integer",
-                    "maximum": 10,
-                    "minimum": 1
+                "value": {
+                    "type": "string"
                 }
             }
         },
-        "main.addToWishlistPayload": {
+        "store.LocationStock": {
             "type": "object",
-            "required": [
-                "book_id"
-            ],
             "properties": {
-                "book_id": {
+                "code": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
                     "type": "integer"
                 }
             }
         },
-        "main.createBookPayload": {
+        "store.LowStockBook": {
             "type": "object",
-            "required": [
-                "author",
-                "isbn",
-                "price",
-                "stock",
-                "title"
-            ],
             "properties": {
                 "author": {
-                    "type": "string",
-                    "maxLength": 255
-                },
-                "cover_image_url": {
                     "type": "string"
                 },
-                "description": {
-                    "type": "string",
-                    "maxLength": 1000
+                "book_id": {
+                    "type": "integer"
                 },
                 "isbn": {
                     "type": "string"
                 },
-                "pages": {
-                    "type": "integer",
-                    "maximum": 100000,
-                    "minimum": 1
-                },
-                "price": {
-                    "type": "number",
-                    "maximum": 100000,
-                    "minimum": 0
-                },
                 "stock": {
-                    "type": "integer",
-                    "minimum": 0
-                },
-                "tags": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                    "type": "integer"
                 },
                 "title": {
-                    "type": "string",
-                    "maxLength": 255
+                    "type": "string"
                 }
             }
         },
-        "main.createOrderPayload": {
+        "store.Order": {
             "type": "object",
-            "required": [
-                "items",
-                "payment_method",
-                "shipping_address",
-                "total_amount"
-            ],
             "properties": {
-                "items": {
+                "address_id": {
+                    "type": "integer"
+                },
+                "coupon_code": {
+                    "type": "string"
+                },
+                "discount_amount": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_items": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/main.OrderItemPayload"
+                        "$ref": "#/definitions/store.OrderItem"
                     }
                 },
                 "payment_method": {
-                    "type": "string",
-                    "enum": [
-                        "cash_on_delivery",
-                        "Bkash",
-                        "credit_card"
-                    ]
+                    "type": "string"
+                },
+                "placed_at": {
+                    "type": "string"
+                },
+                "refunded_amount": {
+                    "type": "number"
+                },
+                "shipments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Shipment"
+                    }
                 },
                 "shipping_address": {
-                    "type": "string",
-                    "minLength": 5
+                    "type": "string"
+                },
+                "shipping_amount": {
+                    "type": "number"
+                },
+                "shipping_details": {
+                    "$ref": "#/definitions/store.Address"
+                },
+                "shipping_region": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subtotal_amount": {
+                    "type": "number"
+                },
+                "tax_amount": {
+                    "type": "number"
                 },
                 "total_amount": {
                     "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "main.createUserPayload": {
+        "store.OrderItem": {
             "type": "object",
-            "required": [
-                "email",
-                "password",
-                "username"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "maxLength": 255
+                "book_id": {
+                    "type": "integer"
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 255,
-                    "minLength": 8
+                "discount_amount": {
+                    "type": "number"
                 },
-                "role": {
-                    "type": "string",
-                    "enum": [
-                        "user",
-                        "moderator",
-                        "admin"
-                    ]
+                "id": {
+                    "type": "integer"
                 },
-                "username": {
-                    "type": "string",
-                    "maxLength": 255
+                "order_id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "integer"
                 }
             }
         },
-        "main.loginUserPayload": {
+        "store.OrderPage": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string"
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Order"
+                    }
                 }
             }
         },
-        "main.passwordResetPayload": {
+        "store.OrderStatusChange": {
             "type": "object",
-            "required": [
-                "new_password",
-                "token",
-                "user_id"
-            ],
             "properties": {
-                "new_password": {
-                    "type": "string",
-                    "minLength": 5
+                "changed_at": {
+                    "type": "string"
                 },
-                "token": {
+                "changed_by": {
+                    "type": "integer"
+                },
+                "from_status": {
                     "type": "string"
                 },
-                "user_id": {
+                "id": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "order_id": {
                     "type": "integer"
+                },
+                "to_status": {
+                    "type": "string"
                 }
             }
         },
-        "main.passwordResetRequestPayload": {
+        "store.OrderValueSummary": {
             "type": "object",
-            "required": [
-                "email"
-            ],
             "properties": {
-                "email": {
-                    "type": "string"
+                "average_items": {
+                    "type": "number"
+                },
+                "average_order_value": {
+                    "type": "number"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "type": "number"
                 }
             }
         },
-        "main.passwordResetResponse": {
+        "store.Payment": {
             "type": "object",
             "properties": {
-                "message": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "needs_refund": {
+                    "type": "boolean"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "provider_reference": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "main.removeWishlistResponse": {
+        "store.PriceFacet": {
             "type": "object",
             "properties": {
-                "book_id": {
+                "count": {
                     "type": "integer"
                 },
-                "message": {
-                    "type": "string"
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
                 }
             }
         },
-        "main.updateBookPayload": {
+        "store.Promotion": {
             "type": "object",
             "properties": {
-                "author": {
-                    "type": "string",
-                    "maxLength": 50
+                "book_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 },
-                "cover_image_url": {
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
                     "type": "string"
                 },
+                "created_by": {
+                    "type": "integer"
+                },
                 "description": {
-                    "type": "string",
-                    "maxLength": 1000
+                    "type": "string"
                 },
-                "isbn": {
+                "discount_type": {
                     "type": "string"
                 },
-                "pages": {
-                    "type": "integer",
-                    "maximum": 100000,
-                    "minimum": 1
+                "discount_value": {
+                    "type": "number"
                 },
-                "price": {
-                    "type": "number",
-                    "maximum": 100000,
-                    "minimum": 0
+                "ends_at": {
+                    "type": "string"
                 },
-                "stock": {
-                    "type": "integer",
-                    "minimum": 0
+                "id": {
+                    "type": "integer"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "min_order_value": {
+                    "type": "number"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
                 },
                 "tags": {
                     "type": "array",
@@ -1771,152 +7049,300 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
-                "title": {
-                    "type": "string",
-                    "maxLength": 50
+                "times_used": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "usage_limit": {
+                    "type": "integer"
+                },
+                "usage_limit_per_user": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "main.updateItemPayload": {
+        "store.RatingFacet": {
             "type": "object",
-            "required": [
-                "quantity"
-            ],
             "properties": {
-                "quantity": {
-                    "type": "integer",
-                    "minimum": 1
+                "count": {
+                    "type": "integer"
+                },
+                "min_rating": {
+                    "type": "integer"
                 }
             }
         },
-        "main.updateOrderAdminPayload": {
+        "store.Refund": {
             "type": "object",
             "properties": {
-                "payment_method": {
-                    "type": "string",
-                    "enum": [
-                        "cash_on_delivery",
-                        "Bkash",
-                        "credit_card"
-                    ]
+                "amount": {
+                    "type": "number"
                 },
-                "shipping_address": {
-                    "type": "string",
-                    "minLength": 1
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "return_request_id": {
+                    "type": "integer"
                 },
                 "status": {
-                    "type": "string",
-                    "enum": [
-                        "pending",
-                        "processing",
-                        "shipped",
-                        "delivered",
-                        "cancelled",
-                        "returned",
-                        "failed",
-                        "refunded"
-                    ]
+                    "type": "string"
                 }
             }
         },
-        "main.updateOrderPayload": {
+        "store.ReturnRequest": {
             "type": "object",
             "properties": {
-                "shipping_address": {
-                    "type": "string",
-                    "minLength": 1
+                "admin_note": {
+                    "type": "string"
+                },
+                "book_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "order_item_id": {
+                    "type": "integer"
+                },
+                "photos_url": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "restocked": {
+                    "type": "boolean"
+                },
+                "reviewed_by": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "main.updateReviewPayload": {
+        "store.RevenuePoint": {
+            "type": "object",
+            "properties": {
+                "gross_revenue": {
+                    "type": "number"
+                },
+                "net_revenue": {
+                    "type": "number"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "period": {
+                    "type": "string"
+                },
+                "refunded": {
+                    "type": "number"
+                },
+                "units": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.Review": {
             "type": "object",
             "properties": {
+                "bookID": {
+                    "type": "integer"
+                },
                 "content": {
-                    "type": "string",
-                    "minLength": 1
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
                 },
                 "rating": {
-                    "type": "integer",
-                    "maximum": 5,
-                    "minimum": 1
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "integer"
                 }
             }
         },
-        "main.updateUserPayload": {
+        "store.Role": {
             "type": "object",
-            "required": [
-                "username"
-            ],
             "properties": {
-                "username": {
-                    "type": "string",
-                    "minLength": 2
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.SearchFacets": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.FacetCount"
+                    }
+                },
+                "availability": {
+                    "$ref": "#/definitions/store.StockFacet"
+                },
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.PriceFacet"
+                    }
+                },
+                "ratings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.RatingFacet"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.FacetCount"
+                    }
                 }
             }
         },
-        "store.Book": {
+        "store.Shipment": {
             "type": "object",
             "properties": {
-                "author": {
-                    "type": "string"
-                },
-                "cover_image_url": {
+                "carrier": {
                     "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
-                "description": {
-                    "type": "string"
-                },
-                "id": {
+                "created_by": {
                     "type": "integer"
                 },
-                "isbn": {
+                "delivered_at": {
                     "type": "string"
                 },
-                "pages": {
-                    "type": "integer"
-                },
-                "price": {
-                    "type": "number"
-                },
-                "stock": {
+                "id": {
                     "type": "integer"
                 },
-                "tags": {
+                "items": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/store.ShipmentItem"
                     }
                 },
-                "title": {
+                "order_id": {
+                    "type": "integer"
+                },
+                "shipped_at": {
                     "type": "string"
                 },
-                "updated_at": {
+                "tracking_number": {
                     "type": "string"
                 },
-                "version": {
-                    "type": "integer"
+                "tracking_url": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "store.CartItem": {
+        "store.ShipmentItem": {
             "type": "object",
             "properties": {
                 "book_id": {
                     "type": "integer"
                 },
-                "cart_id": {
+                "id": {
+                    "type": "integer"
+                },
+                "order_item_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "shipment_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "store.ShippingRate": {
+            "type": "object",
+            "properties": {
+                "base_amount": {
+                    "type": "number"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "free_over": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "quantity": {
+                "is_active": {
+                    "type": "boolean"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "per_unit_amount": {
+                    "type": "number"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "unit_size": {
                     "type": "integer"
                 },
                 "updated_at": {
@@ -1924,19 +7350,35 @@ const docTemplate = `{
                 }
             }
         },
-        "store.CartItemWithBook": {
+        "store.StatusCount": {
             "type": "object",
             "properties": {
-                "author": {
-                    "type": "string"
-                },
-                "book_id": {
+                "orders": {
                     "type": "integer"
                 },
-                "cart_id": {
+                "revenue": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.StockFacet": {
+            "type": "object",
+            "properties": {
+                "in_stock": {
                     "type": "integer"
                 },
-                "cover_image_url": {
+                "out_of_stock": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.StockLocation": {
+            "type": "object",
+            "properties": {
+                "code": {
                     "type": "string"
                 },
                 "created_at": {
@@ -1945,16 +7387,13 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
-                "price": {
-                    "type": "number"
-                },
-                "quantity": {
-                    "type": "integer"
+                "name": {
+                    "type": "string"
                 },
-                "stock": {
+                "priority": {
                     "type": "integer"
                 },
-                "title": {
+                "region": {
                     "type": "string"
                 },
                 "updated_at": {
@@ -1962,34 +7401,54 @@ const docTemplate = `{
                 }
             }
         },
-        "store.Order": {
+        "store.StockMovement": {
             "type": "object",
             "properties": {
-                "id": {
+                "actor_id": {
                     "type": "integer"
                 },
-                "order_items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/store.OrderItem"
-                    }
+                "book_id": {
+                    "type": "integer"
                 },
-                "payment_method": {
+                "created_at": {
                     "type": "string"
                 },
-                "placed_at": {
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
                     "type": "string"
                 },
-                "shipping_address": {
+                "location_id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reason": {
                     "type": "string"
                 },
-                "status": {
+                "stock_after": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.StockSubscription": {
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "total_amount": {
-                    "type": "number"
+                "id": {
+                    "type": "integer"
                 },
-                "updated_at": {
+                "notified_at": {
                     "type": "string"
                 },
                 "user_id": {
@@ -1997,66 +7456,98 @@ const docTemplate = `{
                 }
             }
         },
-        "store.OrderItem": {
+        "store.StockTransfer": {
             "type": "object",
             "properties": {
+                "actor_id": {
+                    "type": "integer"
+                },
                 "book_id": {
                     "type": "integer"
                 },
-                "id": {
+                "created_at": {
+                    "type": "string"
+                },
+                "from_location_id": {
                     "type": "integer"
                 },
-                "order_id": {
+                "id": {
                     "type": "integer"
                 },
-                "price": {
-                    "type": "number"
+                "note": {
+                    "type": "string"
                 },
                 "quantity": {
                     "type": "integer"
+                },
+                "to_location_id": {
+                    "type": "integer"
                 }
             }
         },
-        "store.Review": {
+        "store.TaxRate": {
             "type": "object",
             "properties": {
-                "bookID": {
-                    "type": "integer"
-                },
-                "content": {
-                    "type": "string"
+                "applies_to_shipping": {
+                    "type": "boolean"
                 },
-                "createdAt": {
+                "created_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "rating": {
-                    "type": "integer"
+                "is_active": {
+                    "type": "boolean"
                 },
-                "updatedAt": {
+                "rate": {
+                    "type": "number"
+                },
+                "region": {
                     "type": "string"
                 },
-                "userID": {
-                    "type": "integer"
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "store.Role": {
+        "store.TopAuthor": {
             "type": "object",
             "properties": {
-                "description": {
+                "author": {
                     "type": "string"
                 },
-                "id": {
+                "books": {
                     "type": "integer"
                 },
-                "level": {
+                "revenue": {
+                    "type": "number"
+                },
+                "units": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.TopBook": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "book_id": {
                     "type": "integer"
                 },
-                "name": {
+                "isbn": {
+                    "type": "string"
+                },
+                "revenue": {
+                    "type": "number"
+                },
+                "title": {
                     "type": "string"
+                },
+                "units": {
+                    "type": "integer"
                 }
             }
         },
@@ -2092,19 +7583,72 @@ const docTemplate = `{
         "store.Wishlist": {
             "type": "object",
             "properties": {
-                "bookID": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "createdAt": {
+                "is_default": {
+                    "type": "boolean"
+                },
+                "item_count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "share_token": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.WishlistItem": {
+            "type": "object",
+            "properties": {
+                "book": {
+                    "$ref": "#/definitions/store.Book"
+                },
+                "book_id": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "updatedAt": {
+                "note": {
                     "type": "string"
                 },
-                "userID": {
+                "price_alert": {
+                    "type": "boolean"
+                },
+                "price_at_add": {
+                    "type": "number"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "target_price": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "wishlist_id": {
                     "type": "integer"
                 }
             }
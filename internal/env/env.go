@@ -3,6 +3,7 @@ package env
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 func GetString(key, fallback string) string {
@@ -41,3 +42,17 @@ func GetBool(key string, fallback bool) bool {
 	}
 	return valAsInt
 }
+
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	val, ok := os.LookupEnv(key)
+
+	if !ok {
+		return fallback
+	}
+	valAsDuration, err := time.ParseDuration(val)
+
+	if err != nil {
+		return fallback
+	}
+	return valAsDuration
+}
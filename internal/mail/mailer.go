@@ -7,6 +7,10 @@ const (
 	maxRetries             = 3
 	UserWelcomeTemplate    = "user_invitation.tmpl"
 	PasswordChangeTemplate = "password_change.tmpl"
+	OrderCancelledTemplate = "order_cancelled.tmpl"
+	LowStockTemplate       = "low_stock.tmpl"
+	BackInStockTemplate    = "back_in_stock.tmpl"
+	PriceDropTemplate      = "price_drop.tmpl"
 )
 
 //go:embed "templates"
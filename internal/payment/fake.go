@@ -0,0 +1,102 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider keeps intents in memory and signs webhooks with HMAC-SHA256.
+// It is meant for local development and tests, never for real payments.
+type FakeProvider struct {
+	secret  string
+	mu      sync.Mutex
+	intents map[string]*Intent
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{
+		secret:  secret,
+		intents: make(map[string]*Intent),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) CreateIntent(ctx context.Context, orderID int, amount float64, currency string) (*Intent, error) {
+	intent := &Intent{
+		ID:           fmt.Sprintf("fake_pi_%d_%s", orderID, uuid.New().String()),
+		ClientSecret: uuid.New().String(),
+		Status:       IntentRequiresPayment,
+		Amount:       amount,
+		Currency:     currency,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.intents[intent.ID] = intent
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) Capture(ctx context.Context, intentID string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	intent.Status = IntentSucceeded
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, intentID string, amount float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return ErrIntentNotFound
+	}
+	if amount > intent.Amount {
+		return fmt.Errorf("refund of %.2f exceeds intent amount %.2f", amount, intent.Amount)
+	}
+	intent.Status = IntentRefunded
+	return nil
+}
+
+func (p *FakeProvider) VerifyWebhook(payload []byte, header http.Header) (*Event, error) {
+	expected := p.Sign(payload)
+	if !hmac.Equal([]byte(expected), []byte(header.Get(FakeSignatureHeader))) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Sign returns the signature the fake provider expects in the
+// X-Fake-Signature header, so webhooks can be simulated with curl.
+func (p *FakeProvider) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+const (
+	IntentRequiresPayment = "requires_payment"
+	IntentRequiresCapture = "requires_capture"
+	IntentSucceeded       = "succeeded"
+	IntentFailed          = "failed"
+	IntentRefunded        = "refunded"
+
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	EventPaymentRefunded  = "payment.refunded"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrIntentNotFound   = errors.New("payment intent not found")
+)
+
+// Intent is a provider side request to collect money for an order.
+type Intent struct {
+	ID           string
+	ClientSecret string
+	Status       string
+	Amount       float64
+	Currency     string
+}
+
+// Event is a verified webhook notification about an intent.
+type Event struct {
+	Type     string  `json:"type"`
+	IntentID string  `json:"intent_id"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, orderID int, amount float64, currency string) (*Intent, error)
+	Capture(ctx context.Context, intentID string) (*Intent, error)
+	Refund(ctx context.Context, intentID string, amount float64) error
+	VerifyWebhook(payload []byte, header http.Header) (*Event, error)
+}
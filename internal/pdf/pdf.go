@@ -0,0 +1,142 @@
+// Package pdf writes simple text documents, such as invoices and packing
+// slips, as PDF files using the standard Helvetica fonts, so no font files
+// or third party libraries are needed.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 in points.
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+	Margin     = 50.0
+)
+
+// Document lays out lines of text top to bottom and starts a new page when
+// the current one is full.
+type Document struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func New() *Document {
+	d := &Document{}
+	d.newPage()
+	return d
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = PageHeight - Margin
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// advance moves the cursor down by h points and returns the baseline to
+// draw at, breaking the page first if there is no room left.
+func (d *Document) advance(h float64) float64 {
+	if d.y-h < Margin {
+		d.newPage()
+	}
+	d.y -= h
+	return d.y
+}
+
+// Text writes a line of text at the left margin.
+func (d *Document) Text(size float64, bold bool, text string) {
+	d.Columns(size, bold, []float64{0}, []string{text})
+}
+
+// Columns writes one line with each text starting at the matching offset
+// from the left margin.
+func (d *Document) Columns(size float64, bold bool, offsets []float64, texts []string) {
+	y := d.advance(size * 1.5)
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	for i, text := range texts {
+		if i >= len(offsets) || text == "" {
+			continue
+		}
+		fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, Margin+offsets[i], y, escape(text))
+	}
+}
+
+// Rule draws a horizontal line across the page.
+func (d *Document) Rule() {
+	y := d.advance(6) + 3
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", Margin, y, PageWidth-Margin, y)
+}
+
+// Space leaves h points of vertical space.
+func (d *Document) Space(h float64) {
+	d.advance(h)
+}
+
+// WriteTo renders the document as a PDF file.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// objects 1-4 are the catalog, the page tree and the two fonts; every
+	// page then takes two objects, the page and its content stream
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+// escape converts text to a PDF string literal body. Characters outside
+// Latin-1 cannot be shown with the standard fonts and become '?'.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package pdf
+
+import "testing"
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "Invoice INV-000042", "Invoice INV-000042"},
+		{"parentheses", "Total (incl. tax)", `Total \(incl. tax\)`},
+		{"backslash", `C:\books`, `C:\\books`},
+		{"unbalanced parenthesis", "a) b", `a\) b`},
+		{"control characters", "line\nbreak\ttab", "line break tab"},
+		{"latin-1", "Café", `Caf\351`},
+		{"outside latin-1", "৳ 100", "? 100"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escape(tt.text); got != tt.want {
+				t.Errorf("escape(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Address struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Label      string    `json:"label"`
+	Recipient  string    `json:"recipient"`
+	Phone      string    `json:"phone"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2"`
+	City       string    `json:"city"`
+	Region     string    `json:"region"`
+	PostalCode string    `json:"postal_code"`
+	Country    string    `json:"country"`
+	IsDefault  bool      `json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// String formats the address as a single line, the way it is stored in
+// orders.shipping_address.
+func (a *Address) String() string {
+	parts := []string{a.Recipient, a.Line1}
+	if a.Line2 != "" {
+		parts = append(parts, a.Line2)
+	}
+	locality := strings.TrimSpace(strings.Join([]string{a.City, a.Region, a.PostalCode}, " "))
+	parts = append(parts, strings.Join(strings.Fields(locality), " "), a.Country)
+	return strings.Join(parts, ", ") + " (" + a.Phone + ")"
+}
+
+// ShippingRegion is the region used to look up shipping and tax rates.
+func (a *Address) ShippingRegion() string {
+	if a.Region != "" {
+		return a.Region
+	}
+	return a.Country
+}
+
+type AddressStore struct {
+	db *sql.DB
+}
+
+// Create inserts an address. The first address of a user always becomes the
+// default one, and a new default replaces the previous default.
+func (s *AddressStore) Create(ctx context.Context, address *Address) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var hasDefault bool
+		query := `SELECT EXISTS (SELECT 1 FROM user_addresses WHERE user_id = $1 AND is_default)`
+		if err := tx.QueryRowContext(ctx, query, address.UserID).Scan(&hasDefault); err != nil {
+			return err
+		}
+		if !hasDefault {
+			address.IsDefault = true
+		}
+		if address.IsDefault {
+			if err := clearDefaultAddress(ctx, tx, address.UserID); err != nil {
+				return err
+			}
+		}
+
+		query = `INSERT INTO user_addresses (user_id, label, recipient, phone, line1, line2, city, region, postal_code, country, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at, updated_at`
+
+		return tx.QueryRowContext(ctx, query,
+			address.UserID,
+			address.Label,
+			address.Recipient,
+			address.Phone,
+			address.Line1,
+			address.Line2,
+			address.City,
+			address.Region,
+			address.PostalCode,
+			address.Country,
+			address.IsDefault,
+		).Scan(&address.ID, &address.CreatedAt, &address.UpdatedAt)
+	})
+}
+
+func (s *AddressStore) GetByID(ctx context.Context, userID, ID int) (*Address, error) {
+	query := `SELECT id, user_id, label, recipient, phone, line1, line2, city, region, postal_code, country, is_default,
+	created_at, updated_at
+	FROM user_addresses WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	address := &Address{}
+	if err := scanAddress(s.db.QueryRowContext(ctx, query, ID, userID), address); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+	return address, nil
+}
+
+func (s *AddressStore) GetByUserID(ctx context.Context, userID int) ([]*Address, error) {
+	query := `SELECT id, user_id, label, recipient, phone, line1, line2, city, region, postal_code, country, is_default,
+	created_at, updated_at
+	FROM user_addresses WHERE user_id = $1 ORDER BY is_default DESC, id`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []*Address{}
+	for rows.Next() {
+		address := &Address{}
+		if err := scanAddress(rows, address); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// Update saves the address. Orders keep their own snapshot, so editing an
+// address never changes orders that were already placed with it.
+func (s *AddressStore) Update(ctx context.Context, address *Address) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(ctx, tx, address.UserID); err != nil {
+				return err
+			}
+		}
+
+		query := `UPDATE user_addresses SET label = $1, recipient = $2, phone = $3, line1 = $4, line2 = $5, city = $6,
+		region = $7, postal_code = $8, country = $9, is_default = $10, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $11 AND user_id = $12 RETURNING updated_at`
+
+		err := tx.QueryRowContext(ctx, query,
+			address.Label,
+			address.Recipient,
+			address.Phone,
+			address.Line1,
+			address.Line2,
+			address.City,
+			address.Region,
+			address.PostalCode,
+			address.Country,
+			address.IsDefault,
+			address.ID,
+			address.UserID,
+		).Scan(&address.UpdatedAt)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes an address. When the default address is removed the most
+// recently added remaining address becomes the default.
+func (s *AddressStore) Delete(ctx context.Context, userID, ID int) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var wasDefault bool
+		query := `DELETE FROM user_addresses WHERE id = $1 AND user_id = $2 RETURNING is_default`
+		if err := tx.QueryRowContext(ctx, query, ID, userID).Scan(&wasDefault); err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+		if !wasDefault {
+			return nil
+		}
+
+		query = `UPDATE user_addresses SET is_default = TRUE, updated_at = CURRENT_TIMESTAMP
+		WHERE id = (SELECT id FROM user_addresses WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1)`
+		_, err := tx.ExecContext(ctx, query, userID)
+		return err
+	})
+}
+
+func clearDefaultAddress(ctx context.Context, tx *sql.Tx, userID int) error {
+	query := `UPDATE user_addresses SET is_default = FALSE, updated_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND is_default`
+	_, err := tx.ExecContext(ctx, query, userID)
+	return err
+}
+
+func scanAddress(row rowScanner, address *Address) error {
+	return row.Scan(
+		&address.ID,
+		&address.UserID,
+		&address.Label,
+		&address.Recipient,
+		&address.Phone,
+		&address.Line1,
+		&address.Line2,
+		&address.City,
+		&address.Region,
+		&address.PostalCode,
+		&address.Country,
+		&address.IsDefault,
+		&address.CreatedAt,
+		&address.UpdatedAt,
+	)
+}
+
+// snapshotOrderAddress copies the referenced address book entry onto the
+// order so the order keeps it even if the entry is edited or deleted later.
+func snapshotOrderAddress(ctx context.Context, tx *sql.Tx, order *Order) error {
+	query := `SELECT id, user_id, label, recipient, phone, line1, line2, city, region, postal_code, country, is_default,
+	created_at, updated_at
+	FROM user_addresses WHERE id = $1 AND user_id = $2`
+
+	address := &Address{}
+	if err := scanAddress(tx.QueryRowContext(ctx, query, *order.AddressID, order.UserID), address); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return fmt.Errorf("address %d: %w", *order.AddressID, ErrorNotFound)
+		default:
+			return err
+		}
+	}
+	order.ShippingDetails = address
+	order.ShippingAddress = address.String()
+	order.ShippingRegion = address.ShippingRegion()
+	return nil
+}
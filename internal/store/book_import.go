@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+// DefaultImportBatchSize is how many rows an import commits at a time when
+// no batch size is given.
+const DefaultImportBatchSize = 500
+
+// errDryRun rolls back the transaction of a dry run once every row is done.
+var errDryRun = errors.New("dry run")
+
+// BookImportRow is one row of a catalog import and what became of it. Rows
+// that failed before reaching the store, such as invalid ones, come with
+// Status already set to ImportFailed and are left alone.
+type BookImportRow struct {
+	Line   int    `json:"line"`
+	ISBN   string `json:"isbn,omitempty"`
+	Status string `json:"status"`
+	BookID int    `json:"book_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Book   *Book  `json:"-"`
+}
+
+// BookImportOptions control an import. BatchSize rows are committed at a
+// time, DefaultImportBatchSize when zero. A dry run reports what would change
+// and writes nothing.
+type BookImportOptions struct {
+	BatchSize int
+	DryRun    bool
+	ActorID   int
+}
+
+// Import upserts the rows' books by ISBN: new books are created with their
+// stock recorded as a receipt, existing ones updated with the change in stock
+// recorded as an adjustment. A row that fails is rolled back on its own and
+// does not stop the others. Every row gets QueryTimeDuration to itself, so
+// the size of an import is only bounded by the caller's context. Dry runs use
+// a single transaction that is rolled back at the end, so rows still see the
+// books created by earlier ones.
+func (s *BookStore) Import(ctx context.Context, rows []*BookImportRow, opts BookImportOptions) error {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+	if opts.DryRun {
+		batchSize = max(len(rows), 1)
+	}
+
+	for start := 0; start < len(rows); start += batchSize {
+		batch := rows[start:min(start+batchSize, len(rows))]
+		err := s.importBatch(ctx, batch, opts)
+		if opts.DryRun && err == errDryRun {
+			// the books a dry run created are gone again
+			for _, row := range rows {
+				if row.Status == ImportCreated {
+					row.BookID = 0
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BookStore) importBatch(ctx context.Context, rows []*BookImportRow, opts BookImportOptions) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		for _, row := range rows {
+			if row.Status == ImportFailed {
+				continue
+			}
+			if err := importRow(ctx, tx, row, opts.ActorID); err != nil {
+				return err
+			}
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+}
+
+// importRow imports the row behind a savepoint, so that a failing row, one
+// that timed out included, is marked failed and rolled back on its own.
+func importRow(ctx context.Context, tx *sql.Tx, row *BookImportRow, actorID int) error {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+		return err
+	}
+	rowCtx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	err := importBook(rowCtx, tx, row, actorID)
+	cancel()
+	if err != nil {
+		if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_row`); err != nil {
+			return err
+		}
+		row.Status = ImportFailed
+		row.Error = err.Error()
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT import_row`)
+	return err
+}
+
+// importBook creates or updates the row's book. The ISBN stays locked until
+// the transaction ends, so a book created by a concurrent import or request
+// is updated rather than duplicated.
+func importBook(ctx context.Context, tx *sql.Tx, row *BookImportRow, actorID int) error {
+	book := row.Book
+
+	if err := lockISBN(ctx, tx, book.ISBN); err != nil {
+		return err
+	}
+	var stock int
+	query := `SELECT id, stock, version FROM books WHERE isbn = $1 FOR UPDATE`
+	err := tx.QueryRowContext(ctx, query, book.ISBN).Scan(&book.ID, &stock, &book.Version)
+	switch {
+	case err == sql.ErrNoRows:
+		if err := insertBook(ctx, tx, book, actorID); err != nil {
+			return err
+		}
+		row.Status = ImportCreated
+	case err != nil:
+		return err
+	default:
+		level := book.Stock
+		if err := updateBook(ctx, tx, book); err != nil {
+			return err
+		}
+		movement := &StockMovement{BookID: book.ID, Reason: "catalog import", ActorID: actorID}
+		if err := setStockLevel(ctx, tx, movement, level); err != nil {
+			return err
+		}
+		book.Stock = level
+		row.Status = ImportUpdated
+	}
+	row.BookID = book.ID
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/lib/pq"
@@ -26,22 +25,47 @@ type Book struct {
 	Version       int       `json:"version"`
 }
 
+var ErrDuplicateISBN = errors.New("a book with this ISBN already exists")
+
 type BookStore struct {
 	db *sql.DB
 }
 
+// Create inserts the book and records its initial stock as a receipt in the
+// stock ledger.
 func (s *BookStore) Create(ctx context.Context, book *Book) error {
-	query := `insert into books ( title, author, isbn, description, price, stock, tags, pages, cover_image_url) values ($1 , $2 , $3 , $4 , $5 ,$6 ,$7 , $8 , $9) RETURNING id , created_at , updated_at ;`
-
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
 
-	err := s.db.QueryRowContext(ctx, query, book.Title, book.Author, book.ISBN, book.Description, book.Price, book.Stock, pq.Array(book.Tags), book.Pages, book.CoverImageUrl).Scan(&book.ID, &book.CreatedAt, &book.UpdatedAt)
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return insertBook(ctx, tx, book, 0)
+	})
+}
+
+func insertBook(ctx context.Context, tx *sql.Tx, book *Book, actorID int) error {
+	query := `insert into books ( title, author, isbn, description, price, stock, tags, pages, cover_image_url) values ($1 , $2 , $3 , $4 , $5 , 0 ,$6 , $7 , $8) RETURNING id , created_at , updated_at , version ;`
 
+	if err := lockISBN(ctx, tx, book.ISBN); err != nil {
+		return err
+	}
+	err := tx.QueryRowContext(ctx, query, book.Title, book.Author, book.ISBN, book.Description, book.Price, pq.Array(book.Tags), book.Pages, book.CoverImageUrl).Scan(&book.ID, &book.CreatedAt, &book.UpdatedAt, &book.Version)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicateISBN
+		}
 		return err
 	}
-	return nil
+	if book.Stock == 0 {
+		return nil
+	}
+	return moveStock(ctx, tx, &StockMovement{
+		BookID:   book.ID,
+		Kind:     StockMovementReceipt,
+		Quantity: book.Stock,
+		Reason:   "initial stock",
+		ActorID:  actorID,
+	})
 }
 
 func (s *BookStore) GetByID(ctx context.Context, bookID int) (*Book, error) {
@@ -77,35 +101,94 @@ func (s *BookStore) GetByID(ctx context.Context, bookID int) (*Book, error) {
 	return book, nil
 }
 
+// Update saves the book's details. Stock is left alone: it only changes
+// through the stock ledger. When the price goes down, alerts are queued for
+// the users waiting on a lower price in the same transaction.
 func (s *BookStore) Update(ctx context.Context, book *Book) error {
-	query := `update books set title=$1 , author=$2 , isbn=$3 , description=$4 , price=$5 , stock=$6 , tags=$7 , pages=$8 , cover_image_url=$9 , version = version+1 where id = 1 and version=$10 RETURNING version;`
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
 
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return updateBook(ctx, tx, book)
+	})
+}
+
+// UpdateWithStock saves the book's details like Update and books the move to
+// the given stock level as an adjustment, both in one transaction so neither
+// is kept when the other fails.
+func (s *BookStore) UpdateWithStock(ctx context.Context, book *Book, movement *StockMovement, level int) error {
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
 
-	err := s.db.QueryRowContext(ctx, query,
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := updateBook(ctx, tx, book); err != nil {
+			return err
+		}
+		movement.BookID = book.ID
+		if err := setStockLevel(ctx, tx, movement, level); err != nil {
+			return err
+		}
+		if movement.ID != 0 {
+			book.Stock = movement.StockAfter
+			book.Version++
+		}
+		return nil
+	})
+}
+
+func updateBook(ctx context.Context, tx *sql.Tx, book *Book) error {
+	query := `update books set title=$1 , author=$2 , isbn=$3 , description=$4 , price=$5 , tags=$6 , pages=$7 , cover_image_url=$8 , version = version+1 where id = $9 and version=$10 RETURNING stock , version , price < $11;`
+
+	var oldPrice string
+	err := tx.QueryRowContext(ctx, `SELECT price FROM books WHERE id = $1 FOR UPDATE`, book.ID).Scan(&oldPrice)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrorNotFound
+		default:
+			return err
+		}
+	}
+
+	var priceDropped bool
+	err = tx.QueryRowContext(ctx, query,
 		book.Title,
 		book.Author,
 		book.ISBN,
 		book.Description,
 		book.Price,
-		book.Stock,
 		pq.Array(book.Tags),
 		book.Pages,
 		book.CoverImageUrl,
+		book.ID,
 		book.Version,
-	).Scan(&book.Version)
+		oldPrice,
+	).Scan(&book.Stock, &book.Version, &priceDropped)
 
 	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
 			return ErrorNotFound
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return ErrDuplicateISBN
 		default:
 			return err
 		}
 
 	}
-	return nil
+	if !priceDropped {
+		return nil
+	}
+	return recordPriceAlerts(ctx, tx, book.ID)
+}
+
+// lockISBN serializes the transactions adding a book with the ISBN until the
+// caller's transaction ends, so an import looking the ISBN up sees a book
+// created concurrently instead of inserting a duplicate.
+func lockISBN(ctx context.Context, tx *sql.Tx, isbn string) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, isbn)
+	return err
 }
 
 func (s *BookStore) Delete(ctx context.Context, bookID int) error {
@@ -123,94 +206,3 @@ func (s *BookStore) Delete(ctx context.Context, bookID int) error {
 	}
 	return nil
 }
-
-type BooksBySearchPayload struct {
-	Query    string
-	Title    string
-	Author   string
-	Tags     []string
-	MinPrice float32
-	MaxPrice float32
-	InStock  *bool
-}
-
-func (s *BookStore) SearchByBooks(ctx context.Context, filters BooksBySearchPayload) ([]*Book, error) {
-	query := `
-	SELECT id, title, author, isbn, price, tags, description,
-		   cover_image_url, pages, stock, created_at, updated_at, version
-	FROM books
-	WHERE 1=1
-`
-	args := []any{}
-	argID := 1
-	if filters.Query != "" {
-		query += fmt.Sprintf(" AND (title ILIKE $%d OR author ILIKE $%d OR description ILIKE $%d)", argID, argID+1, argID+2)
-		search := "%" + filters.Query + "%"
-		args = append(args, search, search, search)
-		argID += 3
-	}
-	if filters.Title != "" {
-		query += fmt.Sprintf(" AND title ILIKE $%d", argID)
-		args = append(args, "%"+filters.Title+"%")
-		argID++
-	}
-	if filters.Author != "" {
-		query += fmt.Sprintf(" AND author ILIKE $%d", argID)
-		args = append(args, "%"+filters.Author+"%")
-		argID++
-	}
-	if len(filters.Tags) > 0 {
-		query += fmt.Sprintf(" AND tags && $%d", argID)
-		args = append(args, pq.Array(filters.Tags))
-		argID++
-	}
-	if filters.MinPrice > 0 {
-		query += fmt.Sprintf(" AND price >=$%d", argID)
-		args = append(args, filters.MinPrice)
-		argID++
-	}
-	if filters.MaxPrice > 0 {
-		query += fmt.Sprintf(" AND price <=$%d", argID)
-		args = append(args, filters.MaxPrice)
-		argID++
-	}
-	if filters.InStock != nil {
-		if *filters.InStock {
-			query += " AND stock > 0"
-		}
-	}
-	query += " ORDER BY title ASC"
-	rows, err := s.db.QueryContext(ctx, query, args...)
-
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var books []*Book
-	for rows.Next() {
-		b := &Book{}
-
-		err := rows.Scan(
-			&b.ID,
-			&b.Title,
-			&b.Author,
-			&b.ISBN,
-			&b.Price,
-			pq.Array(&b.Tags),
-			&b.Description,
-			&b.CoverImageUrl,
-			&b.Pages,
-			&b.Stock,
-			&b.CreatedAt,
-			&b.UpdatedAt,
-			&b.Version,
-		)
-		if err != nil {
-			return nil, err
-		}
-		books = append(books, b)
-	}
-	return books, nil
-
-}
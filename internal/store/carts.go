@@ -23,17 +23,18 @@ type CartItem struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 type CartItemWithBook struct {
-	ID            int       `json:"id"`
-	CartID        int       `json:"cart_id"`
-	BookID        int       `json:"book_id"`
-	Quantity      int       `json:"quantity"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	Title         string    `json:"title"`
-	Author        string    `json:"author"`
-	Price         float64   `json:"price"`
-	CoverImageUrl string    `json:"cover_image_url"`
-	Stock         int       `json:"stock"`
+	ID            int        `json:"id"`
+	CartID        int        `json:"cart_id"`
+	BookID        int        `json:"book_id"`
+	Quantity      int        `json:"quantity"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Title         string     `json:"title"`
+	Author        string     `json:"author"`
+	Price         float64    `json:"price"`
+	CoverImageUrl string     `json:"cover_image_url"`
+	Stock         int        `json:"stock"`
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
 }
 type CartStore struct {
 	db *sql.DB
@@ -95,29 +96,89 @@ func (s *CartStore) GetCartItem(ctx context.Context, cartID int) (*CartItem, err
 	}
 	return &item, nil
 }
-func (s *CartStore) InsertOrUpdateCartItem(ctx context.Context, cartID, bookID, quantity int) error {
-	query := `INSERT INTO cart_items (cart_id , book_id , quantity) VALUES ($1 , $2 , $3 ) ON CONFLICT (cart_id , book_id)
-	DO UPDATE
-	SET quantity = cart_items.quantity + EXCLUDED.quantity , updated_at = CURRENT_TIMESTAMP
-	`
 
+// InsertOrUpdateCartItem adds copies of a book to the cart and reserves the
+// cart's whole quantity of it for hold, failing with an InsufficientStockError
+// when other carts' reservations leave too few copies.
+func (s *CartStore) InsertOrUpdateCartItem(ctx context.Context, cartID, bookID, quantity int, hold time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return addCartItem(ctx, tx, cartID, bookID, quantity, hold)
+	})
+}
+
+// MoveFromWishlist takes a book off the wishlist and puts one copy of it in
+// the cart in a single transaction. A book that is already in the cart only
+// leaves the list, so repeating a move never adds another copy.
+func (s *CartStore) MoveFromWishlist(ctx context.Context, cartID, wishlistID, bookID int, hold time.Duration) error {
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, query, cartID, bookID, quantity)
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM wishlist_items WHERE wishlist_id = $1 AND book_id = $2`, wishlistID, bookID)
+		if err != nil {
+			return err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrorNotFound
+		}
+
+		var inCart bool
+		query := `SELECT EXISTS (SELECT 1 FROM cart_items WHERE cart_id = $1 AND book_id = $2)`
+		if err := tx.QueryRowContext(ctx, query, cartID, bookID).Scan(&inCart); err != nil {
+			return err
+		}
+		if inCart {
+			return nil
+		}
+		return addCartItem(ctx, tx, cartID, bookID, 1, hold)
+	})
+}
+
+// addCartItem adds quantity copies of the book to the cart if enough stock is
+// left, and extends the cart's reservation to the new quantity.
+func addCartItem(ctx context.Context, tx *sql.Tx, cartID, bookID, quantity int, hold time.Duration) error {
+	available, err := availableForCart(ctx, tx, bookID, cartID)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	var current int
+	err = tx.QueryRowContext(ctx, `SELECT quantity FROM cart_items WHERE cart_id = $1 AND book_id = $2`, cartID, bookID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if current+quantity > available {
+		return &InsufficientStockError{BookID: bookID, Requested: current + quantity, Available: available}
+	}
+
+	query := `INSERT INTO cart_items (cart_id , book_id , quantity) VALUES ($1 , $2 , $3 ) ON CONFLICT (cart_id , book_id)
+	DO UPDATE
+	SET quantity = cart_items.quantity + EXCLUDED.quantity , updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := tx.ExecContext(ctx, query, cartID, bookID, quantity); err != nil {
+		return err
+	}
+	return reserve(ctx, tx, cartID, bookID, current+quantity, hold)
 }
 
 func (s *CartStore) GetCartItemsWithBooks(ctx context.Context, cartID int) ([]CartItemWithBook, error) {
 	query := `
 	SELECT
 	ci.id, ci.cart_id, ci.book_id, ci.quantity, ci.created_at, ci.updated_at,
-	b.title, b.author, b.price , b.cover_image_url , b.stock
+	b.title, b.author, b.price , b.cover_image_url ,
+	b.stock - COALESCE((SELECT SUM(r.quantity) FROM cart_reservations r
+		WHERE r.book_id = b.id AND r.cart_id <> ci.cart_id AND r.expires_at > CURRENT_TIMESTAMP), 0),
+	cr.expires_at
 	FROM cart_items ci
 	JOIN books b ON ci.book_id = b.id
+	LEFT JOIN cart_reservations cr ON cr.cart_id = ci.cart_id AND cr.book_id = ci.book_id AND cr.expires_at > CURRENT_TIMESTAMP
 	WHERE ci.cart_id = $1 ORDER BY ci.created_at ASC
 	`
 
@@ -133,6 +194,7 @@ func (s *CartStore) GetCartItemsWithBooks(ctx context.Context, cartID int) ([]Ca
 	var items []CartItemWithBook
 	for rows.Next() {
 		var item CartItemWithBook
+		var reservedUntil sql.NullTime
 		err := rows.Scan(
 			&item.ID,
 			&item.CartID,
@@ -145,32 +207,42 @@ func (s *CartStore) GetCartItemsWithBooks(ctx context.Context, cartID int) ([]Ca
 			&item.Price,
 			&item.CoverImageUrl,
 			&item.Stock,
+			&reservedUntil,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if reservedUntil.Valid {
+			item.ReservedUntil = &reservedUntil.Time
+		}
 		items = append(items, item)
 	}
 	return items, nil
 }
 
+// DeleteCartItem removes an item from the user's cart together with its
+// stock reservation. Items of other users' carts are not found.
 func (s *CartStore) DeleteCartItem(ctx context.Context, userID int, itemID int) error {
-	query := `DELETE FROM cart_items ci USING carts c
+	query := `WITH deleted AS (
+			DELETE FROM cart_items ci USING carts c
 			WHERE ci.cart_id = c.id
-			AND c.user_id = $1 AND ci.id= $2`
+			AND c.user_id = $1 AND ci.id= $2
+			RETURNING ci.cart_id, ci.book_id
+		), released AS (
+			DELETE FROM cart_reservations r USING deleted d
+			WHERE r.cart_id = d.cart_id AND r.book_id = d.book_id
+		)
+		SELECT COUNT(*) FROM deleted`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, query, userID, itemID)
-
-	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return ErrorNotFound
-		default:
-			return err
-		}
+	var deleted int
+	if err := s.db.QueryRowContext(ctx, query, userID, itemID).Scan(&deleted); err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrorNotFound
 	}
 	return nil
 }
@@ -194,28 +266,37 @@ func (s *CartStore) DeleteCart(ctx context.Context, userID int) error {
 	return nil
 }
 
-func (s *CartStore) UpdateQuantity(ctx context.Context, quantity int, itemID, userID int) error {
-	query := `
-	UPDATE cart_items ci
-	SET quantity = $1
-	FROM carts c
-	WHERE c.id = ci.cart_id
-  	AND ci.id = $2
-  	AND c.user_id = $3
-	`
-
+// UpdateQuantity sets the quantity of a cart item and renews its reservation
+// for hold.
+func (s *CartStore) UpdateQuantity(ctx context.Context, quantity int, itemID, userID int, hold time.Duration) error {
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, query, quantity, itemID, userID)
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var cartID, bookID int
+		query := `SELECT ci.cart_id, ci.book_id FROM cart_items ci JOIN carts c ON c.id = ci.cart_id
+		WHERE ci.id = $1 AND c.user_id = $2 FOR UPDATE OF ci`
+		if err := tx.QueryRowContext(ctx, query, itemID, userID).Scan(&cartID, &bookID); err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
 
-	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return ErrorNotFound
-		default:
+		available, err := availableForCart(ctx, tx, bookID, cartID)
+		if err != nil {
 			return err
 		}
-	}
-	return nil
+		if quantity > available {
+			return &InsufficientStockError{BookID: bookID, Requested: quantity, Available: available}
+		}
+
+		query = `UPDATE cart_items SET quantity = $1 , updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+		if _, err := tx.ExecContext(ctx, query, quantity, itemID); err != nil {
+			return err
+		}
+		return reserve(ctx, tx, cartID, bookID, quantity, hold)
+	})
 }
@@ -0,0 +1,411 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ShippingMethodFlat      = "flat"
+	ShippingMethodPerItem   = "per_item"
+	ShippingMethodPageCount = "page_count"
+)
+
+var ErrDuplicateTaxRegion = errors.New("a tax rate for this region already exists")
+
+// ShippingRate prices delivery to a region. An empty region is the fallback
+// used when no rate is configured for the order's region.
+type ShippingRate struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	Region        string    `json:"region"`
+	Method        string    `json:"method"`
+	BaseAmount    float64   `json:"base_amount"`
+	PerUnitAmount float64   `json:"per_unit_amount"`
+	UnitSize      int       `json:"unit_size"`
+	FreeOver      *float64  `json:"free_over"`
+	IsActive      bool      `json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// cost returns the shipping charge for a parcel of the given number of
+// copies and pages whose discounted goods total is goods.
+func (r *ShippingRate) cost(goods float64, quantity, pages int) float64 {
+	if r.FreeOver != nil && goods >= *r.FreeOver {
+		return 0
+	}
+	switch r.Method {
+	case ShippingMethodPerItem:
+		return roundCents(r.BaseAmount + r.PerUnitAmount*float64(quantity))
+	case ShippingMethodPageCount:
+		units := math.Ceil(float64(pages) / float64(r.UnitSize))
+		return roundCents(r.BaseAmount + r.PerUnitAmount*units)
+	default:
+		return roundCents(r.BaseAmount)
+	}
+}
+
+// TaxRate is the sales tax charged on orders shipped to a region. Rate is a
+// fraction, so 0.15 means 15%.
+type TaxRate struct {
+	ID                int       `json:"id"`
+	Region            string    `json:"region"`
+	Rate              float64   `json:"rate"`
+	AppliesToShipping bool      `json:"applies_to_shipping"`
+	IsActive          bool      `json:"is_active"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// tax returns the tax charged on the discounted goods total and, when the
+// rate applies to it, on the shipping charge.
+func (r *TaxRate) tax(goods, shipping float64) float64 {
+	taxable := goods
+	if r.AppliesToShipping {
+		taxable += shipping
+	}
+	return roundCents(taxable * r.Rate)
+}
+
+type ShippingRateStore struct {
+	db *sql.DB
+}
+
+func (s *ShippingRateStore) Create(ctx context.Context, rate *ShippingRate) error {
+	query := `INSERT INTO shipping_rates (name, region, method, base_amount, per_unit_amount, unit_size, free_over, is_active)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, query,
+		rate.Name,
+		rate.Region,
+		rate.Method,
+		rate.BaseAmount,
+		rate.PerUnitAmount,
+		rate.UnitSize,
+		rate.FreeOver,
+		rate.IsActive,
+	).Scan(&rate.ID, &rate.CreatedAt, &rate.UpdatedAt)
+}
+
+func (s *ShippingRateStore) GetByID(ctx context.Context, ID int) (*ShippingRate, error) {
+	query := `SELECT id, name, region, method, base_amount, per_unit_amount, unit_size, free_over, is_active, created_at, updated_at
+	FROM shipping_rates WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rate := &ShippingRate{}
+	if err := scanShippingRate(s.db.QueryRowContext(ctx, query, ID), rate); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+	return rate, nil
+}
+
+func (s *ShippingRateStore) List(ctx context.Context) ([]*ShippingRate, error) {
+	query := `SELECT id, name, region, method, base_amount, per_unit_amount, unit_size, free_over, is_active, created_at, updated_at
+	FROM shipping_rates ORDER BY region, id`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []*ShippingRate{}
+	for rows.Next() {
+		rate := &ShippingRate{}
+		if err := scanShippingRate(rows, rate); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (s *ShippingRateStore) Update(ctx context.Context, rate *ShippingRate) error {
+	query := `UPDATE shipping_rates SET name = $1, region = $2, method = $3, base_amount = $4, per_unit_amount = $5,
+	unit_size = $6, free_over = $7, is_active = $8, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $9 RETURNING updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		rate.Name,
+		rate.Region,
+		rate.Method,
+		rate.BaseAmount,
+		rate.PerUnitAmount,
+		rate.UnitSize,
+		rate.FreeOver,
+		rate.IsActive,
+		rate.ID,
+	).Scan(&rate.UpdatedAt)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrorNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ShippingRateStore) Delete(ctx context.Context, ID int) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM shipping_rates WHERE id = $1`, ID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrorNotFound
+	}
+	return nil
+}
+
+func scanShippingRate(row rowScanner, rate *ShippingRate) error {
+	var freeOver sql.NullFloat64
+	err := row.Scan(
+		&rate.ID,
+		&rate.Name,
+		&rate.Region,
+		&rate.Method,
+		&rate.BaseAmount,
+		&rate.PerUnitAmount,
+		&rate.UnitSize,
+		&freeOver,
+		&rate.IsActive,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	)
+	if freeOver.Valid {
+		rate.FreeOver = &freeOver.Float64
+	}
+	return err
+}
+
+type TaxRateStore struct {
+	db *sql.DB
+}
+
+func (s *TaxRateStore) Create(ctx context.Context, rate *TaxRate) error {
+	query := `INSERT INTO tax_rates (region, rate, applies_to_shipping, is_active)
+	VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, rate.Region, rate.Rate, rate.AppliesToShipping, rate.IsActive).Scan(
+		&rate.ID,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicateTaxRegion
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *TaxRateStore) GetByID(ctx context.Context, ID int) (*TaxRate, error) {
+	query := `SELECT id, region, rate, applies_to_shipping, is_active, created_at, updated_at FROM tax_rates WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rate := &TaxRate{}
+	err := s.db.QueryRowContext(ctx, query, ID).Scan(
+		&rate.ID,
+		&rate.Region,
+		&rate.Rate,
+		&rate.AppliesToShipping,
+		&rate.IsActive,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+	return rate, nil
+}
+
+func (s *TaxRateStore) List(ctx context.Context) ([]*TaxRate, error) {
+	query := `SELECT id, region, rate, applies_to_shipping, is_active, created_at, updated_at FROM tax_rates ORDER BY region`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []*TaxRate{}
+	for rows.Next() {
+		rate := &TaxRate{}
+		if err := rows.Scan(
+			&rate.ID,
+			&rate.Region,
+			&rate.Rate,
+			&rate.AppliesToShipping,
+			&rate.IsActive,
+			&rate.CreatedAt,
+			&rate.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (s *TaxRateStore) Update(ctx context.Context, rate *TaxRate) error {
+	query := `UPDATE tax_rates SET region = $1, rate = $2, applies_to_shipping = $3, is_active = $4, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $5 RETURNING updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, rate.Region, rate.Rate, rate.AppliesToShipping, rate.IsActive, rate.ID).Scan(&rate.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrorNotFound
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return ErrDuplicateTaxRegion
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TaxRateStore) Delete(ctx context.Context, ID int) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM tax_rates WHERE id = $1`, ID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrorNotFound
+	}
+	return nil
+}
+
+// applyCharges sets the shipping, tax and total amounts of a priced order.
+func applyCharges(ctx context.Context, tx *sql.Tx, order *Order, books map[int]orderBook) error {
+	goods := roundCents(order.SubtotalAmount - order.DiscountAmount)
+
+	var quantity, pages int
+	for _, item := range order.Items {
+		quantity += item.Quantity
+		pages += books[item.BookID].pages * item.Quantity
+	}
+
+	shipping, err := shippingCost(ctx, tx, order.ShippingRegion, goods, quantity, pages)
+	if err != nil {
+		return err
+	}
+	order.ShippingAmount = shipping
+
+	query := `SELECT rate, applies_to_shipping FROM tax_rates
+	WHERE is_active AND (region = '' OR lower(region) = lower($1))
+	ORDER BY region = '' LIMIT 1`
+
+	rate := &TaxRate{}
+	err = tx.QueryRowContext(ctx, query, order.ShippingRegion).Scan(&rate.Rate, &rate.AppliesToShipping)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		order.TaxAmount = 0
+	case err != nil:
+		return err
+	default:
+		order.TaxAmount = rate.tax(goods, order.ShippingAmount)
+	}
+
+	order.TotalAmount = roundCents(goods + order.ShippingAmount + order.TaxAmount)
+	return nil
+}
+
+// shippingCost picks the cheapest active rate for the region. Rates
+// configured for the region win over the regionless fallbacks, and no
+// matching rate at all means shipping is free.
+func shippingCost(ctx context.Context, tx *sql.Tx, region string, goods float64, quantity, pages int) (float64, error) {
+	query := `SELECT id, name, region, method, base_amount, per_unit_amount, unit_size, free_over, is_active, created_at, updated_at
+	FROM shipping_rates
+	WHERE is_active AND region = (
+		SELECT region FROM shipping_rates
+		WHERE is_active AND (region = '' OR lower(region) = lower($1))
+		ORDER BY region = '' LIMIT 1
+	)`
+
+	rows, err := tx.QueryContext(ctx, query, region)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var cheapest float64
+	found := false
+	for rows.Next() {
+		rate := &ShippingRate{}
+		if err := scanShippingRate(rows, rate); err != nil {
+			return 0, err
+		}
+		cost := rate.cost(goods, quantity, pages)
+		if !found || cost < cheapest {
+			cheapest = cost
+			found = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return cheapest, nil
+}
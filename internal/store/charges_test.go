@@ -0,0 +1,52 @@
+package store
+
+import "testing"
+
+func TestShippingRateCost(t *testing.T) {
+	freeOver := 50.0
+	tests := []struct {
+		name     string
+		rate     ShippingRate
+		goods    float64
+		quantity int
+		pages    int
+		want     float64
+	}{
+		{"flat", ShippingRate{Method: ShippingMethodFlat, BaseAmount: 4.99}, 20, 3, 900, 4.99},
+		{"per item", ShippingRate{Method: ShippingMethodPerItem, BaseAmount: 2, PerUnitAmount: 1.25}, 20, 3, 900, 5.75},
+		{"page count rounds units up", ShippingRate{Method: ShippingMethodPageCount, BaseAmount: 1, PerUnitAmount: 0.5, UnitSize: 250}, 20, 3, 501, 2.5},
+		{"page count on a unit boundary", ShippingRate{Method: ShippingMethodPageCount, BaseAmount: 1, PerUnitAmount: 0.5, UnitSize: 250}, 20, 2, 500, 2},
+		{"free over the threshold", ShippingRate{Method: ShippingMethodFlat, BaseAmount: 4.99, FreeOver: &freeOver}, 50, 1, 100, 0},
+		{"charged under the threshold", ShippingRate{Method: ShippingMethodFlat, BaseAmount: 4.99, FreeOver: &freeOver}, 49.99, 1, 100, 4.99},
+		{"rounded to cents", ShippingRate{Method: ShippingMethodPerItem, PerUnitAmount: 0.333}, 20, 3, 300, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rate.cost(tt.goods, tt.quantity, tt.pages); got != tt.want {
+				t.Errorf("cost(%v, %d, %d) = %v, want %v", tt.goods, tt.quantity, tt.pages, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaxRateTax(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     TaxRate
+		goods    float64
+		shipping float64
+		want     float64
+	}{
+		{"goods only", TaxRate{Rate: 0.15}, 100, 10, 15},
+		{"goods and shipping", TaxRate{Rate: 0.15, AppliesToShipping: true}, 100, 10, 16.5},
+		{"rounded to cents", TaxRate{Rate: 0.075}, 19.99, 0, 1.5},
+		{"zero rate", TaxRate{Rate: 0, AppliesToShipping: true}, 100, 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rate.tax(tt.goods, tt.shipping); got != tt.want {
+				t.Errorf("tax(%v, %v) = %v, want %v", tt.goods, tt.shipping, got, tt.want)
+			}
+		})
+	}
+}
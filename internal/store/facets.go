@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// SearchFacetSize is how many tags and authors a facet lists at most.
+const SearchFacetSize = 20
+
+// PriceFacetBounds splits prices into the buckets of the price facet: under
+// the first bound, between each pair of bounds and from the last one up.
+var PriceFacetBounds = []float64{10, 25, 50, 100}
+
+// SearchFacets counts the books matching a search by the values they could
+// be narrowed down by further.
+type SearchFacets struct {
+	Tags         []FacetCount  `json:"tags"`
+	Authors      []FacetCount  `json:"authors"`
+	Prices       []PriceFacet  `json:"prices"`
+	Ratings      []RatingFacet `json:"ratings"`
+	Availability StockFacet    `json:"availability"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// PriceFacet counts the books priced from Min up to, but not including, Max.
+// The last bucket has no Max.
+type PriceFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int      `json:"count"`
+}
+
+// RatingFacet counts the books rated MinRating stars or better on average.
+// Books without reviews are in none of the buckets.
+type RatingFacet struct {
+	MinRating int `json:"min_rating"`
+	Count     int `json:"count"`
+}
+
+type StockFacet struct {
+	InStock    int `json:"in_stock"`
+	OutOfStock int `json:"out_of_stock"`
+}
+
+// SearchFacets counts the books matching the same filters as SearchByBooks
+// per tag, author, price bucket, average rating and availability. The most
+// common tags and authors come first.
+func (s *BookStore) SearchFacets(ctx context.Context, filters BooksBySearchPayload) (*SearchFacets, error) {
+	from, where, args := searchConditions(filters)
+	args = append(args, SearchFacetSize, pq.Array(PriceFacetBounds))
+	sizeArg, boundsArg := len(args)-1, len(args)
+
+	query := fmt.Sprintf(`WITH matched AS (
+		SELECT books.author, books.tags, books.price, %s AS available, %s AS rating
+		FROM %s %s
+	)
+	(SELECT 'tag', tag, COUNT(*) FROM matched, unnest(matched.tags) tag GROUP BY tag ORDER BY 3 DESC, 2 LIMIT $%[5]d)
+	UNION ALL
+	(SELECT 'author', author, COUNT(*) FROM matched GROUP BY author ORDER BY 3 DESC, 2 LIMIT $%[5]d)
+	UNION ALL
+	SELECT 'price', width_bucket(price::float8, $%[6]d::float8[])::text, COUNT(*) FROM matched GROUP BY 2
+	UNION ALL
+	SELECT 'rating', FLOOR(rating)::int::text, COUNT(*) FROM matched WHERE rating > 0 GROUP BY 2
+	UNION ALL
+	SELECT 'stock', (available > 0)::text, COUNT(*) FROM matched GROUP BY 2`,
+		availableStock, bookRating, from, where, sizeArg, boundsArg)
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := &SearchFacets{
+		Tags:    []FacetCount{},
+		Authors: []FacetCount{},
+		Prices:  make([]PriceFacet, len(PriceFacetBounds)+1),
+		Ratings: make([]RatingFacet, 5),
+	}
+	for i := range facets.Prices {
+		if i > 0 {
+			facets.Prices[i].Min = PriceFacetBounds[i-1]
+		}
+		if i < len(PriceFacetBounds) {
+			max := PriceFacetBounds[i]
+			facets.Prices[i].Max = &max
+		}
+	}
+	// best ratings first; counted per whole star, then summed up below
+	for i := range facets.Ratings {
+		facets.Ratings[i].MinRating = 5 - i
+	}
+
+	for rows.Next() {
+		var facet, value string
+		var count int
+		if err := rows.Scan(&facet, &value, &count); err != nil {
+			return nil, err
+		}
+		switch facet {
+		case "tag":
+			facets.Tags = append(facets.Tags, FacetCount{Value: value, Count: count})
+		case "author":
+			facets.Authors = append(facets.Authors, FacetCount{Value: value, Count: count})
+		case "price":
+			bucket, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, err
+			}
+			facets.Prices[bucket].Count += count
+		case "rating":
+			stars, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, err
+			}
+			facets.Ratings[5-stars].Count += count
+		case "stock":
+			if value == "true" {
+				facets.Availability.InStock = count
+			} else {
+				facets.Availability.OutOfStock = count
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(facets.Ratings); i++ {
+		facets.Ratings[i].Count += facets.Ratings[i-1].Count
+	}
+	return facets, nil
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// IdempotencyKey remembers the outcome of a request sent with an
+// Idempotency-Key header. UserID is 0 for unauthenticated requests.
+type IdempotencyKey struct {
+	ID                  int
+	UserID              int
+	Key                 string
+	RequestHash         string
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseBody        []byte
+	CreatedAt           time.Time
+}
+
+// Completed reports whether a response has been stored for the key.
+func (k *IdempotencyKey) Completed() bool {
+	return k.ResponseStatus != 0
+}
+
+type IdempotencyStore struct {
+	db *sql.DB
+}
+
+// Reserve claims the key for a new request. It returns false when the key is
+// already held by a request younger than ttl; older entries are taken over.
+func (s *IdempotencyStore) Reserve(ctx context.Context, key *IdempotencyKey, ttl time.Duration) (bool, error) {
+	query := `INSERT INTO idempotency_keys (user_id, key, request_hash)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, key) DO UPDATE
+	SET request_hash = EXCLUDED.request_hash, response_status = NULL, response_content_type = '', response_body = NULL,
+		created_at = CURRENT_TIMESTAMP, completed_at = NULL
+	WHERE idempotency_keys.created_at < $4
+	RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, key.UserID, key.Key, key.RequestHash, time.Now().Add(-ttl)).Scan(
+		&key.ID,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (s *IdempotencyStore) Get(ctx context.Context, userID int, key string) (*IdempotencyKey, error) {
+	query := `SELECT id, user_id, key, request_hash, COALESCE(response_status, 0), response_content_type, response_body, created_at
+	FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	k := &IdempotencyKey{}
+	err := s.db.QueryRowContext(ctx, query, userID, key).Scan(
+		&k.ID,
+		&k.UserID,
+		&k.Key,
+		&k.RequestHash,
+		&k.ResponseStatus,
+		&k.ResponseContentType,
+		&k.ResponseBody,
+		&k.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// Complete stores the response that later requests with the same key replay.
+func (s *IdempotencyStore) Complete(ctx context.Context, key *IdempotencyKey) error {
+	query := `UPDATE idempotency_keys
+	SET response_status = $1, response_content_type = $2, response_body = $3, completed_at = CURRENT_TIMESTAMP
+	WHERE id = $4`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, key.ResponseStatus, key.ResponseContentType, key.ResponseBody, key.ID)
+	return err
+}
+
+// Delete releases a key so the request can be retried, e.g. after a server
+// error.
+func (s *IdempotencyStore) Delete(ctx context.Context, ID int) error {
+	query := `DELETE FROM idempotency_keys WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, ID)
+	return err
+}
+
+// DeleteExpired deletes keys older than ttl, which can no longer be replayed,
+// and returns how many were deleted.
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, time.Now().Add(-ttl))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrOrderNotPaid = errors.New("order has not been paid")
+
+// cashOnDeliveryPaidStatuses are the statuses in which a cash on delivery
+// order has been handed over and paid for.
+var cashOnDeliveryPaidStatuses = []string{OrderStatusDelivered, OrderStatusReturned, OrderStatusRefunded}
+
+type Invoice struct {
+	ID       int       `json:"id"`
+	OrderID  int       `json:"order_id"`
+	Number   int64     `json:"invoice_number"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// Code is the invoice number as printed on the document.
+func (i *Invoice) Code() string {
+	return fmt.Sprintf("INV-%06d", i.Number)
+}
+
+type InvoiceStore struct {
+	db *sql.DB
+}
+
+// GetOrIssue returns the invoice of the order, issuing one the first time it
+// is asked for. Numbers come from a single counter row that is incremented in
+// the same transaction as the insert, unlike a sequence, so a rolled back
+// issue hands its number out again and the numbering never skips. Only paid
+// orders get a number: those with a captured payment and cash on delivery
+// orders that have been delivered. Others fail with ErrOrderNotPaid.
+func (s *InvoiceStore) GetOrIssue(ctx context.Context, orderID int) (*Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	invoice := &Invoice{}
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var paid bool
+		query := `SELECT EXISTS (SELECT 1 FROM payments p WHERE p.order_id = o.id AND p.status IN ($2, $3) AND NOT p.needs_refund)
+			OR (o.payment_method = 'cash_on_delivery' AND o.status = ANY($4))
+		FROM orders o WHERE o.id = $1 FOR UPDATE`
+		err := tx.QueryRowContext(ctx, query, orderID, PaymentStatusSucceeded, PaymentStatusRefunded, pq.Array(cashOnDeliveryPaidStatuses)).Scan(&paid)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+
+		query = `SELECT id, order_id, invoice_number, issued_at FROM invoices WHERE order_id = $1`
+		err = tx.QueryRowContext(ctx, query, orderID).Scan(&invoice.ID, &invoice.OrderID, &invoice.Number, &invoice.IssuedAt)
+		if err != sql.ErrNoRows {
+			return err
+		}
+		if !paid {
+			return ErrOrderNotPaid
+		}
+
+		query = `UPDATE invoice_counter SET last_number = last_number + 1 RETURNING last_number`
+		if err := tx.QueryRowContext(ctx, query).Scan(&invoice.Number); err != nil {
+			return err
+		}
+
+		query = `INSERT INTO invoices (order_id, invoice_number) VALUES ($1, $2) RETURNING id, order_id, issued_at`
+		return tx.QueryRowContext(ctx, query, orderID, invoice.Number).Scan(&invoice.ID, &invoice.OrderID, &invoice.IssuedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
@@ -0,0 +1,266 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	AllocateByPriority  = "priority"
+	AllocateByMostStock = "most_stock"
+	AllocateByNearest   = "nearest"
+)
+
+// StockAllocation is the strategy that picks the locations an order's copies
+// are taken from. Nearest prefers locations in the order's shipping region
+// and falls back to priority order.
+var StockAllocation = AllocateByPriority
+
+var ErrDuplicateLocationCode = errors.New("a stock location with this code already exists")
+
+func IsAllocationStrategy(strategy string) bool {
+	switch strategy {
+	case AllocateByPriority, AllocateByMostStock, AllocateByNearest:
+		return true
+	}
+	return false
+}
+
+// StockLocation is a warehouse or shop holding stock. books.stock is the sum
+// of a book's stock over all locations. The location with the lowest
+// priority is the default one, used whenever stock is added without naming
+// a location.
+type StockLocation struct {
+	ID        int       `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Region    string    `json:"region"`
+	Priority  int       `json:"priority"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LocationStock is a book's stock at one location.
+type LocationStock struct {
+	LocationID int    `json:"location_id"`
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Quantity   int    `json:"quantity"`
+}
+
+type LocationStore struct {
+	db *sql.DB
+}
+
+func (s *LocationStore) Create(ctx context.Context, location *StockLocation) error {
+	query := `INSERT INTO stock_locations (code, name, region, priority)
+	VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, location.Code, location.Name, location.Region, location.Priority).Scan(
+		&location.ID,
+		&location.CreatedAt,
+		&location.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicateLocationCode
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *LocationStore) GetByID(ctx context.Context, ID int) (*StockLocation, error) {
+	query := `SELECT id, code, name, region, priority, created_at, updated_at FROM stock_locations WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	location := &StockLocation{}
+	err := s.db.QueryRowContext(ctx, query, ID).Scan(
+		&location.ID,
+		&location.Code,
+		&location.Name,
+		&location.Region,
+		&location.Priority,
+		&location.CreatedAt,
+		&location.UpdatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+	return location, nil
+}
+
+func (s *LocationStore) List(ctx context.Context) ([]*StockLocation, error) {
+	query := `SELECT id, code, name, region, priority, created_at, updated_at FROM stock_locations ORDER BY priority, id`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []*StockLocation{}
+	for rows.Next() {
+		location := &StockLocation{}
+		if err := rows.Scan(
+			&location.ID,
+			&location.Code,
+			&location.Name,
+			&location.Region,
+			&location.Priority,
+			&location.CreatedAt,
+			&location.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+func (s *LocationStore) Update(ctx context.Context, location *StockLocation) error {
+	query := `UPDATE stock_locations SET code = $1, name = $2, region = $3, priority = $4, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $5 RETURNING updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, location.Code, location.Name, location.Region, location.Priority, location.ID).Scan(&location.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrorNotFound
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return ErrDuplicateLocationCode
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBookStock returns the book's stock at every location, including the
+// ones that hold none of it.
+func (s *LocationStore) GetBookStock(ctx context.Context, bookID int) ([]*LocationStock, error) {
+	query := `SELECT l.id, l.code, l.name, COALESCE(bs.quantity, 0)
+	FROM stock_locations l
+	LEFT JOIN book_stock bs ON bs.location_id = l.id AND bs.book_id = $1
+	ORDER BY l.priority, l.id`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stock := []*LocationStock{}
+	for rows.Next() {
+		ls := &LocationStock{}
+		if err := rows.Scan(&ls.LocationID, &ls.Code, &ls.Name, &ls.Quantity); err != nil {
+			return nil, err
+		}
+		stock = append(stock, ls)
+	}
+	return stock, rows.Err()
+}
+
+func defaultLocationID(ctx context.Context, tx *sql.Tx) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM stock_locations ORDER BY priority, id LIMIT 1`).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, errors.New("no stock location configured")
+	}
+	return id, err
+}
+
+// allocateOrderItem takes the item's copies from the locations picked by
+// StockAllocation, splitting it over several locations when none holds
+// enough, and records where each copy came from.
+func allocateOrderItem(ctx context.Context, tx *sql.Tx, order *Order, item *OrderItem) error {
+	var orderBy string
+	args := []any{item.BookID}
+	switch StockAllocation {
+	case AllocateByMostStock:
+		orderBy = "bs.quantity DESC, l.priority, l.id"
+	case AllocateByNearest:
+		args = append(args, order.ShippingRegion)
+		orderBy = "(l.region <> '' AND LOWER(l.region) = LOWER($2)) DESC, l.priority, l.id"
+	default:
+		orderBy = "l.priority, l.id"
+	}
+
+	query := `SELECT bs.location_id, bs.quantity FROM book_stock bs
+	JOIN stock_locations l ON l.id = bs.location_id
+	WHERE bs.book_id = $1 AND bs.quantity > 0
+	ORDER BY ` + orderBy + `
+	FOR UPDATE OF bs`
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var allocations []*StockMovement
+	remaining := item.Quantity
+	for remaining > 0 && rows.Next() {
+		var locationID, quantity int
+		if err := rows.Scan(&locationID, &quantity); err != nil {
+			return err
+		}
+		take := min(quantity, remaining)
+		allocations = append(allocations, &StockMovement{
+			BookID:     item.BookID,
+			LocationID: locationID,
+			Kind:       StockMovementSale,
+			Quantity:   -take,
+			OrderID:    order.ID,
+			ActorID:    order.UserID,
+		})
+		remaining -= take
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+	if remaining > 0 {
+		return &InsufficientStockError{BookID: item.BookID, Requested: item.Quantity, Available: item.Quantity - remaining}
+	}
+
+	for _, sale := range allocations {
+		if err := moveStock(ctx, tx, sale); err != nil {
+			return err
+		}
+		query := `INSERT INTO order_item_allocations (order_item_id, location_id, quantity) VALUES ($1, $2, $3)`
+		if _, err := tx.ExecContext(ctx, query, item.ID, sale.LocationID, -sale.Quantity); err != nil {
+			return fmt.Errorf("allocating order item %d: %w", item.ID, err)
+		}
+	}
+	return nil
+}
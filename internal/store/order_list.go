@@ -0,0 +1,279 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	OrderSortPlacedAt    = "placed_at"
+	OrderSortTotalAmount = "total_amount"
+	OrderSortID          = "id"
+
+	DefaultOrderPageSize = 20
+	MaxOrderPageSize     = 500
+)
+
+// orders.placed_at has no time zone, so cursors carry its wall clock value
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// OrderFilters narrows down and orders the admin order listing. Zero values
+// mean "no filter". PlacedTo is exclusive.
+type OrderFilters struct {
+	Status        string
+	PaymentMethod string
+	UserID        int
+	PlacedFrom    *time.Time
+	PlacedTo      *time.Time
+	MinTotal      *float64
+	MaxTotal      *float64
+	SortBy        string
+	Descending    bool
+	Limit         int
+	Cursor        string
+}
+
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"next_cursor"`
+}
+
+// List returns one page of orders across all users. Pages are keyed on the
+// sort column and the order ID, so concurrent inserts never shift a page the
+// way OFFSET based pagination would; NextCursor is empty on the last page.
+func (s *OrderStore) List(ctx context.Context, filters OrderFilters) (*OrderPage, error) {
+	sortColumn, err := orderSortColumn(filters.SortBy)
+	if err != nil {
+		return nil, err
+	}
+	limit := filters.Limit
+	if limit <= 0 {
+		limit = DefaultOrderPageSize
+	}
+	limit = min(limit, MaxOrderPageSize)
+
+	query := `
+	SELECT id, user_id, subtotal_amount, discount_amount, shipping_amount, tax_amount, total_amount, refunded_amount,
+	COALESCE(coupon_code, ''), status, payment_method, shipping_address, shipping_region, address_id, shipping_details,
+	placed_at, updated_at
+	FROM orders
+	WHERE 1=1
+`
+	args := []any{}
+	argID := 1
+	if filters.Status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argID)
+		args = append(args, filters.Status)
+		argID++
+	}
+	if filters.PaymentMethod != "" {
+		query += fmt.Sprintf(" AND payment_method = $%d", argID)
+		args = append(args, filters.PaymentMethod)
+		argID++
+	}
+	if filters.UserID > 0 {
+		query += fmt.Sprintf(" AND user_id = $%d", argID)
+		args = append(args, filters.UserID)
+		argID++
+	}
+	if filters.PlacedFrom != nil {
+		query += fmt.Sprintf(" AND placed_at >= $%d", argID)
+		args = append(args, *filters.PlacedFrom)
+		argID++
+	}
+	if filters.PlacedTo != nil {
+		query += fmt.Sprintf(" AND placed_at < $%d", argID)
+		args = append(args, *filters.PlacedTo)
+		argID++
+	}
+	if filters.MinTotal != nil {
+		query += fmt.Sprintf(" AND total_amount >= $%d", argID)
+		args = append(args, *filters.MinTotal)
+		argID++
+	}
+	if filters.MaxTotal != nil {
+		query += fmt.Sprintf(" AND total_amount <= $%d", argID)
+		args = append(args, *filters.MaxTotal)
+		argID++
+	}
+
+	comparison, direction := ">", "ASC"
+	if filters.Descending {
+		comparison, direction = "<", "DESC"
+	}
+	if filters.Cursor != "" {
+		value, id, err := decodeOrderCursor(filters.Cursor, sortColumn)
+		if err != nil {
+			return nil, err
+		}
+		if sortColumn == OrderSortID {
+			query += fmt.Sprintf(" AND id %s $%d", comparison, argID)
+			args = append(args, id)
+			argID++
+		} else {
+			query += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", sortColumn, comparison, argID, argID+1)
+			args = append(args, value, id)
+			argID += 2
+		}
+	}
+	if sortColumn == OrderSortID {
+		query += fmt.Sprintf(" ORDER BY id %s", direction)
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, id %s", sortColumn, direction, direction)
+	}
+	// one extra row tells whether there is a next page
+	query += fmt.Sprintf(" LIMIT $%d", argID)
+	args = append(args, limit+1)
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &OrderPage{Orders: []Order{}}
+	for rows.Next() {
+		var order Order
+		var addressID sql.NullInt64
+		var shippingDetails []byte
+
+		err := rows.Scan(
+			&order.ID,
+			&order.UserID,
+			&order.SubtotalAmount,
+			&order.DiscountAmount,
+			&order.ShippingAmount,
+			&order.TaxAmount,
+			&order.TotalAmount,
+			&order.RefundedAmount,
+			&order.CouponCode,
+			&order.Status,
+			&order.PaymentMethod,
+			&order.ShippingAddress,
+			&order.ShippingRegion,
+			&addressID,
+			&shippingDetails,
+			&order.PlacedAt,
+			&order.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := setOrderAddress(&order, addressID, shippingDetails); err != nil {
+			return nil, err
+		}
+		page.Orders = append(page.Orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Orders) > limit {
+		page.Orders = page.Orders[:limit]
+		page.NextCursor = encodeOrderCursor(&page.Orders[limit-1], sortColumn)
+	}
+	if err := s.attachOrderItems(ctx, page.Orders); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *OrderStore) attachOrderItems(ctx context.Context, orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	index := make(map[int]int, len(orders))
+	ids := make([]int64, len(orders))
+	for i := range orders {
+		orders[i].Items = []OrderItem{}
+		index[orders[i].ID] = i
+		ids[i] = int64(orders[i].ID)
+	}
+
+	query := `SELECT id, order_id, book_id, quantity, price, discount_amount
+	FROM order_items WHERE order_id = ANY($1) ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item OrderItem
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.BookID, &item.Quantity, &item.Price, &item.DiscountAmount); err != nil {
+			return err
+		}
+		order := &orders[index[item.OrderID]]
+		order.Items = append(order.Items, item)
+	}
+	return rows.Err()
+}
+
+func orderSortColumn(sortBy string) (string, error) {
+	switch sortBy {
+	case "", OrderSortPlacedAt:
+		return OrderSortPlacedAt, nil
+	case OrderSortTotalAmount, OrderSortID:
+		return sortBy, nil
+	default:
+		return "", fmt.Errorf("cannot sort orders by %q", sortBy)
+	}
+}
+
+// encodeOrderCursor makes an opaque cursor out of the sort value and ID of
+// the last order on a page.
+func encodeOrderCursor(order *Order, sortColumn string) string {
+	var value string
+	switch sortColumn {
+	case OrderSortPlacedAt:
+		value = order.PlacedAt.Format(cursorTimeLayout)
+	case OrderSortTotalAmount:
+		value = strconv.FormatFloat(order.TotalAmount, 'f', -1, 64)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(value + "|" + strconv.Itoa(order.ID)))
+}
+
+func decodeOrderCursor(cursor, sortColumn string) (any, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	value, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	switch sortColumn {
+	case OrderSortPlacedAt:
+		if _, err := time.Parse(cursorTimeLayout, value); err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		return value, id, nil
+	case OrderSortTotalAmount:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		return f, id, nil
+	default:
+		return nil, id, nil
+	}
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+	OrderStatusReturned   = "returned"
+	OrderStatusFailed     = "failed"
+	OrderStatusRefunded   = "refunded"
+)
+
+var ErrInvalidStatusTransition = errors.New("invalid order status transition")
+
+// orderStatusTransitions lists, for every status, the statuses an order may
+// move to next. Statuses without an entry are terminal.
+var orderStatusTransitions = map[string][]string{
+	OrderStatusPending:    {OrderStatusProcessing, OrderStatusCancelled, OrderStatusFailed},
+	OrderStatusProcessing: {OrderStatusShipped, OrderStatusCancelled, OrderStatusFailed},
+	OrderStatusShipped:    {OrderStatusDelivered, OrderStatusReturned},
+	OrderStatusDelivered:  {OrderStatusReturned, OrderStatusRefunded},
+	OrderStatusReturned:   {OrderStatusRefunded},
+	OrderStatusCancelled:  {OrderStatusRefunded},
+}
+
+// CanTransitionOrderStatus reports whether an order may move from one status
+// to another.
+func CanTransitionOrderStatus(from, to string) bool {
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOrderEditable reports whether the customer may still change the order's
+// shipping details.
+func IsOrderEditable(status string) bool {
+	return status == OrderStatusPending || status == OrderStatusProcessing
+}
+
+type OrderStatusChange struct {
+	ID         int       `json:"id"`
+	OrderID    int       `json:"order_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  int       `json:"changed_by"`
+	Note       string    `json:"note"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// releasesStock reports whether moving an order into the status gives its
+// books back to the catalog.
+func releasesStock(status string) bool {
+	return status == OrderStatusCancelled || status == OrderStatusFailed
+}
+
+// UpdateStatus moves the order to the given status if the state machine
+// allows it and records the change in the status history.
+func (s *OrderStore) UpdateStatus(ctx context.Context, order *Order, status string, changedBy int, note string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return transitionOrderStatus(ctx, tx, order, status, changedBy, note)
+	})
+}
+
+func (s *OrderStore) GetStatusHistory(ctx context.Context, orderID int) ([]OrderStatusChange, error) {
+	query := `SELECT id, order_id, COALESCE(from_status, ''), to_status, COALESCE(changed_by, 0), COALESCE(note, ''), changed_at
+	FROM order_status_history WHERE order_id = $1 ORDER BY changed_at ASC, id ASC`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []OrderStatusChange{}
+	for rows.Next() {
+		var change OrderStatusChange
+		err := rows.Scan(
+			&change.ID,
+			&change.OrderID,
+			&change.FromStatus,
+			&change.ToStatus,
+			&change.ChangedBy,
+			&change.Note,
+			&change.ChangedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// transitionOrderStatus locks the order row, validates the transition against
+// the current status and writes both the new status and a history entry.
+// Cancelled and failed orders have the items that have not shipped restocked
+// and their coupon redemptions released.
+func transitionOrderStatus(ctx context.Context, tx *sql.Tx, order *Order, status string, changedBy int, note string) error {
+	var current string
+	err := tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, order.ID).Scan(&current)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrorNotFound
+		default:
+			return err
+		}
+	}
+	if !CanTransitionOrderStatus(current, status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, current, status)
+	}
+
+	query := `UPDATE orders SET status = $1 , updated_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING updated_at`
+	if err := tx.QueryRowContext(ctx, query, status, order.ID).Scan(&order.UpdatedAt); err != nil {
+		return err
+	}
+	order.Status = status
+
+	if err := recordOrderStatus(ctx, tx, order.ID, current, status, changedBy, note); err != nil {
+		return err
+	}
+	if releasesStock(status) {
+		if err := releasePromotions(ctx, tx, order.ID); err != nil {
+			return err
+		}
+		return restockOrderItems(ctx, tx, order.ID, changedBy, "order "+status)
+	}
+	return nil
+}
+
+func recordOrderStatus(ctx context.Context, tx *sql.Tx, orderID int, from, to string, changedBy int, note string) error {
+	query := `INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, note)
+	VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, 0), NULLIF($5, ''))`
+
+	_, err := tx.ExecContext(ctx, query, orderID, from, to, changedBy, note)
+	return err
+}
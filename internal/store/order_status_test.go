@@ -0,0 +1,40 @@
+package store
+
+import "testing"
+
+func TestCanTransitionOrderStatus(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{OrderStatusPending, OrderStatusProcessing, true},
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusPending, OrderStatusFailed, true},
+		{OrderStatusPending, OrderStatusShipped, false},
+		{OrderStatusPending, OrderStatusDelivered, false},
+		{OrderStatusProcessing, OrderStatusShipped, true},
+		{OrderStatusProcessing, OrderStatusCancelled, true},
+		{OrderStatusProcessing, OrderStatusPending, false},
+		{OrderStatusShipped, OrderStatusDelivered, true},
+		{OrderStatusShipped, OrderStatusReturned, true},
+		{OrderStatusShipped, OrderStatusCancelled, false},
+		{OrderStatusDelivered, OrderStatusReturned, true},
+		{OrderStatusDelivered, OrderStatusRefunded, true},
+		{OrderStatusDelivered, OrderStatusShipped, false},
+		{OrderStatusReturned, OrderStatusRefunded, true},
+		{OrderStatusReturned, OrderStatusDelivered, false},
+		{OrderStatusCancelled, OrderStatusRefunded, true},
+		{OrderStatusCancelled, OrderStatusPending, false},
+		{OrderStatusFailed, OrderStatusPending, false},
+		{OrderStatusFailed, OrderStatusProcessing, false},
+		{OrderStatusRefunded, OrderStatusDelivered, false},
+		{OrderStatusPending, OrderStatusPending, false},
+		{"unknown", OrderStatusProcessing, false},
+		{OrderStatusPending, "unknown", false},
+	}
+	for _, tt := range tests {
+		if got := CanTransitionOrderStatus(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionOrderStatus(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -3,60 +3,277 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"math"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type Order struct {
 	ID              int         `json:"id"`
 	UserID          int         `json:"user_id"`
+	SubtotalAmount  float64     `json:"subtotal_amount"`
+	DiscountAmount  float64     `json:"discount_amount"`
+	ShippingAmount  float64     `json:"shipping_amount"`
+	TaxAmount       float64     `json:"tax_amount"`
 	TotalAmount     float64     `json:"total_amount"`
+	RefundedAmount  float64     `json:"refunded_amount"`
+	CouponCode      string      `json:"coupon_code"`
 	Status          string      `json:"status"`
 	PaymentMethod   string      `json:"payment_method"`
 	ShippingAddress string      `json:"shipping_address"`
+	ShippingRegion  string      `json:"shipping_region"`
+	AddressID       *int        `json:"address_id"`
+	ShippingDetails *Address    `json:"shipping_details,omitempty"`
 	PlacedAt        time.Time   `json:"placed_at"`
 	UpdatedAt       time.Time   `json:"updated_at"`
 	Items           []OrderItem `json:"order_items"`
+	Shipments       []*Shipment `json:"shipments,omitempty"`
 }
 type OrderItem struct {
-	ID       int     `json:"id"`
-	OrderID  int     `json:"order_id"`
-	BookID   int     `json:"book_id"`
-	Quantity int     `json:"quantity"`
-	Price    float64 `json:"price"`
+	ID             int     `json:"id"`
+	OrderID        int     `json:"order_id"`
+	BookID         int     `json:"book_id"`
+	Quantity       int     `json:"quantity"`
+	Price          float64 `json:"price"`
+	DiscountAmount float64 `json:"discount_amount"`
 }
 
 type OrderStore struct {
 	db *sql.DB
 }
 
+// InsufficientStockError is returned when an order line asks for more copies
+// of a book than are currently in stock.
+type InsufficientStockError struct {
+	BookID    int
+	Requested int
+	Available int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for book %d: requested %d, available %d", e.BookID, e.Requested, e.Available)
+}
+
+// Create prices every line from the catalog, checks and decrements stock and
+// inserts the order in a single transaction. Client supplied prices and totals
+// are ignored.
 func (s *OrderStore) Create(ctx context.Context, order *Order) error {
 	return withTx(s.db, ctx, func(tx *sql.Tx) error {
-		query := `insert into orders ( user_id, total_amount, payment_method ,shipping_address)
-		values ($1 , $2 , $3  , $4 ) returning id , placed_at , updated_at;`
+		return s.create(ctx, tx, order)
+	})
+}
 
-		err := tx.QueryRowContext(ctx, query, order.UserID, order.TotalAmount, order.PaymentMethod, order.ShippingAddress).Scan(
-			&order.ID,
-			&order.PlacedAt,
-			&order.UpdatedAt,
-		)
+// CreateFromCart turns the items of a cart into an order and empties the
+// cart in the same transaction.
+func (s *OrderStore) CreateFromCart(ctx context.Context, order *Order, cartID int) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `SELECT book_id, quantity FROM cart_items WHERE cart_id = $1 ORDER BY id FOR UPDATE`
+
+		rows, err := tx.QueryContext(ctx, query, cartID)
 		if err != nil {
 			return err
 		}
-		for i := range order.Items {
-			order.Items[i].OrderID = order.ID
-			if err := s.createOrderItem(ctx, tx, &order.Items[i]); err != nil {
+		defer rows.Close()
+
+		order.Items = []OrderItem{}
+		for rows.Next() {
+			var item OrderItem
+			if err := rows.Scan(&item.BookID, &item.Quantity); err != nil {
 				return err
 			}
+			order.Items = append(order.Items, item)
 		}
-		return nil
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(order.Items) == 0 {
+			return ErrEmptyCart
+		}
+
+		if err := s.create(ctx, tx, order); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM cart_items WHERE cart_id = $1`, cartID); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM cart_reservations WHERE cart_id = $1`, cartID)
+		return err
 	})
 }
 
+// Quote prices a prospective order exactly like Create would, including
+// coupon, shipping and tax, without persisting anything or touching stock.
+// It takes no row locks, so quotes never wait on checkouts.
+func (s *OrderStore) Quote(ctx context.Context, order *Order) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		_, err := s.price(ctx, tx, order, false)
+		return err
+	})
+}
+
+// price fills in line prices and the subtotal, discount, shipping, tax and
+// total of the order. It returns the promotion that was applied, if any.
+// With lock set the books and the promotion stay locked until the
+// transaction ends, so an order can be created from the prices.
+func (s *OrderStore) price(ctx context.Context, tx *sql.Tx, order *Order, lock bool) (*Promotion, error) {
+	if order.AddressID != nil {
+		if err := snapshotOrderAddress(ctx, tx, order); err != nil {
+			return nil, err
+		}
+	}
+	order.Items = mergeOrderItems(order.Items)
+
+	books, err := s.priceOrderItems(ctx, tx, order, lock)
+	if err != nil {
+		return nil, err
+	}
+
+	var promotion *Promotion
+	if order.CouponCode != "" {
+		promotion, err = applyPromotion(ctx, tx, order, books, lock)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := applyCharges(ctx, tx, order, books); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (s *OrderStore) create(ctx context.Context, tx *sql.Tx, order *Order) error {
+	promotion, err := s.price(ctx, tx, order, true)
+	if err != nil {
+		return err
+	}
+
+	var shippingDetails []byte
+	if order.ShippingDetails != nil {
+		if shippingDetails, err = json.Marshal(order.ShippingDetails); err != nil {
+			return err
+		}
+	}
+
+	query := `insert into orders ( user_id, subtotal_amount, discount_amount, shipping_amount, tax_amount, total_amount,
+		coupon_code, payment_method ,shipping_address, shipping_region, address_id, shipping_details)
+		values ($1 , $2 , $3 , $4 , $5 , $6 , NULLIF($7, '') , $8 , $9 , $10 , $11 , $12 ) returning id , status , placed_at , updated_at;`
+
+	err = tx.QueryRowContext(ctx, query,
+		order.UserID,
+		order.SubtotalAmount,
+		order.DiscountAmount,
+		order.ShippingAmount,
+		order.TaxAmount,
+		order.TotalAmount,
+		order.CouponCode,
+		order.PaymentMethod,
+		order.ShippingAddress,
+		order.ShippingRegion,
+		order.AddressID,
+		shippingDetails,
+	).Scan(
+		&order.ID,
+		&order.Status,
+		&order.PlacedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := recordOrderStatus(ctx, tx, order.ID, "", order.Status, order.UserID, ""); err != nil {
+		return err
+	}
+	for i := range order.Items {
+		order.Items[i].OrderID = order.ID
+		if err := s.createOrderItem(ctx, tx, &order.Items[i]); err != nil {
+			return err
+		}
+		if err := allocateOrderItem(ctx, tx, order, &order.Items[i]); err != nil {
+			return err
+		}
+	}
+	if promotion != nil {
+		return redeemPromotion(ctx, tx, promotion, order)
+	}
+	return nil
+}
+
+// orderBook is the catalog row an order line is priced from.
+type orderBook struct {
+	price float64
+	stock int
+	pages int
+	tags  []string
+}
+
+// priceOrderItems reads the referenced books, locking them when asked, sets
+// each line price from books.price and computes the order subtotal. Copies
+// reserved by other users' carts do not count as available.
+func (s *OrderStore) priceOrderItems(ctx context.Context, tx *sql.Tx, order *Order, lock bool) (map[int]orderBook, error) {
+	bookIDs := make([]int64, len(order.Items))
+	for i, item := range order.Items {
+		bookIDs[i] = int64(item.BookID)
+	}
+
+	query := `SELECT b.id, b.price, b.stock - COALESCE((SELECT SUM(r.quantity) FROM cart_reservations r
+		JOIN carts c ON c.id = r.cart_id
+		WHERE r.book_id = b.id AND c.user_id <> $2 AND r.expires_at > CURRENT_TIMESTAMP), 0),
+	b.pages, b.tags
+	FROM books b WHERE b.id = ANY($1) ORDER BY b.id`
+	if lock {
+		query += ` FOR UPDATE OF b`
+	}
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(bookIDs), order.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := make(map[int]orderBook, len(bookIDs))
+	for rows.Next() {
+		var id int
+		var b orderBook
+		if err := rows.Scan(&id, &b.price, &b.stock, &b.pages, pq.Array(&b.tags)); err != nil {
+			return nil, err
+		}
+		books[id] = b
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var subtotal float64
+	for i := range order.Items {
+		item := &order.Items[i]
+		book, ok := books[item.BookID]
+		if !ok {
+			return nil, fmt.Errorf("book %d: %w", item.BookID, ErrorNotFound)
+		}
+		if book.stock < item.Quantity {
+			return nil, &InsufficientStockError{
+				BookID:    item.BookID,
+				Requested: item.Quantity,
+				Available: book.stock,
+			}
+		}
+		item.Price = book.price
+		item.DiscountAmount = 0
+		subtotal += book.price * float64(item.Quantity)
+	}
+	order.SubtotalAmount = roundCents(subtotal)
+	order.DiscountAmount = 0
+	return books, nil
+}
+
 func (s *OrderStore) createOrderItem(ctx context.Context, tx *sql.Tx, orderItem *OrderItem) error {
-	query := `insert into order_items ( order_id, book_id, quantity, price)
-	values ($1 , $2 , $3 , $4) returning id;`
-	err := tx.QueryRowContext(ctx, query, orderItem.OrderID, orderItem.BookID, orderItem.Quantity, orderItem.Price).Scan(
+	query := `insert into order_items ( order_id, book_id, quantity, price, discount_amount)
+	values ($1 , $2 , $3 , $4 , $5) returning id;`
+	err := tx.QueryRowContext(ctx, query, orderItem.OrderID, orderItem.BookID, orderItem.Quantity, orderItem.Price, orderItem.DiscountAmount).Scan(
 		&orderItem.ID,
 	)
 	if err != nil {
@@ -64,22 +281,97 @@ func (s *OrderStore) createOrderItem(ctx context.Context, tx *sql.Tx, orderItem
 	}
 	return nil
 }
+
+// mergeOrderItems collapses lines that reference the same book, since
+// order_items allows a book only once per order.
+func mergeOrderItems(items []OrderItem) []OrderItem {
+	merged := make([]OrderItem, 0, len(items))
+	index := make(map[int]int, len(items))
+	for _, item := range items {
+		if i, ok := index[item.BookID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.BookID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// GetBooks returns the catalog entry of every book in the order, keyed by
+// book ID, for documents that need titles next to the order lines.
+func (s *OrderStore) GetBooks(ctx context.Context, orderID int) (map[int]*Book, error) {
+	query := `SELECT b.id, b.title, b.author, b.isbn
+	FROM books b
+	JOIN order_items oi ON oi.book_id = b.id
+	WHERE oi.order_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := make(map[int]*Book)
+	for rows.Next() {
+		book := &Book{}
+		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.ISBN); err != nil {
+			return nil, err
+		}
+		books[book.ID] = book
+	}
+	return books, rows.Err()
+}
+
+// setOrderAddress fills in the address book reference and the address
+// snapshot read from orders.address_id and orders.shipping_details.
+func setOrderAddress(order *Order, addressID sql.NullInt64, shippingDetails []byte) error {
+	if addressID.Valid {
+		id := int(addressID.Int64)
+		order.AddressID = &id
+	}
+	if len(shippingDetails) == 0 {
+		return nil
+	}
+	order.ShippingDetails = &Address{}
+	return json.Unmarshal(shippingDetails, order.ShippingDetails)
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
 func (s *OrderStore) GetByID(ctx context.Context, ID int) (*Order, error) {
 
 	query := `
-	SELECT id, user_id, total_amount, status, payment_method, shipping_address, placed_at, updated_at
+	SELECT id, user_id, subtotal_amount, discount_amount, shipping_amount, tax_amount, total_amount, refunded_amount,
+	COALESCE(coupon_code, ''), status, payment_method, shipping_address, shipping_region, address_id, shipping_details,
+	placed_at, updated_at
 	FROM orders
 	WHERE id = $1;
 	`
 	order := &Order{}
+	var addressID sql.NullInt64
+	var shippingDetails []byte
 
 	err := s.db.QueryRowContext(ctx, query, ID).Scan(
 		&order.ID,
 		&order.UserID,
+		&order.SubtotalAmount,
+		&order.DiscountAmount,
+		&order.ShippingAmount,
+		&order.TaxAmount,
 		&order.TotalAmount,
+		&order.RefundedAmount,
+		&order.CouponCode,
 		&order.Status,
 		&order.PaymentMethod,
 		&order.ShippingAddress,
+		&order.ShippingRegion,
+		&addressID,
+		&shippingDetails,
 		&order.PlacedAt,
 		&order.UpdatedAt,
 	)
@@ -91,9 +383,12 @@ func (s *OrderStore) GetByID(ctx context.Context, ID int) (*Order, error) {
 			return nil, err
 		}
 	}
+	if err := setOrderAddress(order, addressID, shippingDetails); err != nil {
+		return nil, err
+	}
 	order.Items = []OrderItem{}
 	itemsQuery := `
-		SELECT id, order_id, book_id, quantity, price
+		SELECT id, order_id, book_id, quantity, price, discount_amount
 		FROM order_items
 		WHERE order_id = $1;
 	`
@@ -106,7 +401,7 @@ func (s *OrderStore) GetByID(ctx context.Context, ID int) (*Order, error) {
 	for rows.Next() {
 		var item OrderItem
 
-		if err := rows.Scan(&item.ID, &item.OrderID, &item.BookID, &item.Quantity, &item.Price); err != nil {
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.BookID, &item.Quantity, &item.Price, &item.DiscountAmount); err != nil {
 			return nil, err
 		}
 		order.Items = append(order.Items, item)
@@ -121,14 +416,23 @@ func (s *OrderStore) Update(ctx context.Context, order *Order) error {
 SET
     shipping_address = $1,
     payment_method = $2,
-    status = $3
+    address_id = $3,
+    shipping_details = $4
 WHERE
-    id = $4 AND user_id = $5;`
+    id = $5 AND user_id = $6;`
+
+	var shippingDetails []byte
+	if order.ShippingDetails != nil {
+		var err error
+		if shippingDetails, err = json.Marshal(order.ShippingDetails); err != nil {
+			return err
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
 
-	res, err := s.db.ExecContext(ctx, query, order.ShippingAddress, order.PaymentMethod, order.Status, order.ID, order.UserID)
+	res, err := s.db.ExecContext(ctx, query, order.ShippingAddress, order.PaymentMethod, order.AddressID, shippingDetails, order.ID, order.UserID)
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
@@ -148,9 +452,10 @@ WHERE
 }
 func (s *OrderStore) Get(ctx context.Context, userID int) ([]Order, error) {
 	query := `
-		SELECT o.id, o.user_id, o.total_amount, o.status, o.payment_method,
-		o.shipping_address, o.placed_at, o.updated_at,
-		oi.id, oi.order_id, oi.book_id, oi.quantity, oi.price
+		SELECT o.id, o.user_id, o.subtotal_amount, o.discount_amount, o.shipping_amount, o.tax_amount,
+		o.total_amount, o.refunded_amount, COALESCE(o.coupon_code, ''), o.status, o.payment_method,
+		o.shipping_address, o.shipping_region, o.address_id, o.shipping_details, o.placed_at, o.updated_at,
+		oi.id, oi.order_id, oi.book_id, oi.quantity, oi.price, oi.discount_amount
 		FROM orders o
 		LEFT JOIN order_items oi ON o.id = oi.order_id
 		WHERE o.user_id = $1
@@ -169,6 +474,8 @@ func (s *OrderStore) Get(ctx context.Context, userID int) ([]Order, error) {
 
 	for rows.Next() {
 		var order Order
+		var addressID sql.NullInt64
+		var shippingDetails []byte
 
 		// Nullable fields for order items
 		var itemID sql.NullInt64
@@ -176,14 +483,24 @@ func (s *OrderStore) Get(ctx context.Context, userID int) ([]Order, error) {
 		var itemBookID sql.NullInt64
 		var itemQuantity sql.NullInt64
 		var itemPrice sql.NullFloat64
+		var itemDiscount sql.NullFloat64
 
 		err := rows.Scan(
 			&order.ID,
 			&order.UserID,
+			&order.SubtotalAmount,
+			&order.DiscountAmount,
+			&order.ShippingAmount,
+			&order.TaxAmount,
 			&order.TotalAmount,
+			&order.RefundedAmount,
+			&order.CouponCode,
 			&order.Status,
 			&order.PaymentMethod,
 			&order.ShippingAddress,
+			&order.ShippingRegion,
+			&addressID,
+			&shippingDetails,
 			&order.PlacedAt,
 			&order.UpdatedAt,
 			&itemID,
@@ -191,6 +508,7 @@ func (s *OrderStore) Get(ctx context.Context, userID int) ([]Order, error) {
 			&itemBookID,
 			&itemQuantity,
 			&itemPrice,
+			&itemDiscount,
 		)
 		if err != nil {
 			return nil, err
@@ -199,21 +517,26 @@ func (s *OrderStore) Get(ctx context.Context, userID int) ([]Order, error) {
 		if existingOrder, exists := ordersMap[order.ID]; exists {
 			if itemID.Valid {
 				existingOrder.Items = append(existingOrder.Items, OrderItem{
-					ID:       int(itemID.Int64),
-					OrderID:  int(itemOrderID.Int64),
-					BookID:   int(itemBookID.Int64),
-					Quantity: int(itemQuantity.Int64),
-					Price:    itemPrice.Float64,
+					ID:             int(itemID.Int64),
+					OrderID:        int(itemOrderID.Int64),
+					BookID:         int(itemBookID.Int64),
+					Quantity:       int(itemQuantity.Int64),
+					Price:          itemPrice.Float64,
+					DiscountAmount: itemDiscount.Float64,
 				})
 			}
 		} else {
+			if err := setOrderAddress(&order, addressID, shippingDetails); err != nil {
+				return nil, err
+			}
 			if itemID.Valid {
 				order.Items = append(order.Items, OrderItem{
-					ID:       int(itemID.Int64),
-					OrderID:  int(itemOrderID.Int64),
-					BookID:   int(itemBookID.Int64),
-					Quantity: int(itemQuantity.Int64),
-					Price:    itemPrice.Float64,
+					ID:             int(itemID.Int64),
+					OrderID:        int(itemOrderID.Int64),
+					BookID:         int(itemBookID.Int64),
+					Quantity:       int(itemQuantity.Int64),
+					Price:          itemPrice.Float64,
+					DiscountAmount: itemDiscount.Float64,
 				})
 			}
 			ordersMap[order.ID] = &order
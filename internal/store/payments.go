@@ -0,0 +1,199 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusSucceeded = "succeeded"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusRefunded  = "refunded"
+)
+
+var ErrInvalidPaymentTransition = errors.New("invalid payment status transition")
+
+// paymentStatusTransitions lists, for every payment status, the statuses a
+// payment may move to next. Failed and refunded payments are final.
+var paymentStatusTransitions = map[string][]string{
+	PaymentStatusPending:   {PaymentStatusSucceeded, PaymentStatusFailed},
+	PaymentStatusSucceeded: {PaymentStatusRefunded},
+}
+
+func canTransitionPaymentStatus(from, to string) bool {
+	for _, next := range paymentStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type Payment struct {
+	ID                int       `json:"id"`
+	OrderID           int       `json:"order_id"`
+	Provider          string    `json:"provider"`
+	ProviderReference string    `json:"provider_reference"`
+	Amount            float64   `json:"amount"`
+	Currency          string    `json:"currency"`
+	Status            string    `json:"status"`
+	NeedsRefund       bool      `json:"needs_refund"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type PaymentStore struct {
+	db *sql.DB
+}
+
+func (s *PaymentStore) Create(ctx context.Context, payment *Payment) error {
+	query := `INSERT INTO payments (order_id, provider, provider_reference, amount, currency)
+	VALUES ($1, $2, $3, $4, $5) RETURNING id, status, needs_refund, created_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, payment.OrderID, payment.Provider, payment.ProviderReference, payment.Amount, payment.Currency).Scan(
+		&payment.ID,
+		&payment.Status,
+		&payment.NeedsRefund,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *PaymentStore) GetByOrderID(ctx context.Context, orderID int) ([]Payment, error) {
+	query := `SELECT id, order_id, provider, provider_reference, amount, currency, status, needs_refund, created_at, updated_at
+	FROM payments WHERE order_id = $1 ORDER BY created_at ASC`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []Payment{}
+	for rows.Next() {
+		var payment Payment
+		err := rows.Scan(
+			&payment.ID,
+			&payment.OrderID,
+			&payment.Provider,
+			&payment.ProviderReference,
+			&payment.Amount,
+			&payment.Currency,
+			&payment.Status,
+			&payment.NeedsRefund,
+			&payment.CreatedAt,
+			&payment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (s *PaymentStore) GetByReference(ctx context.Context, provider, reference string) (*Payment, error) {
+	query := `SELECT id, order_id, provider, provider_reference, amount, currency, status, needs_refund, created_at, updated_at
+	FROM payments WHERE provider = $1 AND provider_reference = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	payment := &Payment{}
+	err := s.db.QueryRowContext(ctx, query, provider, reference).Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.Provider,
+		&payment.ProviderReference,
+		&payment.Amount,
+		&payment.Currency,
+		&payment.Status,
+		&payment.NeedsRefund,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+	return payment, nil
+}
+
+// paymentOrderStatus maps a payment outcome to the status its pending order
+// should move to.
+var paymentOrderStatus = map[string]string{
+	PaymentStatusSucceeded: OrderStatusProcessing,
+	PaymentStatusFailed:    OrderStatusFailed,
+}
+
+// UpdateStatus records a new payment status and advances the order while it
+// is still awaiting payment. Repeated notifications for the same status are
+// ignored and regressions, such as a failed payment succeeding, are refused.
+// A payment that succeeds after its order moved on, for instance because it
+// was cancelled or paid through another intent, is flagged as needing a
+// refund.
+func (s *PaymentStore) UpdateStatus(ctx context.Context, payment *Payment, status string, actorID int) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var current string
+		err := tx.QueryRowContext(ctx, `SELECT status FROM payments WHERE id = $1 FOR UPDATE`, payment.ID).Scan(&current)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+		if current == status {
+			payment.Status = current
+			return nil
+		}
+		if !canTransitionPaymentStatus(current, status) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidPaymentTransition, current, status)
+		}
+
+		var orderStatus string
+		err = tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, payment.OrderID).Scan(&orderStatus)
+		if err != nil {
+			return err
+		}
+		needsRefund := status == PaymentStatusSucceeded && orderStatus != OrderStatusPending
+
+		query := `UPDATE payments SET status = $1, needs_refund = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 RETURNING updated_at`
+		if err := tx.QueryRowContext(ctx, query, status, needsRefund, payment.ID).Scan(&payment.UpdatedAt); err != nil {
+			return err
+		}
+		payment.Status = status
+		payment.NeedsRefund = needsRefund
+
+		next, ok := paymentOrderStatus[status]
+		if !ok || orderStatus != OrderStatusPending {
+			return nil
+		}
+		order := &Order{ID: payment.OrderID}
+		note := fmt.Sprintf("%s payment %s %s", payment.Provider, payment.ProviderReference, status)
+		return transitionOrderStatus(ctx, tx, order, next, actorID, note)
+	})
+}
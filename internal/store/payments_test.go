@@ -0,0 +1,27 @@
+package store
+
+import "testing"
+
+func TestCanTransitionPaymentStatus(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{PaymentStatusPending, PaymentStatusSucceeded, true},
+		{PaymentStatusPending, PaymentStatusFailed, true},
+		{PaymentStatusPending, PaymentStatusRefunded, false},
+		{PaymentStatusSucceeded, PaymentStatusRefunded, true},
+		{PaymentStatusSucceeded, PaymentStatusPending, false},
+		{PaymentStatusSucceeded, PaymentStatusFailed, false},
+		{PaymentStatusFailed, PaymentStatusSucceeded, false},
+		{PaymentStatusFailed, PaymentStatusPending, false},
+		{PaymentStatusRefunded, PaymentStatusPending, false},
+		{PaymentStatusRefunded, PaymentStatusSucceeded, false},
+		{"unknown", PaymentStatusSucceeded, false},
+	}
+	for _, tt := range tests {
+		if got := canTransitionPaymentStatus(tt.from, tt.to); got != tt.want {
+			t.Errorf("canTransitionPaymentStatus(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PriceAlert is queued in the same transaction as the price change that
+// triggered it. OldPrice is the price the book had when it was wishlisted.
+type PriceAlert struct {
+	ID             int
+	WishlistItemID int
+	BookID         int
+	Title          string
+	Author         string
+	OldPrice       float32
+	NewPrice       float32
+	TargetPrice    *float32
+	Recipient      Recipient
+	CreatedAt      time.Time
+}
+
+// ClaimPriceAlerts marks up to limit pending price alerts as processed and
+// returns them with the user to email. Concurrent callers never claim the
+// same alert.
+func (s *WishlistStore) ClaimPriceAlerts(ctx context.Context, limit int) ([]*PriceAlert, error) {
+	query := `UPDATE price_alerts a SET processed_at = CURRENT_TIMESTAMP
+	FROM wishlist_items i
+	JOIN books b ON b.id = i.book_id
+	JOIN users u ON u.id = i.user_id
+	WHERE i.id = a.wishlist_item_id AND a.id IN (
+		SELECT id FROM price_alerts WHERE processed_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+	)
+	RETURNING a.id, a.wishlist_item_id, b.id, b.title, b.author, a.old_price, a.new_price, i.target_price,
+	u.id, u.username, u.email, a.created_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []*PriceAlert
+	for rows.Next() {
+		a := &PriceAlert{}
+		err := rows.Scan(
+			&a.ID,
+			&a.WishlistItemID,
+			&a.BookID,
+			&a.Title,
+			&a.Author,
+			&a.OldPrice,
+			&a.NewPrice,
+			&a.TargetPrice,
+			&a.Recipient.UserID,
+			&a.Recipient.Username,
+			&a.Recipient.Email,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// recordPriceAlerts queues an alert for every opted-in wishlist entry the
+// book's current price qualifies for: at or below the target price, or below
+// the price at the time of wishlisting when there is no target. An entry is
+// alerted again only when the price drops below the last one it was alerted
+// about.
+func recordPriceAlerts(ctx context.Context, tx *sql.Tx, bookID int) error {
+	query := `WITH alerted AS (
+		UPDATE wishlist_items i SET last_alerted_price = b.price
+		FROM books b
+		WHERE b.id = i.book_id AND i.book_id = $1 AND i.alert_enabled
+		AND (b.price <= i.target_price OR (i.target_price IS NULL AND b.price < i.price_at_add))
+		AND (i.last_alerted_price IS NULL OR b.price < i.last_alerted_price)
+		RETURNING i.id, i.price_at_add, b.price
+	)
+	INSERT INTO price_alerts (wishlist_item_id, old_price, new_price)
+	SELECT id, price_at_add, price FROM alerted`
+
+	_, err := tx.ExecContext(ctx, query, bookID)
+	return err
+}
@@ -0,0 +1,392 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	DiscountTypePercentage = "percentage"
+	DiscountTypeFixed      = "fixed"
+
+	PromotionScopeOrder = "order"
+	PromotionScopeTag   = "tag"
+	PromotionScopeBook  = "book"
+)
+
+var (
+	ErrCouponNotApplicable = errors.New("coupon cannot be applied")
+	ErrDuplicateCouponCode = errors.New("duplicate coupon code")
+)
+
+type Promotion struct {
+	ID                int        `json:"id"`
+	Code              string     `json:"code"`
+	Description       string     `json:"description"`
+	DiscountType      string     `json:"discount_type"`
+	DiscountValue     float64    `json:"discount_value"`
+	Scope             string     `json:"scope"`
+	Tags              []string   `json:"tags"`
+	BookIDs           []int64    `json:"book_ids"`
+	MinOrderValue     float64    `json:"min_order_value"`
+	UsageLimit        *int       `json:"usage_limit"`
+	UsageLimitPerUser *int       `json:"usage_limit_per_user"`
+	TimesUsed         int        `json:"times_used"`
+	StartsAt          *time.Time `json:"starts_at"`
+	EndsAt            *time.Time `json:"ends_at"`
+	IsActive          bool       `json:"is_active"`
+	CreatedBy         int        `json:"created_by"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	Version           int        `json:"version"`
+}
+
+// appliesTo reports whether a book falls within the promotion's scope.
+func (p *Promotion) appliesTo(bookID int, tags []string) bool {
+	switch p.Scope {
+	case PromotionScopeBook:
+		for _, id := range p.BookIDs {
+			if int(id) == bookID {
+				return true
+			}
+		}
+		return false
+	case PromotionScopeTag:
+		for _, tag := range tags {
+			for _, scoped := range p.Tags {
+				if tag == scoped {
+					return true
+				}
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+type PromotionStore struct {
+	db *sql.DB
+}
+
+func (s *PromotionStore) Create(ctx context.Context, p *Promotion) error {
+	query := `INSERT INTO promotions (code, description, discount_type, discount_value, scope, scope_tags, scope_book_ids,
+	min_order_value, usage_limit, usage_limit_per_user, starts_at, ends_at, is_active, created_by)
+	VALUES (upper($1), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, 0))
+	RETURNING id, code, times_used, created_at, updated_at, version`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		p.Code,
+		p.Description,
+		p.DiscountType,
+		p.DiscountValue,
+		p.Scope,
+		pq.Array(p.Tags),
+		pq.Array(p.BookIDs),
+		p.MinOrderValue,
+		p.UsageLimit,
+		p.UsageLimitPerUser,
+		p.StartsAt,
+		p.EndsAt,
+		p.IsActive,
+		p.CreatedBy,
+	).Scan(&p.ID, &p.Code, &p.TimesUsed, &p.CreatedAt, &p.UpdatedAt, &p.Version)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicateCouponCode
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *PromotionStore) GetByID(ctx context.Context, ID int) (*Promotion, error) {
+	query := `SELECT id, code, COALESCE(description, ''), discount_type, discount_value, scope, scope_tags, scope_book_ids,
+	min_order_value, usage_limit, usage_limit_per_user, times_used, starts_at, ends_at, is_active, COALESCE(created_by, 0),
+	created_at, updated_at, version
+	FROM promotions WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	p := &Promotion{}
+	if err := scanPromotion(s.db.QueryRowContext(ctx, query, ID), p); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (s *PromotionStore) List(ctx context.Context) ([]*Promotion, error) {
+	query := `SELECT id, code, COALESCE(description, ''), discount_type, discount_value, scope, scope_tags, scope_book_ids,
+	min_order_value, usage_limit, usage_limit_per_user, times_used, starts_at, ends_at, is_active, COALESCE(created_by, 0),
+	created_at, updated_at, version
+	FROM promotions ORDER BY created_at DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []*Promotion{}
+	for rows.Next() {
+		p := &Promotion{}
+		if err := scanPromotion(rows, p); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+func (s *PromotionStore) Update(ctx context.Context, p *Promotion) error {
+	query := `UPDATE promotions SET code = upper($1), description = $2, discount_type = $3, discount_value = $4, scope = $5,
+	scope_tags = $6, scope_book_ids = $7, min_order_value = $8, usage_limit = $9, usage_limit_per_user = $10,
+	starts_at = $11, ends_at = $12, is_active = $13, updated_at = CURRENT_TIMESTAMP, version = version + 1
+	WHERE id = $14 AND version = $15
+	RETURNING code, updated_at, version`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		p.Code,
+		p.Description,
+		p.DiscountType,
+		p.DiscountValue,
+		p.Scope,
+		pq.Array(p.Tags),
+		pq.Array(p.BookIDs),
+		p.MinOrderValue,
+		p.UsageLimit,
+		p.UsageLimitPerUser,
+		p.StartsAt,
+		p.EndsAt,
+		p.IsActive,
+		p.ID,
+		p.Version,
+	).Scan(&p.Code, &p.UpdatedAt, &p.Version)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrorNotFound
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return ErrDuplicateCouponCode
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PromotionStore) Delete(ctx context.Context, ID int) error {
+	query := `DELETE FROM promotions WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, ID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrorNotFound
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPromotion(row rowScanner, p *Promotion) error {
+	return row.Scan(
+		&p.ID,
+		&p.Code,
+		&p.Description,
+		&p.DiscountType,
+		&p.DiscountValue,
+		&p.Scope,
+		pq.Array(&p.Tags),
+		pq.Array(&p.BookIDs),
+		&p.MinOrderValue,
+		&p.UsageLimit,
+		&p.UsageLimitPerUser,
+		&p.TimesUsed,
+		&p.StartsAt,
+		&p.EndsAt,
+		&p.IsActive,
+		&p.CreatedBy,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.Version,
+	)
+}
+
+// applyPromotion reads the promotion named by order.CouponCode, locking it
+// when asked, checks that the order qualifies and spreads the discount over
+// the eligible lines.
+func applyPromotion(ctx context.Context, tx *sql.Tx, order *Order, books map[int]orderBook, lock bool) (*Promotion, error) {
+	query := `SELECT id, code, COALESCE(description, ''), discount_type, discount_value, scope, scope_tags, scope_book_ids,
+	min_order_value, usage_limit, usage_limit_per_user, times_used, starts_at, ends_at, is_active, COALESCE(created_by, 0),
+	created_at, updated_at, version
+	FROM promotions WHERE code = upper($1)`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	p := &Promotion{}
+	if err := scanPromotion(tx.QueryRowContext(ctx, query, order.CouponCode), p); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, fmt.Errorf("%w: unknown code %q", ErrCouponNotApplicable, order.CouponCode)
+		default:
+			return nil, err
+		}
+	}
+	order.CouponCode = p.Code
+
+	now := time.Now()
+	switch {
+	case !p.IsActive:
+		return nil, fmt.Errorf("%w: promotion is not active", ErrCouponNotApplicable)
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return nil, fmt.Errorf("%w: promotion has not started yet", ErrCouponNotApplicable)
+	case p.EndsAt != nil && now.After(*p.EndsAt):
+		return nil, fmt.Errorf("%w: promotion has ended", ErrCouponNotApplicable)
+	case p.UsageLimit != nil && p.TimesUsed >= *p.UsageLimit:
+		return nil, fmt.Errorf("%w: promotion has been fully redeemed", ErrCouponNotApplicable)
+	case order.SubtotalAmount < p.MinOrderValue:
+		return nil, fmt.Errorf("%w: order must be at least %.2f", ErrCouponNotApplicable, p.MinOrderValue)
+	}
+
+	if p.UsageLimitPerUser != nil {
+		var used int
+		query := `SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2`
+		if err := tx.QueryRowContext(ctx, query, p.ID, order.UserID).Scan(&used); err != nil {
+			return nil, err
+		}
+		if used >= *p.UsageLimitPerUser {
+			return nil, fmt.Errorf("%w: you have already used this coupon", ErrCouponNotApplicable)
+		}
+	}
+
+	discountOrder(order, p, books)
+	if order.DiscountAmount == 0 {
+		return nil, fmt.Errorf("%w: no items in the order qualify", ErrCouponNotApplicable)
+	}
+	return p, nil
+}
+
+// discountOrder computes the promotion's discount over the eligible lines and
+// allocates it to each of them in proportion to the line total. Shares are
+// counted in cents: every line gets the whole cents of its share and the
+// cents left over go to the lines with the largest remainders, so the per
+// item discounts add up to the order discount and never exceed a line total.
+func discountOrder(order *Order, p *Promotion, books map[int]orderBook) {
+	var eligible []int
+	var eligibleTotal float64
+	for i, item := range order.Items {
+		if p.appliesTo(item.BookID, books[item.BookID].tags) {
+			eligible = append(eligible, i)
+			eligibleTotal += item.Price * float64(item.Quantity)
+		}
+	}
+	if len(eligible) == 0 || eligibleTotal == 0 {
+		return
+	}
+
+	discount := p.DiscountValue
+	if p.DiscountType == DiscountTypePercentage {
+		discount = eligibleTotal * p.DiscountValue / 100
+	}
+	discount = roundCents(min(discount, eligibleTotal))
+
+	discountCents := int64(math.Round(discount * 100))
+	totalCents := int64(math.Round(eligibleTotal * 100))
+	shares := make([]int64, len(eligible))
+	remainders := make([]int64, len(eligible))
+	left := discountCents
+	for n, i := range eligible {
+		item := order.Items[i]
+		lineCents := int64(math.Round(item.Price * float64(item.Quantity) * 100))
+		shares[n] = min(discountCents*lineCents/totalCents, lineCents)
+		remainders[n] = discountCents * lineCents % totalCents
+		left -= shares[n]
+	}
+
+	byRemainder := make([]int, len(eligible))
+	for n := range byRemainder {
+		byRemainder[n] = n
+	}
+	slices.SortStableFunc(byRemainder, func(a, b int) int {
+		return cmp.Compare(remainders[b], remainders[a])
+	})
+	for _, n := range byRemainder {
+		if left <= 0 {
+			break
+		}
+		item := order.Items[eligible[n]]
+		lineCents := int64(math.Round(item.Price * float64(item.Quantity) * 100))
+		if shares[n] < lineCents {
+			shares[n]++
+			left--
+		}
+	}
+
+	for n, i := range eligible {
+		order.Items[i].DiscountAmount = float64(shares[n]) / 100
+	}
+	order.DiscountAmount = discount
+}
+
+func redeemPromotion(ctx context.Context, tx *sql.Tx, p *Promotion, order *Order) error {
+	query := `INSERT INTO promotion_redemptions (promotion_id, order_id, user_id, discount_amount) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, p.ID, order.ID, order.UserID, order.DiscountAmount); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `UPDATE promotions SET times_used = times_used + 1 WHERE id = $1`, p.ID)
+	return err
+}
+
+// releasePromotions gives back the coupon redemptions of an order that was
+// cancelled or failed, so they no longer count against the usage limits.
+func releasePromotions(ctx context.Context, tx *sql.Tx, orderID int) error {
+	query := `WITH released AS (
+		DELETE FROM promotion_redemptions WHERE order_id = $1 RETURNING promotion_id
+	)
+	UPDATE promotions p SET times_used = GREATEST(p.times_used - r.count, 0)
+	FROM (SELECT promotion_id, COUNT(*) AS count FROM released GROUP BY promotion_id) r
+	WHERE p.id = r.promotion_id`
+
+	_, err := tx.ExecContext(ctx, query, orderID)
+	return err
+}
@@ -0,0 +1,100 @@
+package store
+
+import "testing"
+
+func TestDiscountOrder(t *testing.T) {
+	books := map[int]orderBook{
+		1: {tags: []string{"fiction"}},
+		2: {tags: []string{"poetry"}},
+		3: {},
+	}
+	tests := []struct {
+		name      string
+		promotion Promotion
+		items     []OrderItem
+		want      float64
+		wantItems []float64
+	}{
+		{
+			name:      "percentage of the whole order",
+			promotion: Promotion{DiscountType: DiscountTypePercentage, DiscountValue: 10, Scope: PromotionScopeOrder},
+			items:     []OrderItem{{BookID: 1, Price: 10, Quantity: 2}, {BookID: 2, Price: 5, Quantity: 1}},
+			want:      2.5,
+			wantItems: []float64{2, 0.5},
+		},
+		{
+			name:      "fixed amount capped at the eligible total",
+			promotion: Promotion{DiscountType: DiscountTypeFixed, DiscountValue: 30, Scope: PromotionScopeOrder},
+			items:     []OrderItem{{BookID: 1, Price: 10, Quantity: 2}, {BookID: 2, Price: 5, Quantity: 1}},
+			want:      25,
+			wantItems: []float64{20, 5},
+		},
+		{
+			name:      "shares add up to the discount",
+			promotion: Promotion{DiscountType: DiscountTypeFixed, DiscountValue: 1, Scope: PromotionScopeOrder},
+			items:     []OrderItem{{BookID: 1, Price: 10, Quantity: 1}, {BookID: 2, Price: 10, Quantity: 1}, {BookID: 3, Price: 10, Quantity: 1}},
+			want:      1,
+			wantItems: []float64{0.34, 0.33, 0.33},
+		},
+		{
+			name:      "rounded shares never go negative",
+			promotion: Promotion{DiscountType: DiscountTypeFixed, DiscountValue: 0.09, Scope: PromotionScopeOrder},
+			items: []OrderItem{
+				{BookID: 1, Price: 10, Quantity: 1}, {BookID: 1, Price: 10, Quantity: 1}, {BookID: 1, Price: 10, Quantity: 1},
+				{BookID: 1, Price: 10, Quantity: 1}, {BookID: 1, Price: 10, Quantity: 1}, {BookID: 1, Price: 10, Quantity: 1},
+			},
+			want:      0.09,
+			wantItems: []float64{0.02, 0.02, 0.02, 0.01, 0.01, 0.01},
+		},
+		{
+			name:      "left over cents go to the largest remainders",
+			promotion: Promotion{DiscountType: DiscountTypeFixed, DiscountValue: 1, Scope: PromotionScopeOrder},
+			items:     []OrderItem{{BookID: 1, Price: 0.01, Quantity: 1}, {BookID: 2, Price: 1.5, Quantity: 1}, {BookID: 3, Price: 1.49, Quantity: 1}},
+			want:      1,
+			wantItems: []float64{0, 0.5, 0.5},
+		},
+		{
+			name:      "shares never exceed the line total",
+			promotion: Promotion{DiscountType: DiscountTypePercentage, DiscountValue: 100, Scope: PromotionScopeOrder},
+			items:     []OrderItem{{BookID: 1, Price: 0.01, Quantity: 1}, {BookID: 2, Price: 0.01, Quantity: 1}, {BookID: 3, Price: 3.33, Quantity: 3}},
+			want:      10.01,
+			wantItems: []float64{0.01, 0.01, 9.99},
+		},
+		{
+			name:      "tag scope only discounts tagged books",
+			promotion: Promotion{DiscountType: DiscountTypePercentage, DiscountValue: 50, Scope: PromotionScopeTag, Tags: []string{"fiction"}},
+			items:     []OrderItem{{BookID: 1, Price: 10, Quantity: 1}, {BookID: 2, Price: 20, Quantity: 1}},
+			want:      5,
+			wantItems: []float64{5, 0},
+		},
+		{
+			name:      "book scope only discounts listed books",
+			promotion: Promotion{DiscountType: DiscountTypeFixed, DiscountValue: 4, Scope: PromotionScopeBook, BookIDs: []int64{2, 3}},
+			items:     []OrderItem{{BookID: 1, Price: 10, Quantity: 1}, {BookID: 2, Price: 6, Quantity: 1}, {BookID: 3, Price: 2, Quantity: 1}},
+			want:      4,
+			wantItems: []float64{0, 3, 1},
+		},
+		{
+			name:      "no eligible items",
+			promotion: Promotion{DiscountType: DiscountTypeFixed, DiscountValue: 5, Scope: PromotionScopeTag, Tags: []string{"history"}},
+			items:     []OrderItem{{BookID: 1, Price: 10, Quantity: 1}},
+			want:      0,
+			wantItems: []float64{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{Items: tt.items}
+			discountOrder(order, &tt.promotion, books)
+
+			if order.DiscountAmount != tt.want {
+				t.Errorf("order discount = %v, want %v", order.DiscountAmount, tt.want)
+			}
+			for i, item := range order.Items {
+				if item.DiscountAmount != tt.wantItems[i] {
+					t.Errorf("item %d discount = %v, want %v", i, item.DiscountAmount, tt.wantItems[i])
+				}
+			}
+		})
+	}
+}
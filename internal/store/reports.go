@@ -0,0 +1,316 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	ReportPeriodDay   = "day"
+	ReportPeriodWeek  = "week"
+	ReportPeriodMonth = "month"
+
+	ReportRankUnits   = "units"
+	ReportRankRevenue = "revenue"
+)
+
+// ReportRange limits a report to orders placed in [From, To). Nil bounds are
+// open.
+type ReportRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+type RevenuePoint struct {
+	Period   time.Time `json:"period"`
+	Orders   int       `json:"orders"`
+	Units    int       `json:"units"`
+	Gross    float64   `json:"gross_revenue"`
+	Refunded float64   `json:"refunded"`
+	Net      float64   `json:"net_revenue"`
+}
+
+type TopBook struct {
+	BookID  int     `json:"book_id"`
+	Title   string  `json:"title"`
+	Author  string  `json:"author"`
+	ISBN    string  `json:"isbn"`
+	Units   int     `json:"units"`
+	Revenue float64 `json:"revenue"`
+}
+
+type TopAuthor struct {
+	Author  string  `json:"author"`
+	Books   int     `json:"books"`
+	Units   int     `json:"units"`
+	Revenue float64 `json:"revenue"`
+}
+
+type OrderValueSummary struct {
+	Orders            int     `json:"orders"`
+	Revenue           float64 `json:"revenue"`
+	AverageOrderValue float64 `json:"average_order_value"`
+	AverageItems      float64 `json:"average_items"`
+}
+
+type StatusCount struct {
+	Status  string  `json:"status"`
+	Orders  int     `json:"orders"`
+	Revenue float64 `json:"revenue"`
+}
+
+type LowStockBook struct {
+	BookID int    `json:"book_id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	ISBN   string `json:"isbn"`
+	Stock  int    `json:"stock"`
+}
+
+// ReportStore aggregates orders for the admin reports. Unless a report is
+// about statuses, only orders that count as sales are included, that is every
+// order that was paid for and moved past pending. Revenue over time lists
+// settled refunds next to the gross and order value is net of them, while top
+// books and authors rank by what the items sold for, before refunds.
+type ReportStore struct {
+	db *sql.DB
+}
+
+const salesCondition = `o.status IN ('processing', 'shipped', 'delivered', 'returned', 'refunded')`
+
+// settledRefundsJoin joins the amount refunded to the customer per order as
+// rf.amount. Refunds still pending with the provider or refused by it are
+// left out.
+const settledRefundsJoin = `
+	LEFT JOIN (SELECT order_id, SUM(amount) AS amount FROM refunds WHERE status = 'settled' GROUP BY order_id) rf
+	ON rf.order_id = o.id`
+
+// rangeCondition appends the placed_at bounds of r to a WHERE clause.
+func rangeCondition(r ReportRange, args []any) (string, []any) {
+	var condition string
+	if r.From != nil {
+		args = append(args, *r.From)
+		condition += fmt.Sprintf(" AND o.placed_at >= $%d", len(args))
+	}
+	if r.To != nil {
+		args = append(args, *r.To)
+		condition += fmt.Sprintf(" AND o.placed_at < $%d", len(args))
+	}
+	return condition, args
+}
+
+func (s *ReportStore) Revenue(ctx context.Context, period string, r ReportRange) ([]RevenuePoint, error) {
+	switch period {
+	case ReportPeriodDay, ReportPeriodWeek, ReportPeriodMonth:
+	default:
+		return nil, fmt.Errorf("unknown report period %q", period)
+	}
+	condition, args := rangeCondition(r, []any{period})
+
+	query := `
+	SELECT date_trunc($1, o.placed_at) AS period, COUNT(*),
+	COALESCE(SUM(u.units), 0), COALESCE(SUM(o.total_amount), 0), COALESCE(SUM(rf.amount), 0)
+	FROM orders o
+	LEFT JOIN (SELECT order_id, SUM(quantity) AS units FROM order_items GROUP BY order_id) u ON u.order_id = o.id` +
+		settledRefundsJoin + `
+	WHERE ` + salesCondition + condition + `
+	GROUP BY period
+	ORDER BY period`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []RevenuePoint{}
+	for rows.Next() {
+		var p RevenuePoint
+		if err := rows.Scan(&p.Period, &p.Orders, &p.Units, &p.Gross, &p.Refunded); err != nil {
+			return nil, err
+		}
+		p.Gross = roundCents(p.Gross)
+		p.Refunded = roundCents(p.Refunded)
+		p.Net = roundCents(p.Gross - p.Refunded)
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (s *ReportStore) TopBooks(ctx context.Context, rankBy string, limit int, r ReportRange) ([]TopBook, error) {
+	order, err := reportRankOrder(rankBy)
+	if err != nil {
+		return nil, err
+	}
+	condition, args := rangeCondition(r, []any{limit})
+
+	query := `
+	SELECT b.id, b.title, b.author, b.isbn, SUM(oi.quantity) AS units,
+	SUM(oi.price * oi.quantity - oi.discount_amount) AS revenue
+	FROM order_items oi
+	JOIN orders o ON o.id = oi.order_id
+	JOIN books b ON b.id = oi.book_id
+	WHERE ` + salesCondition + condition + `
+	GROUP BY b.id
+	ORDER BY ` + order + `, b.id
+	LIMIT $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []TopBook{}
+	for rows.Next() {
+		var b TopBook
+		if err := rows.Scan(&b.BookID, &b.Title, &b.Author, &b.ISBN, &b.Units, &b.Revenue); err != nil {
+			return nil, err
+		}
+		b.Revenue = roundCents(b.Revenue)
+		books = append(books, b)
+	}
+	return books, rows.Err()
+}
+
+func (s *ReportStore) TopAuthors(ctx context.Context, rankBy string, limit int, r ReportRange) ([]TopAuthor, error) {
+	order, err := reportRankOrder(rankBy)
+	if err != nil {
+		return nil, err
+	}
+	condition, args := rangeCondition(r, []any{limit})
+
+	query := `
+	SELECT b.author, COUNT(DISTINCT b.id), SUM(oi.quantity) AS units,
+	SUM(oi.price * oi.quantity - oi.discount_amount) AS revenue
+	FROM order_items oi
+	JOIN orders o ON o.id = oi.order_id
+	JOIN books b ON b.id = oi.book_id
+	WHERE ` + salesCondition + condition + `
+	GROUP BY b.author
+	ORDER BY ` + order + `, b.author
+	LIMIT $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := []TopAuthor{}
+	for rows.Next() {
+		var a TopAuthor
+		if err := rows.Scan(&a.Author, &a.Books, &a.Units, &a.Revenue); err != nil {
+			return nil, err
+		}
+		a.Revenue = roundCents(a.Revenue)
+		authors = append(authors, a)
+	}
+	return authors, rows.Err()
+}
+
+func (s *ReportStore) OrderValue(ctx context.Context, r ReportRange) (*OrderValueSummary, error) {
+	condition, args := rangeCondition(r, nil)
+
+	query := `
+	SELECT COUNT(*), COALESCE(SUM(o.total_amount - COALESCE(rf.amount, 0)), 0), COALESCE(AVG(u.units), 0)
+	FROM orders o
+	LEFT JOIN (SELECT order_id, SUM(quantity) AS units FROM order_items GROUP BY order_id) u ON u.order_id = o.id` +
+		settledRefundsJoin + `
+	WHERE ` + salesCondition + condition
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	summary := &OrderValueSummary{}
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&summary.Orders, &summary.Revenue, &summary.AverageItems)
+	if err != nil {
+		return nil, err
+	}
+	summary.Revenue = roundCents(summary.Revenue)
+	summary.AverageItems = roundCents(summary.AverageItems)
+	if summary.Orders > 0 {
+		summary.AverageOrderValue = roundCents(summary.Revenue / float64(summary.Orders))
+	}
+	return summary, nil
+}
+
+// StatusBreakdown counts orders of every status, cancelled and failed ones
+// included. Revenue is the orders' total before refunds.
+func (s *ReportStore) StatusBreakdown(ctx context.Context, r ReportRange) ([]StatusCount, error) {
+	condition, args := rangeCondition(r, nil)
+
+	query := `
+	SELECT o.status, COUNT(*), COALESCE(SUM(o.total_amount), 0)
+	FROM orders o
+	WHERE 1=1` + condition + `
+	GROUP BY o.status
+	ORDER BY COUNT(*) DESC, o.status`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []StatusCount{}
+	for rows.Next() {
+		var c StatusCount
+		if err := rows.Scan(&c.Status, &c.Orders, &c.Revenue); err != nil {
+			return nil, err
+		}
+		c.Revenue = roundCents(c.Revenue)
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// LowStock lists books with fewer than threshold copies left, emptiest first,
+// the same books a low stock alert is raised for at that threshold.
+func (s *ReportStore) LowStock(ctx context.Context, threshold int) ([]LowStockBook, error) {
+	query := `SELECT id, title, author, isbn, stock FROM books WHERE stock < $1 ORDER BY stock, id`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []LowStockBook{}
+	for rows.Next() {
+		var b LowStockBook
+		if err := rows.Scan(&b.BookID, &b.Title, &b.Author, &b.ISBN, &b.Stock); err != nil {
+			return nil, err
+		}
+		books = append(books, b)
+	}
+	return books, rows.Err()
+}
+
+func reportRankOrder(rankBy string) (string, error) {
+	switch rankBy {
+	case "", ReportRankUnits:
+		return "units DESC, revenue DESC", nil
+	case ReportRankRevenue:
+		return "revenue DESC, units DESC", nil
+	default:
+		return "", fmt.Errorf("cannot rank by %q", rankBy)
+	}
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Cart reservations hold copies of a book for a cart for a limited time.
+// They do not touch books.stock, which stays the number of copies on hand;
+// instead every availability check subtracts the active reservations of
+// other carts, so two customers cannot both be promised the last copy.
+// Expired reservations are ignored by those checks right away and deleted by
+// ReleaseExpiredReservations.
+
+// availableForCart locks the book and returns how many copies the cart may
+// hold, that is the stock minus what other carts have reserved.
+func availableForCart(ctx context.Context, tx *sql.Tx, bookID, cartID int) (int, error) {
+	query := `SELECT b.stock - COALESCE((SELECT SUM(r.quantity) FROM cart_reservations r
+		WHERE r.book_id = b.id AND r.cart_id <> $2 AND r.expires_at > CURRENT_TIMESTAMP), 0)
+	FROM books b WHERE b.id = $1 FOR UPDATE OF b`
+
+	var available int
+	if err := tx.QueryRowContext(ctx, query, bookID, cartID).Scan(&available); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return 0, fmt.Errorf("book %d: %w", bookID, ErrorNotFound)
+		default:
+			return 0, err
+		}
+	}
+	return max(available, 0), nil
+}
+
+// reserve sets the cart's reservation of a book to quantity, expiring hold
+// from now.
+func reserve(ctx context.Context, tx *sql.Tx, cartID, bookID, quantity int, hold time.Duration) error {
+	query := `INSERT INTO cart_reservations (cart_id, book_id, quantity, expires_at)
+	VALUES ($1, $2, $3, CURRENT_TIMESTAMP + $4 * INTERVAL '1 second')
+	ON CONFLICT (cart_id, book_id)
+	DO UPDATE SET quantity = EXCLUDED.quantity, expires_at = EXCLUDED.expires_at`
+
+	_, err := tx.ExecContext(ctx, query, cartID, bookID, quantity, int(hold.Seconds()))
+	return err
+}
+
+// ReleaseExpiredReservations deletes reservations past their expiry and
+// returns how many were released.
+func (s *CartStore) ReleaseExpiredReservations(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM cart_reservations WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
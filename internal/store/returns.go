@@ -0,0 +1,454 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusReceived  = "received"
+
+	RefundStatusPending = "pending"
+	RefundStatusSettled = "settled"
+	RefundStatusFailed  = "failed"
+)
+
+var (
+	ErrReturnNotAllowed         = errors.New("order is not eligible for returns")
+	ErrReturnQuantityExceeded   = errors.New("return quantity exceeds the quantity ordered")
+	ErrInvalidReturnTransition  = errors.New("invalid return request transition")
+	ErrRefundExceedsOrderAmount = errors.New("refund exceeds the amount left to refund")
+	ErrRefundNotPending         = errors.New("refund is not pending")
+)
+
+type ReturnRequest struct {
+	ID          int       `json:"id"`
+	OrderID     int       `json:"order_id"`
+	OrderItemID int       `json:"order_item_id"`
+	BookID      int       `json:"book_id"`
+	UserID      int       `json:"user_id"`
+	Quantity    int       `json:"quantity"`
+	Reason      string    `json:"reason"`
+	PhotosURL   string    `json:"photos_url"`
+	Status      string    `json:"status"`
+	AdminNote   string    `json:"admin_note"`
+	ReviewedBy  int       `json:"reviewed_by"`
+	Restocked   bool      `json:"restocked"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Refund struct {
+	ID              int       `json:"id"`
+	OrderID         int       `json:"order_id"`
+	ReturnRequestID int       `json:"return_request_id"`
+	Amount          float64   `json:"amount"`
+	Reason          string    `json:"reason"`
+	CreatedBy       int       `json:"created_by"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type ReturnStore struct {
+	db *sql.DB
+}
+
+// Create opens a return request for an order item after checking that the
+// order has been delivered and that the item still has unreturned copies.
+func (s *ReturnStore) Create(ctx context.Context, ret *ReturnRequest) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `SELECT oi.order_id, oi.book_id, oi.quantity, o.user_id, o.status
+		FROM order_items oi JOIN orders o ON o.id = oi.order_id
+		WHERE oi.id = $1 FOR UPDATE`
+
+		var orderID, userID, ordered int
+		var status string
+		err := tx.QueryRowContext(ctx, query, ret.OrderItemID).Scan(&orderID, &ret.BookID, &ordered, &userID, &status)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+		if orderID != ret.OrderID || userID != ret.UserID {
+			return ErrorNotFound
+		}
+		if status != OrderStatusDelivered && status != OrderStatusReturned {
+			return ErrReturnNotAllowed
+		}
+
+		var alreadyReturned int
+		query = `SELECT COALESCE(SUM(quantity), 0) FROM return_requests WHERE order_item_id = $1 AND status <> $2`
+		if err := tx.QueryRowContext(ctx, query, ret.OrderItemID, ReturnStatusRejected).Scan(&alreadyReturned); err != nil {
+			return err
+		}
+		if alreadyReturned+ret.Quantity > ordered {
+			return ErrReturnQuantityExceeded
+		}
+
+		query = `INSERT INTO return_requests (order_id, order_item_id, user_id, quantity, reason, photos_url)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')) RETURNING id, status, created_at, updated_at`
+
+		return tx.QueryRowContext(ctx, query, ret.OrderID, ret.OrderItemID, ret.UserID, ret.Quantity, ret.Reason, ret.PhotosURL).Scan(
+			&ret.ID,
+			&ret.Status,
+			&ret.CreatedAt,
+			&ret.UpdatedAt,
+		)
+	})
+}
+
+func (s *ReturnStore) GetByID(ctx context.Context, ID int) (*ReturnRequest, error) {
+	query := `SELECT r.id, r.order_id, r.order_item_id, oi.book_id, r.user_id, r.quantity, r.reason,
+	COALESCE(r.photos_url, ''), r.status, COALESCE(r.admin_note, ''), COALESCE(r.reviewed_by, 0), r.restocked,
+	r.created_at, r.updated_at
+	FROM return_requests r JOIN order_items oi ON oi.id = r.order_item_id
+	WHERE r.id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	ret := &ReturnRequest{}
+	err := s.db.QueryRowContext(ctx, query, ID).Scan(
+		&ret.ID,
+		&ret.OrderID,
+		&ret.OrderItemID,
+		&ret.BookID,
+		&ret.UserID,
+		&ret.Quantity,
+		&ret.Reason,
+		&ret.PhotosURL,
+		&ret.Status,
+		&ret.AdminNote,
+		&ret.ReviewedBy,
+		&ret.Restocked,
+		&ret.CreatedAt,
+		&ret.UpdatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (s *ReturnStore) GetByOrderID(ctx context.Context, orderID int) ([]ReturnRequest, error) {
+	query := `SELECT r.id, r.order_id, r.order_item_id, oi.book_id, r.user_id, r.quantity, r.reason,
+	COALESCE(r.photos_url, ''), r.status, COALESCE(r.admin_note, ''), COALESCE(r.reviewed_by, 0), r.restocked,
+	r.created_at, r.updated_at
+	FROM return_requests r JOIN order_items oi ON oi.id = r.order_item_id
+	WHERE r.order_id = $1 ORDER BY r.created_at ASC`
+
+	return s.list(ctx, query, orderID)
+}
+
+// List returns every return request, optionally narrowed to one status.
+func (s *ReturnStore) List(ctx context.Context, status string) ([]ReturnRequest, error) {
+	query := `SELECT r.id, r.order_id, r.order_item_id, oi.book_id, r.user_id, r.quantity, r.reason,
+	COALESCE(r.photos_url, ''), r.status, COALESCE(r.admin_note, ''), COALESCE(r.reviewed_by, 0), r.restocked,
+	r.created_at, r.updated_at
+	FROM return_requests r JOIN order_items oi ON oi.id = r.order_item_id
+	WHERE ($1 = '' OR r.status = $1) ORDER BY r.created_at ASC`
+
+	return s.list(ctx, query, status)
+}
+
+func (s *ReturnStore) list(ctx context.Context, query string, args ...any) ([]ReturnRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := []ReturnRequest{}
+	for rows.Next() {
+		var ret ReturnRequest
+		err := rows.Scan(
+			&ret.ID,
+			&ret.OrderID,
+			&ret.OrderItemID,
+			&ret.BookID,
+			&ret.UserID,
+			&ret.Quantity,
+			&ret.Reason,
+			&ret.PhotosURL,
+			&ret.Status,
+			&ret.AdminNote,
+			&ret.ReviewedBy,
+			&ret.Restocked,
+			&ret.CreatedAt,
+			&ret.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+// Review approves or rejects a pending return request.
+func (s *ReturnStore) Review(ctx context.Context, ret *ReturnRequest, approve bool, reviewerID int, note string) error {
+	status := ReturnStatusRejected
+	if approve {
+		status = ReturnStatusApproved
+	}
+	query := `UPDATE return_requests
+	SET status = $1, admin_note = NULLIF($2, ''), reviewed_by = $3, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $4 AND status = $5
+	RETURNING status, COALESCE(admin_note, ''), reviewed_by, updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, status, note, reviewerID, ret.ID, ReturnStatusRequested).Scan(
+		&ret.Status,
+		&ret.AdminNote,
+		&ret.ReviewedBy,
+		&ret.UpdatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidReturnTransition, ret.Status, status)
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive marks an approved return as received, optionally puts the copies
+// back into stock and reserves a refund against the order, to be settled
+// with CompleteRefund once the money has been sent. A zero refund amount
+// reserves no refund. The order moves to returned once every copy
+// ordered has been received back, and to refunded once its whole total has
+// been paid back.
+func (s *ReturnStore) Receive(ctx context.Context, ret *ReturnRequest, restock bool, refundAmount float64, actorID int) (*Refund, error) {
+	var refund *Refund
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `UPDATE return_requests
+		SET status = $1, restocked = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = $4
+		RETURNING status, restocked, updated_at`
+
+		err := tx.QueryRowContext(ctx, query, ReturnStatusReceived, restock, ret.ID, ReturnStatusApproved).Scan(
+			&ret.Status,
+			&ret.Restocked,
+			&ret.UpdatedAt,
+		)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return fmt.Errorf("%w: %s -> %s", ErrInvalidReturnTransition, ret.Status, ReturnStatusReceived)
+			default:
+				return err
+			}
+		}
+
+		if restock {
+			locationID, err := allocatedLocationID(ctx, tx, ret.OrderID, ret.BookID)
+			if err != nil {
+				return err
+			}
+			movement := &StockMovement{
+				BookID:     ret.BookID,
+				LocationID: locationID,
+				Kind:       StockMovementReturn,
+				Quantity:   ret.Quantity,
+				Reason:     fmt.Sprintf("return request %d", ret.ID),
+				OrderID:    ret.OrderID,
+				ActorID:    actorID,
+			}
+			if err := moveStock(ctx, tx, movement); err != nil {
+				return err
+			}
+		}
+
+		// the order only counts as returned once every copy has come back
+		order := &Order{ID: ret.OrderID}
+		var status string
+		var allReturned bool
+		query = `SELECT o.status,
+			(SELECT COALESCE(SUM(quantity), 0) FROM order_items WHERE order_id = o.id) <=
+			(SELECT COALESCE(SUM(quantity), 0) FROM return_requests WHERE order_id = o.id AND status = $2)
+		FROM orders o WHERE o.id = $1`
+		if err := tx.QueryRowContext(ctx, query, order.ID, ReturnStatusReceived).Scan(&status, &allReturned); err != nil {
+			return err
+		}
+		if allReturned && CanTransitionOrderStatus(status, OrderStatusReturned) {
+			note := fmt.Sprintf("return request %d received", ret.ID)
+			if err := transitionOrderStatus(ctx, tx, order, OrderStatusReturned, actorID, note); err != nil {
+				return err
+			}
+		}
+
+		if refundAmount > 0 {
+			refund = &Refund{
+				OrderID:         ret.OrderID,
+				ReturnRequestID: ret.ID,
+				Amount:          refundAmount,
+				Reason:          ret.Reason,
+				CreatedBy:       actorID,
+			}
+			return addRefund(ctx, tx, refund)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// CreateRefund reserves a refund against an order that is not tied to a
+// return request, to be settled with CompleteRefund once the money has been
+// sent.
+func (s *ReturnStore) CreateRefund(ctx context.Context, refund *Refund) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return addRefund(ctx, tx, refund)
+	})
+}
+
+func (s *ReturnStore) GetRefunds(ctx context.Context, orderID int) ([]Refund, error) {
+	query := `SELECT id, order_id, COALESCE(return_request_id, 0), amount, COALESCE(reason, ''), COALESCE(created_by, 0), status, created_at
+	FROM refunds WHERE order_id = $1 ORDER BY created_at ASC`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []Refund{}
+	for rows.Next() {
+		var refund Refund
+		err := rows.Scan(
+			&refund.ID,
+			&refund.OrderID,
+			&refund.ReturnRequestID,
+			&refund.Amount,
+			&refund.Reason,
+			&refund.CreatedBy,
+			&refund.Status,
+			&refund.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// CompleteRefund settles a pending refund once the provider has sent the
+// money, moving the order to refunded when its whole total has been paid
+// back. A refund the provider did not send is marked failed and no longer
+// counts against the order.
+func (s *ReturnStore) CompleteRefund(ctx context.Context, refund *Refund, sent bool) error {
+	status := RefundStatusFailed
+	if sent {
+		status = RefundStatusSettled
+	}
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var orderStatus string
+		var total float64
+		query := `SELECT status, total_amount FROM orders WHERE id = $1 FOR UPDATE`
+		if err := tx.QueryRowContext(ctx, query, refund.OrderID).Scan(&orderStatus, &total); err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+
+		query = `UPDATE refunds SET status = $1 WHERE id = $2 AND status = $3 RETURNING status`
+		err := tx.QueryRowContext(ctx, query, status, refund.ID, RefundStatusPending).Scan(&refund.Status)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrRefundNotPending
+			default:
+				return err
+			}
+		}
+
+		if !sent {
+			query = `UPDATE orders SET refunded_amount = refunded_amount - $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+			_, err := tx.ExecContext(ctx, query, refund.Amount, refund.OrderID)
+			return err
+		}
+
+		var settled float64
+		query = `SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE order_id = $1 AND status = $2`
+		if err := tx.QueryRowContext(ctx, query, refund.OrderID, RefundStatusSettled).Scan(&settled); err != nil {
+			return err
+		}
+		if settled >= total && CanTransitionOrderStatus(orderStatus, OrderStatusRefunded) {
+			order := &Order{ID: refund.OrderID}
+			return transitionOrderStatus(ctx, tx, order, OrderStatusRefunded, refund.CreatedBy, "order fully refunded")
+		}
+		return nil
+	})
+}
+
+// addRefund locks the order, makes sure the refund does not exceed what is
+// left to refund and reserves it as pending. Pending refunds count towards
+// orders.refunded_amount so concurrent refunds cannot exceed the total.
+func addRefund(ctx context.Context, tx *sql.Tx, refund *Refund) error {
+	var total, refunded float64
+	query := `SELECT total_amount, refunded_amount FROM orders WHERE id = $1 FOR UPDATE`
+	err := tx.QueryRowContext(ctx, query, refund.OrderID).Scan(&total, &refunded)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrorNotFound
+		default:
+			return err
+		}
+	}
+	refund.Amount = roundCents(refund.Amount)
+	if refund.Amount > roundCents(total-refunded) {
+		return ErrRefundExceedsOrderAmount
+	}
+
+	query = `INSERT INTO refunds (order_id, return_request_id, amount, reason, created_by, status)
+	VALUES ($1, NULLIF($2, 0), $3, NULLIF($4, ''), NULLIF($5, 0), $6) RETURNING id, status, created_at`
+	err = tx.QueryRowContext(ctx, query, refund.OrderID, refund.ReturnRequestID, refund.Amount, refund.Reason, refund.CreatedBy, RefundStatusPending).Scan(
+		&refund.ID,
+		&refund.Status,
+		&refund.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	query = `UPDATE orders SET refunded_amount = refunded_amount + $1 , updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	_, err = tx.ExecContext(ctx, query, refund.Amount, refund.OrderID)
+	return err
+}
@@ -0,0 +1,479 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// searchHeadline configures the snippets of free-text search results:
+// matching words are wrapped in <mark> tags.
+const searchHeadline = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+const (
+	SearchSortRelevance = "relevance"
+	SearchSortPrice     = "price"
+	SearchSortNewest    = "newest"
+	SearchSortRating    = "rating"
+	SearchSortTitle     = "title"
+
+	DefaultSearchPageSize = 20
+	MaxSearchPageSize     = 100
+
+	// FuzzySearchMinHits is how many books a free-text search must find for
+	// the fuzzy fallback not to kick in.
+	FuzzySearchMinHits = 3
+)
+
+// availableStock is the book's stock minus the copies held by carts, which
+// are not available to anyone else
+const availableStock = `(books.stock - COALESCE((SELECT SUM(r.quantity) FROM cart_reservations r
+	WHERE r.book_id = books.id AND r.expires_at > CURRENT_TIMESTAMP), 0))`
+
+// bookRating is a book's average review rating, zero without reviews
+const bookRating = `ROUND(COALESCE((SELECT AVG(rv.rating) FROM reviews rv WHERE rv.book_id = books.id), 0), 2)`
+
+// BookSearchResult is a book found by SearchByBooks. Rank and Snippet are
+// only set for free-text searches: Rank is the book's relevance and Snippet
+// the part of its description that best matches the query.
+type BookSearchResult struct {
+	*Book
+	Rating  float64 `json:"rating"`
+	Rank    float32 `json:"rank,omitempty"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+// BooksBySearchPayload filters SearchByBooks. Query is free text in web
+// search syntax: quoted phrases, "or" and -excluded words are understood.
+// With Fuzzy it is matched by trigram similarity to titles and authors
+// instead, which tolerates typos.
+// Sort defaults to relevance for free-text searches and to title otherwise;
+// Order, asc or desc, defaults to the natural direction of the sort: best
+// match, lowest price, newest and best rated first, titles A to Z.
+type BooksBySearchPayload struct {
+	Query    string
+	Title    string
+	Author   string
+	Tags     []string
+	MinPrice float32
+	MaxPrice float32
+	InStock  *bool
+	Fuzzy    bool
+	Sort     string
+	Order    string
+	Limit    int
+	Cursor   string
+}
+
+// BookSearchPage is one page of search results. Total counts every match,
+// not just the ones on the page; NextCursor is empty on the last page.
+// Fuzzy tells that the books were matched by similarity, as the search
+// itself found too few; DidYouMean is the title or author closest to a query
+// that found too few books.
+type BookSearchPage struct {
+	Books      []*BookSearchResult `json:"books"`
+	Total      int                 `json:"total"`
+	NextCursor string              `json:"next_cursor"`
+	Fuzzy      bool                `json:"fuzzy"`
+	DidYouMean string              `json:"did_you_mean,omitempty"`
+	Facets     *SearchFacets       `json:"facets,omitempty"`
+}
+
+// SearchByBooks returns one page of the books matching the filters. Pages
+// are keyed on the sort value and the book ID, like order listings. Free-text
+// relevance weighs title over author over tags over description.
+//
+// When a free-text search finds fewer than FuzzySearchMinHits books, its
+// first page falls back to a fuzzy search if that finds more, and suggests
+// what the user might have meant. Later pages of a fuzzy result need Fuzzy
+// set along with the cursor.
+func (s *BookStore) SearchByBooks(ctx context.Context, filters BooksBySearchPayload) (*BookSearchPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	page, err := s.searchPage(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if filters.Query == "" || filters.Fuzzy || filters.Cursor != "" || page.Total >= FuzzySearchMinHits {
+		return page, nil
+	}
+
+	filters.Fuzzy = true
+	fuzzy, err := s.searchPage(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if fuzzy.Total > page.Total {
+		page = fuzzy
+	}
+	if page.DidYouMean, err = s.didYouMean(ctx, filters.Query); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *BookStore) searchPage(ctx context.Context, filters BooksBySearchPayload) (*BookSearchPage, error) {
+	sortBy, sortExpr, err := searchSort(filters.Sort, filters.Query != "", filters.Fuzzy)
+	if err != nil {
+		return nil, err
+	}
+	descending := sortBy == SearchSortRelevance || sortBy == SearchSortNewest || sortBy == SearchSortRating
+	switch filters.Order {
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	}
+	limit := filters.Limit
+	if limit <= 0 {
+		limit = DefaultSearchPageSize
+	}
+	limit = min(limit, MaxSearchPageSize)
+
+	from, where, args := searchConditions(filters)
+
+	page := &BookSearchPage{Books: []*BookSearchResult{}, Fuzzy: filters.Fuzzy}
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", from, where)
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	columns := `books.id, books.title, books.author, books.isbn, books.price, books.tags, books.description,
+		books.cover_image_url, books.pages, books.stock, books.created_at, books.updated_at, books.version, ` + bookRating
+	if filters.Query != "" {
+		columns += fmt.Sprintf(`, %s,
+		ts_headline(q.language, COALESCE(books.description, ''), q.query, '%s')`, searchRelevance(filters.Fuzzy), searchHeadline)
+	} else {
+		columns += `, 0, ''`
+	}
+	columns += fmt.Sprintf(", (%s)::text", sortExpr)
+
+	comparison, direction := ">", "ASC"
+	if descending {
+		comparison, direction = "<", "DESC"
+	}
+	if filters.Cursor != "" {
+		value, id, err := decodeSearchCursor(filters.Cursor, sortBy)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value, id)
+		where += fmt.Sprintf(" AND (%s, books.id) %s ($%d, $%d)", sortExpr, comparison, len(args)-1, len(args))
+	}
+	// one extra row tells whether there is a next page
+	args = append(args, limit+1)
+	query := fmt.Sprintf("SELECT %s FROM %s %s ORDER BY %s %s, books.id %s LIMIT $%d",
+		columns, from, where, sortExpr, direction, direction, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lastValue string
+	for rows.Next() {
+		r := &BookSearchResult{Book: &Book{}}
+		var sortValue string
+
+		err := rows.Scan(
+			&r.ID,
+			&r.Title,
+			&r.Author,
+			&r.ISBN,
+			&r.Price,
+			pq.Array(&r.Tags),
+			&r.Description,
+			&r.CoverImageUrl,
+			&r.Pages,
+			&r.Stock,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+			&r.Version,
+			&r.Rating,
+			&r.Rank,
+			&r.Snippet,
+			&sortValue,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Books) == limit {
+			page.NextCursor = encodeSearchCursor(page.Books[limit-1].ID, lastValue)
+			break
+		}
+		page.Books = append(page.Books, r)
+		lastValue = sortValue
+	}
+	return page, rows.Err()
+}
+
+// searchSort returns the sort to use and the expression ordering by it.
+// Relevance needs a free-text query; without one it falls back to title.
+func searchSort(sortBy string, hasQuery, fuzzy bool) (string, string, error) {
+	if sortBy == "" || (sortBy == SearchSortRelevance && !hasQuery) {
+		sortBy = SearchSortTitle
+		if hasQuery {
+			sortBy = SearchSortRelevance
+		}
+	}
+	switch sortBy {
+	case SearchSortRelevance:
+		return sortBy, searchRelevance(fuzzy), nil
+	case SearchSortPrice:
+		return sortBy, "books.price", nil
+	case SearchSortNewest:
+		return sortBy, "books.created_at", nil
+	case SearchSortRating:
+		return sortBy, bookRating, nil
+	case SearchSortTitle:
+		return sortBy, "books.title", nil
+	default:
+		return "", "", fmt.Errorf("cannot sort books by %q", sortBy)
+	}
+}
+
+// searchRelevance is the expression ranking the books found by a free-text
+// search: the weighted full-text rank, or for fuzzy searches how closely the
+// query resembles part of the title or author.
+func searchRelevance(fuzzy bool) string {
+	if fuzzy {
+		return "GREATEST(word_similarity(q.text, books.title), word_similarity(q.text, books.author))"
+	}
+	return "ts_rank(books.search_vector, q.query)"
+}
+
+// didYouMean returns the title or author that most resembles the query, or
+// an empty string if none is close enough.
+func (s *BookStore) didYouMean(ctx context.Context, query string) (string, error) {
+	q := `SELECT value FROM (
+		SELECT title AS value, word_similarity($1, title) AS score FROM books WHERE $1 <% title
+		UNION ALL
+		SELECT author, word_similarity($1, author) FROM books WHERE $1 <% author
+	) c
+	WHERE lower(value) <> lower($1)
+	ORDER BY score DESC, value
+	LIMIT 1`
+
+	var suggestion string
+	err := s.db.QueryRowContext(ctx, q, query).Scan(&suggestion)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return suggestion, err
+}
+
+// encodeSearchCursor makes an opaque cursor out of the ID and the sort
+// value, as Postgres prints it, of the last book on a page.
+func encodeSearchCursor(bookID int, value string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(bookID) + "|" + value))
+}
+
+func decodeSearchCursor(cursor, sortBy string) (string, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	idPart, value, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return "", 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+
+	switch sortBy {
+	case SearchSortRelevance, SearchSortPrice, SearchSortRating:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", 0, ErrInvalidCursor
+		}
+	case SearchSortNewest:
+		if _, err := time.Parse(cursorTimeLayout, value); err != nil {
+			return "", 0, ErrInvalidCursor
+		}
+	}
+	return value, id, nil
+}
+
+// searchConditions builds the FROM and WHERE clauses selecting the books
+// that match the filters. Free-text searches join the query as q: q.text as
+// given, q.query parsed and q.language, the text search configuration.
+func searchConditions(filters BooksBySearchPayload) (string, string, []any) {
+	from := "books"
+	where := "WHERE 1=1"
+	args := []any{}
+
+	if filters.Query != "" {
+		args = append(args, filters.Query)
+		from += fmt.Sprintf(` CROSS JOIN (
+			SELECT $%[1]d::text AS text, websearch_to_tsquery(language, $%[1]d) AS query, language FROM search_settings
+		) q`, len(args))
+		if filters.Fuzzy {
+			where += " AND (q.text <% books.title OR q.text <% books.author)"
+		} else {
+			where += " AND books.search_vector @@ q.query"
+		}
+	}
+	if filters.Title != "" {
+		args = append(args, "%"+filters.Title+"%")
+		where += fmt.Sprintf(" AND books.title ILIKE $%d", len(args))
+	}
+	if filters.Author != "" {
+		args = append(args, "%"+filters.Author+"%")
+		where += fmt.Sprintf(" AND books.author ILIKE $%d", len(args))
+	}
+	if len(filters.Tags) > 0 {
+		args = append(args, pq.Array(filters.Tags))
+		where += fmt.Sprintf(" AND books.tags && $%d", len(args))
+	}
+	if filters.MinPrice > 0 {
+		args = append(args, filters.MinPrice)
+		where += fmt.Sprintf(" AND books.price >= $%d", len(args))
+	}
+	if filters.MaxPrice > 0 {
+		args = append(args, filters.MaxPrice)
+		where += fmt.Sprintf(" AND books.price <= $%d", len(args))
+	}
+	if filters.InStock != nil {
+		if *filters.InStock {
+			where += " AND " + availableStock + " > 0"
+		}
+	}
+	return from, where, args
+}
+
+// SetSearchLanguage makes language, a Postgres text search configuration
+// such as english or simple, the one books are indexed and searched in.
+// Books indexed in another language keep matching until ReindexSearch has
+// caught up with them.
+func (s *BookStore) SetSearchLanguage(ctx context.Context, language string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	query := `UPDATE search_settings SET language = $1::regconfig WHERE language <> $1::regconfig`
+	if _, err := s.db.ExecContext(ctx, query, language); err != nil {
+		return fmt.Errorf("search language %q: %w", language, err)
+	}
+	return nil
+}
+
+// ReindexSearch rebuilds the search vector of the books indexed in another
+// language than the current one, batchSize books per statement, and returns
+// how many it reindexed. Batches skip books that are locked, for instance by
+// another server reindexing them, and an interrupted run is finished by the
+// next one.
+func (s *BookStore) ReindexSearch(ctx context.Context, batchSize int) (int64, error) {
+	query := `WITH batch AS (
+		SELECT id FROM books
+		WHERE id > $1 AND search_language IS DISTINCT FROM (SELECT language FROM search_settings)
+		ORDER BY id LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE books b
+	SET search_vector = books_search_vector(s.language, b.title, b.author, b.tags, b.description), search_language = s.language
+	FROM batch, search_settings s
+	WHERE b.id = batch.id
+	RETURNING b.id`
+
+	// books locked by someone else are skipped, so passes over the catalog
+	// repeat until one finds nothing left to do
+	var reindexed int64
+	for {
+		var pass int64
+		lastID := 0
+		for {
+			ids, err := s.reindexBatch(ctx, query, lastID, batchSize)
+			if err != nil {
+				return reindexed + pass, err
+			}
+			if len(ids) == 0 {
+				break
+			}
+			pass += int64(len(ids))
+			lastID = slices.Max(ids)
+		}
+		if pass == 0 {
+			return reindexed, nil
+		}
+		reindexed += pass
+	}
+}
+
+func (s *BookStore) reindexBatch(ctx context.Context, query string, lastID, batchSize int) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, lastID, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+const (
+	SuggestionTitle  = "title"
+	SuggestionAuthor = "author"
+)
+
+// BookSuggestion completes what a user is typing into the search box with a
+// book title or an author name. BookID is only set for titles.
+type BookSuggestion struct {
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+	BookID int    `json:"book_id,omitempty"`
+}
+
+// Suggest returns up to limit titles and authors for a partly typed text.
+// Those starting with the text come first, then those containing it, then
+// similar ones, so that misspelled names still find something.
+func (s *BookStore) Suggest(ctx context.Context, text string, limit int) ([]*BookSuggestion, error) {
+	query := `SELECT kind, value, book_id FROM (
+		SELECT 'title' AS kind, title AS value, id AS book_id,
+		title ILIKE $2 AS prefix, title ILIKE $3 AS contains, word_similarity($1, title) AS score
+		FROM books WHERE title ILIKE $3 OR $1 <% title
+		UNION ALL
+		SELECT 'author', author, 0, bool_or(author ILIKE $2), bool_or(author ILIKE $3), MAX(word_similarity($1, author))
+		FROM books WHERE author ILIKE $3 OR $1 <% author
+		GROUP BY author
+	) s
+	ORDER BY prefix DESC, contains DESC, score DESC, value
+	LIMIT $4`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, text, text+"%", "%"+text+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []*BookSuggestion{}
+	for rows.Next() {
+		sg := &BookSuggestion{}
+		if err := rows.Scan(&sg.Kind, &sg.Value, &sg.BookID); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, sg)
+	}
+	return suggestions, rows.Err()
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		sortBy string
+		id     int
+		value  string
+	}{
+		{"relevance", SearchSortRelevance, 7, "0.6079271"},
+		{"price", SearchSortPrice, 12, "19.99"},
+		{"rating", SearchSortRating, 3, "4.5"},
+		{"newest", SearchSortNewest, 42, "2025-03-01 10:15:30.123456"},
+		{"title with separator", SearchSortTitle, 9, "The Name | of the Wind"},
+		{"empty title", SearchSortTitle, 10, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, id, err := decodeSearchCursor(encodeSearchCursor(tt.id, tt.value), tt.sortBy)
+			if err != nil {
+				t.Fatalf("decodeSearchCursor: %v", err)
+			}
+			if id != tt.id || value != tt.value {
+				t.Errorf("decoded (%q, %d), want (%q, %d)", value, id, tt.value, tt.id)
+			}
+		})
+	}
+}
+
+func TestDecodeSearchCursorRejectsInvalid(t *testing.T) {
+	raw := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+		sortBy string
+	}{
+		{"not base64", "!!!", SearchSortTitle},
+		{"no separator", raw("12"), SearchSortTitle},
+		{"id not a number", raw("abc|Dune"), SearchSortTitle},
+		{"price not a number", raw("12|cheap"), SearchSortPrice},
+		{"relevance not a number", raw("12|high"), SearchSortRelevance},
+		{"rating not a number", raw("12|five"), SearchSortRating},
+		{"newest not a timestamp", raw("12|yesterday"), SearchSortNewest},
+		{"cursor of another sort", encodeSearchCursor(12, "Dune"), SearchSortPrice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeSearchCursor(tt.cursor, tt.sortBy); err != ErrInvalidCursor {
+				t.Errorf("decodeSearchCursor(%q, %q) error = %v, want %v", tt.cursor, tt.sortBy, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrShipmentNotAllowed       = errors.New("order cannot be shipped in its current status")
+	ErrShipmentQuantityExceeded = errors.New("shipment quantity exceeds the quantity left to ship")
+	ErrShipmentAlreadyDelivered = errors.New("shipment has already been delivered")
+	ErrShipmentNothingToShip    = errors.New("every item of the order has already been shipped")
+	ErrShipmentItemNotInOrder   = errors.New("book is not part of the order")
+)
+
+type Shipment struct {
+	ID             int            `json:"id"`
+	OrderID        int            `json:"order_id"`
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"tracking_number"`
+	TrackingURL    string         `json:"tracking_url"`
+	ShippedAt      time.Time      `json:"shipped_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+	CreatedBy      int            `json:"created_by"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	Items          []ShipmentItem `json:"items"`
+}
+
+type ShipmentItem struct {
+	ID          int `json:"id"`
+	ShipmentID  int `json:"shipment_id"`
+	OrderItemID int `json:"order_item_id"`
+	BookID      int `json:"book_id"`
+	Quantity    int `json:"quantity"`
+}
+
+type ShipmentStore struct {
+	db *sql.DB
+}
+
+// unshippedItem is an order line together with the quantity that is not yet
+// part of any shipment.
+type unshippedItem struct {
+	orderItemID int
+	remaining   int
+}
+
+// Create records a parcel for the order. Items name books of the order and
+// how many copies go into the parcel; when no items are given everything
+// that has not shipped yet goes into it. Once every item of the order is
+// covered by a shipment the order moves to shipped.
+func (s *ShipmentStore) Create(ctx context.Context, shipment *Shipment) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		order := &Order{ID: shipment.OrderID}
+		err := tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, order.ID).Scan(&order.Status)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+		if order.Status != OrderStatusProcessing {
+			return fmt.Errorf("%w: %s", ErrShipmentNotAllowed, order.Status)
+		}
+
+		unshipped, err := unshippedOrderItems(ctx, tx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		if len(shipment.Items) == 0 {
+			for bookID, item := range unshipped {
+				if item.remaining > 0 {
+					shipment.Items = append(shipment.Items, ShipmentItem{BookID: bookID, Quantity: item.remaining})
+				}
+			}
+			if len(shipment.Items) == 0 {
+				return ErrShipmentNothingToShip
+			}
+		}
+
+		for i := range shipment.Items {
+			item := &shipment.Items[i]
+			line, ok := unshipped[item.BookID]
+			if !ok {
+				return fmt.Errorf("book %d: %w", item.BookID, ErrShipmentItemNotInOrder)
+			}
+			if item.Quantity > line.remaining {
+				return fmt.Errorf("%w: book %d has %d left to ship", ErrShipmentQuantityExceeded, item.BookID, line.remaining)
+			}
+			item.OrderItemID = line.orderItemID
+			line.remaining -= item.Quantity
+			unshipped[item.BookID] = line
+		}
+
+		query := `INSERT INTO shipments (order_id, carrier, tracking_number, tracking_url, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, 0)) RETURNING id, shipped_at, created_at, updated_at`
+		err = tx.QueryRowContext(ctx, query,
+			shipment.OrderID,
+			shipment.Carrier,
+			shipment.TrackingNumber,
+			shipment.TrackingURL,
+			shipment.CreatedBy,
+		).Scan(&shipment.ID, &shipment.ShippedAt, &shipment.CreatedAt, &shipment.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		query = `INSERT INTO shipment_items (shipment_id, order_item_id, quantity) VALUES ($1, $2, $3) RETURNING id`
+		for i := range shipment.Items {
+			item := &shipment.Items[i]
+			item.ShipmentID = shipment.ID
+			if err := tx.QueryRowContext(ctx, query, item.ShipmentID, item.OrderItemID, item.Quantity).Scan(&item.ID); err != nil {
+				return err
+			}
+		}
+
+		for _, line := range unshipped {
+			if line.remaining > 0 {
+				return nil
+			}
+		}
+		return transitionOrderStatus(ctx, tx, order, OrderStatusShipped, shipment.CreatedBy, "all items shipped")
+	})
+}
+
+// MarkDelivered records the delivery of a parcel. The order moves to
+// delivered once every item has shipped and every parcel has arrived.
+func (s *ShipmentStore) MarkDelivered(ctx context.Context, shipment *Shipment, actorID int) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		order := &Order{ID: shipment.OrderID}
+		err := tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, order.ID).Scan(&order.Status)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+
+		query := `UPDATE shipments SET delivered_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND delivered_at IS NULL RETURNING delivered_at, updated_at`
+		var deliveredAt time.Time
+		if err := tx.QueryRowContext(ctx, query, shipment.ID).Scan(&deliveredAt, &shipment.UpdatedAt); err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrShipmentAlreadyDelivered
+			default:
+				return err
+			}
+		}
+		shipment.DeliveredAt = &deliveredAt
+
+		if order.Status != OrderStatusShipped {
+			return nil
+		}
+		var inTransit bool
+		query = `SELECT EXISTS (SELECT 1 FROM shipments WHERE order_id = $1 AND delivered_at IS NULL)`
+		if err := tx.QueryRowContext(ctx, query, order.ID).Scan(&inTransit); err != nil {
+			return err
+		}
+		if inTransit {
+			return nil
+		}
+		unshipped, err := unshippedOrderItems(ctx, tx, order.ID)
+		if err != nil {
+			return err
+		}
+		for _, line := range unshipped {
+			if line.remaining > 0 {
+				return nil
+			}
+		}
+		return transitionOrderStatus(ctx, tx, order, OrderStatusDelivered, actorID, "all shipments delivered")
+	})
+}
+
+func (s *ShipmentStore) GetByID(ctx context.Context, ID int) (*Shipment, error) {
+	query := `SELECT id, order_id, carrier, tracking_number, COALESCE(tracking_url, ''), shipped_at, delivered_at,
+	COALESCE(created_by, 0), created_at, updated_at
+	FROM shipments WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	shipment := &Shipment{}
+	if err := scanShipment(s.db.QueryRowContext(ctx, query, ID), shipment); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	shipments := []*Shipment{shipment}
+	if err := s.attachItems(ctx, shipments); err != nil {
+		return nil, err
+	}
+	return shipment, nil
+}
+
+func (s *ShipmentStore) GetByOrderID(ctx context.Context, orderID int) ([]*Shipment, error) {
+	query := `SELECT id, order_id, carrier, tracking_number, COALESCE(tracking_url, ''), shipped_at, delivered_at,
+	COALESCE(created_by, 0), created_at, updated_at
+	FROM shipments WHERE order_id = $1 ORDER BY shipped_at, id`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shipments := []*Shipment{}
+	for rows.Next() {
+		shipment := &Shipment{}
+		if err := scanShipment(rows, shipment); err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.attachItems(ctx, shipments); err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+func (s *ShipmentStore) attachItems(ctx context.Context, shipments []*Shipment) error {
+	if len(shipments) == 0 {
+		return nil
+	}
+	byID := make(map[int]*Shipment, len(shipments))
+	ids := make([]int64, len(shipments))
+	for i, shipment := range shipments {
+		shipment.Items = []ShipmentItem{}
+		byID[shipment.ID] = shipment
+		ids[i] = int64(shipment.ID)
+	}
+
+	query := `SELECT si.id, si.shipment_id, si.order_item_id, oi.book_id, si.quantity
+	FROM shipment_items si
+	JOIN order_items oi ON oi.id = si.order_item_id
+	WHERE si.shipment_id = ANY($1)
+	ORDER BY si.id`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item ShipmentItem
+		if err := rows.Scan(&item.ID, &item.ShipmentID, &item.OrderItemID, &item.BookID, &item.Quantity); err != nil {
+			return err
+		}
+		shipment := byID[item.ShipmentID]
+		shipment.Items = append(shipment.Items, item)
+	}
+	return rows.Err()
+}
+
+func scanShipment(row rowScanner, shipment *Shipment) error {
+	var deliveredAt sql.NullTime
+	err := row.Scan(
+		&shipment.ID,
+		&shipment.OrderID,
+		&shipment.Carrier,
+		&shipment.TrackingNumber,
+		&shipment.TrackingURL,
+		&shipment.ShippedAt,
+		&deliveredAt,
+		&shipment.CreatedBy,
+		&shipment.CreatedAt,
+		&shipment.UpdatedAt,
+	)
+	if deliveredAt.Valid {
+		shipment.DeliveredAt = &deliveredAt.Time
+	}
+	return err
+}
+
+// unshippedOrderItems returns, per book of the order, the order line and the
+// quantity that no shipment covers yet.
+func unshippedOrderItems(ctx context.Context, tx *sql.Tx, orderID int) (map[int]unshippedItem, error) {
+	query := `SELECT oi.id, oi.book_id, oi.quantity - COALESCE(SUM(si.quantity), 0)
+	FROM order_items oi
+	LEFT JOIN shipment_items si ON si.order_item_id = oi.id
+	WHERE oi.order_id = $1
+	GROUP BY oi.id, oi.book_id, oi.quantity`
+
+	rows, err := tx.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[int]unshippedItem)
+	for rows.Next() {
+		var bookID int
+		var item unshippedItem
+		if err := rows.Scan(&item.orderItemID, &bookID, &item.remaining); err != nil {
+			return nil, err
+		}
+		items[bookID] = item
+	}
+	return items, rows.Err()
+}
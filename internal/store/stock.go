@@ -0,0 +1,395 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	StockMovementReceipt    = "receipt"
+	StockMovementSale       = "sale"
+	StockMovementReturn     = "return"
+	StockMovementAdjustment = "adjustment"
+	StockMovementTransfer   = "transfer"
+)
+
+var ErrNegativeStock = errors.New("stock cannot go below zero")
+
+// StockMovement is one entry of the inventory ledger. Quantity is signed:
+// positive movements add copies, negative ones take them away. StockAfter is
+// books.stock right after the movement, so the ledger explains every value
+// the column ever had. LocationID is where the copies moved; zero adds to the
+// default location and takes from the locations in priority order.
+type StockMovement struct {
+	ID         int       `json:"id"`
+	BookID     int       `json:"book_id"`
+	LocationID int       `json:"location_id,omitempty"`
+	Kind       string    `json:"kind"`
+	Quantity   int       `json:"quantity"`
+	StockAfter int       `json:"stock_after"`
+	Reason     string    `json:"reason,omitempty"`
+	OrderID    int       `json:"order_id,omitempty"`
+	ActorID    int       `json:"actor_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StockTransfer moves copies of a book from one location to another. It
+// leaves books.stock unchanged and is recorded in the ledger as a pair of
+// transfer movements.
+type StockTransfer struct {
+	ID             int       `json:"id"`
+	BookID         int       `json:"book_id"`
+	FromLocationID int       `json:"from_location_id"`
+	ToLocationID   int       `json:"to_location_id"`
+	Quantity       int       `json:"quantity"`
+	Note           string    `json:"note,omitempty"`
+	ActorID        int       `json:"actor_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type StockStore struct {
+	db *sql.DB
+}
+
+// Record applies a movement to the book's stock and writes it to the ledger
+// in one transaction.
+func (s *StockStore) Record(ctx context.Context, movement *StockMovement) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return moveStock(ctx, tx, movement)
+	})
+}
+
+// setStockLevel records an adjustment that brings the book's stock to level,
+// as after a stock count. Nothing is recorded when the stock already matches.
+func setStockLevel(ctx context.Context, tx *sql.Tx, movement *StockMovement, level int) error {
+	var stock int
+	err := tx.QueryRowContext(ctx, `SELECT stock FROM books WHERE id = $1 FOR UPDATE`, movement.BookID).Scan(&stock)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrorNotFound
+		default:
+			return err
+		}
+	}
+	movement.Kind = StockMovementAdjustment
+	movement.Quantity = level - stock
+	movement.StockAfter = stock
+	if movement.Quantity == 0 {
+		return nil
+	}
+	return moveStock(ctx, tx, movement)
+}
+
+// GetByBookID returns the book's movements newest first. A positive beforeID
+// continues the history after the last movement of the previous page.
+func (s *StockStore) GetByBookID(ctx context.Context, bookID, beforeID, limit int) ([]*StockMovement, error) {
+	query := `SELECT id, book_id, COALESCE(location_id, 0), kind, quantity, stock_after, COALESCE(reason, ''),
+	COALESCE(order_id, 0), COALESCE(actor_id, 0), created_at
+	FROM stock_movements
+	WHERE book_id = $1 AND ($2 = 0 OR id < $2)
+	ORDER BY id DESC
+	LIMIT $3`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, bookID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []*StockMovement{}
+	for rows.Next() {
+		m := &StockMovement{}
+		err := rows.Scan(&m.ID, &m.BookID, &m.LocationID, &m.Kind, &m.Quantity, &m.StockAfter, &m.Reason, &m.OrderID, &m.ActorID, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
+
+// Transfer moves copies between two locations of the book.
+func (s *StockStore) Transfer(ctx context.Context, transfer *StockTransfer) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		reason := fmt.Sprintf("transfer from location %d to location %d", transfer.FromLocationID, transfer.ToLocationID)
+		out := &StockMovement{
+			BookID:     transfer.BookID,
+			LocationID: transfer.FromLocationID,
+			Kind:       StockMovementTransfer,
+			Quantity:   -transfer.Quantity,
+			Reason:     reason,
+			ActorID:    transfer.ActorID,
+		}
+		if err := moveStock(ctx, tx, out); err != nil {
+			return err
+		}
+		in := *out
+		in.LocationID = transfer.ToLocationID
+		in.Quantity = transfer.Quantity
+		if err := moveStock(ctx, tx, &in); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO stock_transfers (book_id, from_location_id, to_location_id, quantity, note, actor_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, 0)) RETURNING id, created_at`
+		return tx.QueryRowContext(ctx, query,
+			transfer.BookID,
+			transfer.FromLocationID,
+			transfer.ToLocationID,
+			transfer.Quantity,
+			transfer.Note,
+			transfer.ActorID,
+		).Scan(&transfer.ID, &transfer.CreatedAt)
+	})
+}
+
+func (s *StockStore) GetTransfers(ctx context.Context, bookID int) ([]*StockTransfer, error) {
+	query := `SELECT id, book_id, from_location_id, to_location_id, quantity, COALESCE(note, ''), COALESCE(actor_id, 0), created_at
+	FROM stock_transfers WHERE book_id = $1 ORDER BY id DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []*StockTransfer{}
+	for rows.Next() {
+		t := &StockTransfer{}
+		err := rows.Scan(&t.ID, &t.BookID, &t.FromLocationID, &t.ToLocationID, &t.Quantity, &t.Note, &t.ActorID, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+// moveStock is the only place stock changes after a book is created: it
+// updates the book's stock at the location and books.stock, their sum,
+// inserts the ledger entry and queues any stock alerts inside the caller's
+// transaction. Stock added without a location goes to the default one;
+// stock taken without a location is spread by spreadStockDebit.
+func moveStock(ctx context.Context, tx *sql.Tx, movement *StockMovement) error {
+	if movement.LocationID == 0 && movement.Quantity < 0 {
+		return spreadStockDebit(ctx, tx, movement)
+	}
+
+	query := `UPDATE books SET stock = stock + $1 , version = version + 1 WHERE id = $2 RETURNING stock`
+
+	err := tx.QueryRowContext(ctx, query, movement.Quantity, movement.BookID).Scan(&movement.StockAfter)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return fmt.Errorf("book %d: %w", movement.BookID, ErrorNotFound)
+		default:
+			return err
+		}
+	}
+	if movement.StockAfter < 0 {
+		return fmt.Errorf("book %d: %w", movement.BookID, ErrNegativeStock)
+	}
+
+	if movement.LocationID == 0 {
+		if movement.LocationID, err = defaultLocationID(ctx, tx); err != nil {
+			return err
+		}
+	}
+	if movement.Quantity > 0 {
+		query = `INSERT INTO book_stock (book_id, location_id, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (book_id, location_id) DO UPDATE SET quantity = book_stock.quantity + EXCLUDED.quantity`
+		if _, err := tx.ExecContext(ctx, query, movement.BookID, movement.LocationID, movement.Quantity); err != nil {
+			return err
+		}
+	} else {
+		query = `UPDATE book_stock SET quantity = quantity + $3 WHERE book_id = $1 AND location_id = $2 AND quantity + $3 >= 0`
+		res, err := tx.ExecContext(ctx, query, movement.BookID, movement.LocationID, movement.Quantity)
+		if err != nil {
+			return err
+		}
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return fmt.Errorf("book %d at location %d: %w", movement.BookID, movement.LocationID, ErrNegativeStock)
+		}
+	}
+
+	query = `INSERT INTO stock_movements (book_id, location_id, kind, quantity, stock_after, reason, order_id, actor_id)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, 0), NULLIF($8, 0))
+	RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, query,
+		movement.BookID,
+		movement.LocationID,
+		movement.Kind,
+		movement.Quantity,
+		movement.StockAfter,
+		movement.Reason,
+		movement.OrderID,
+		movement.ActorID,
+	).Scan(&movement.ID, &movement.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return recordStockAlerts(ctx, tx, movement)
+}
+
+// spreadStockDebit takes stock that was not taken from a particular location
+// from the locations holding the book in priority order, the way orders are
+// allocated by default, recording one movement per location. The movement
+// ends up with the ID and stock level of the last one.
+func spreadStockDebit(ctx context.Context, tx *sql.Tx, movement *StockMovement) error {
+	query := `SELECT bs.location_id, bs.quantity FROM book_stock bs
+	JOIN stock_locations l ON l.id = bs.location_id
+	WHERE bs.book_id = $1 AND bs.quantity > 0
+	ORDER BY l.priority, l.id
+	FOR UPDATE OF bs`
+
+	rows, err := tx.QueryContext(ctx, query, movement.BookID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var parts []*StockMovement
+	remaining := -movement.Quantity
+	for remaining > 0 && rows.Next() {
+		var locationID, quantity int
+		if err := rows.Scan(&locationID, &quantity); err != nil {
+			return err
+		}
+		part := *movement
+		part.LocationID = locationID
+		part.Quantity = -min(quantity, remaining)
+		parts = append(parts, &part)
+		remaining += part.Quantity
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+	if remaining > 0 {
+		return fmt.Errorf("book %d: %w", movement.BookID, ErrNegativeStock)
+	}
+
+	for _, part := range parts {
+		if err := moveStock(ctx, tx, part); err != nil {
+			return err
+		}
+		movement.ID = part.ID
+		movement.StockAfter = part.StockAfter
+		movement.CreatedAt = part.CreatedAt
+	}
+	return nil
+}
+
+// restockOrderItems puts the items of the order that have not shipped back
+// into stock at the locations they were allocated from. Items of orders
+// placed before stock had locations go back to the default location.
+func restockOrderItems(ctx context.Context, tx *sql.Tx, orderID, actorID int, reason string) error {
+	query := `SELECT oi.id, oi.book_id, COALESCE(a.location_id, 0), COALESCE(a.quantity, oi.quantity),
+	COALESCE((SELECT SUM(si.quantity) FROM shipment_items si WHERE si.order_item_id = oi.id), 0)
+	FROM order_items oi
+	LEFT JOIN order_item_allocations a ON a.order_item_id = oi.id
+	WHERE oi.order_id = $1
+	ORDER BY oi.book_id, oi.id, a.location_id`
+
+	rows, err := tx.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var allocations []itemAllocation
+	for rows.Next() {
+		var a itemAllocation
+		if err := rows.Scan(&a.orderItemID, &a.bookID, &a.locationID, &a.quantity, &a.shipped); err != nil {
+			return err
+		}
+		allocations = append(allocations, a)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, a := range unshippedAllocations(allocations) {
+		m := &StockMovement{
+			BookID:     a.bookID,
+			LocationID: a.locationID,
+			Quantity:   a.quantity,
+			Kind:       StockMovementReturn,
+			Reason:     reason,
+			OrderID:    orderID,
+			ActorID:    actorID,
+		}
+		if err := moveStock(ctx, tx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemAllocation is the quantity of an order line taken from one location,
+// together with how many copies of the whole line have shipped.
+type itemAllocation struct {
+	orderItemID int
+	bookID      int
+	locationID  int
+	quantity    int
+	shipped     int
+}
+
+// unshippedAllocations returns the allocations left once the shipped copies
+// of every order line are taken out of its allocations in order. Allocations
+// that shipped completely are dropped.
+func unshippedAllocations(allocations []itemAllocation) []itemAllocation {
+	shipped := make(map[int]int)
+	var left []itemAllocation
+	for _, a := range allocations {
+		if _, ok := shipped[a.orderItemID]; !ok {
+			shipped[a.orderItemID] = a.shipped
+		}
+		taken := min(a.quantity, shipped[a.orderItemID])
+		shipped[a.orderItemID] -= taken
+		if a.quantity -= taken; a.quantity > 0 {
+			left = append(left, a)
+		}
+	}
+	return left
+}
+
+// allocatedLocationID returns the location most of the order's copies of the
+// book were taken from, or zero for the default location.
+func allocatedLocationID(ctx context.Context, tx *sql.Tx, orderID, bookID int) (int, error) {
+	query := `SELECT a.location_id FROM order_item_allocations a
+	JOIN order_items oi ON oi.id = a.order_item_id
+	WHERE oi.order_id = $1 AND oi.book_id = $2
+	ORDER BY a.quantity DESC, a.location_id
+	LIMIT 1`
+
+	var locationID int
+	err := tx.QueryRowContext(ctx, query, orderID, bookID).Scan(&locationID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return locationID, err
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	StockAlertLowStock    = "low_stock"
+	StockAlertBackInStock = "back_in_stock"
+)
+
+// LowStockThreshold is the stock level below which moderators are warned.
+var LowStockThreshold = 5
+
+var ErrBookInStock = errors.New("book is in stock")
+
+// StockSubscription asks for an email once the book is back in stock. It is
+// used up by that email; NotifiedAt is set when it is sent.
+type StockSubscription struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	BookID     int        `json:"book_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	NotifiedAt *time.Time `json:"notified_at"`
+}
+
+// StockAlert is written in the same transaction as the stock movement that
+// crossed a threshold, so every crossing is announced exactly once by
+// whoever claims the alert.
+type StockAlert struct {
+	ID        int
+	BookID    int
+	Title     string
+	Author    string
+	Kind      string
+	Stock     int
+	CreatedAt time.Time
+}
+
+// Recipient is a user to email about a stock alert.
+type Recipient struct {
+	UserID   int
+	Username string
+	Email    string
+}
+
+type StockNotificationStore struct {
+	db *sql.DB
+}
+
+// Subscribe registers the user for a back in stock email. Only books that
+// are out of stock can be subscribed to; subscribing twice returns the
+// pending subscription.
+func (s *StockNotificationStore) Subscribe(ctx context.Context, sub *StockSubscription) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var stock int
+		err := tx.QueryRowContext(ctx, `SELECT stock FROM books WHERE id = $1 FOR SHARE`, sub.BookID).Scan(&stock)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+		if stock > 0 {
+			return ErrBookInStock
+		}
+
+		query := `INSERT INTO stock_subscriptions (user_id, book_id) VALUES ($1, $2)
+		ON CONFLICT (book_id, user_id) WHERE notified_at IS NULL DO NOTHING`
+		if _, err := tx.ExecContext(ctx, query, sub.UserID, sub.BookID); err != nil {
+			return err
+		}
+
+		query = `SELECT id, created_at FROM stock_subscriptions WHERE user_id = $1 AND book_id = $2 AND notified_at IS NULL`
+		return tx.QueryRowContext(ctx, query, sub.UserID, sub.BookID).Scan(&sub.ID, &sub.CreatedAt)
+	})
+}
+
+func (s *StockNotificationStore) Unsubscribe(ctx context.Context, userID, bookID int) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	query := `DELETE FROM stock_subscriptions WHERE user_id = $1 AND book_id = $2 AND notified_at IS NULL`
+	res, err := s.db.ExecContext(ctx, query, userID, bookID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrorNotFound
+	}
+	return nil
+}
+
+// ClaimAlerts marks up to limit pending alerts as processed and returns
+// them. Concurrent callers never claim the same alert.
+func (s *StockNotificationStore) ClaimAlerts(ctx context.Context, limit int) ([]*StockAlert, error) {
+	query := `UPDATE stock_alerts a SET processed_at = CURRENT_TIMESTAMP
+	FROM books b
+	WHERE b.id = a.book_id AND a.id IN (
+		SELECT id FROM stock_alerts WHERE processed_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+	)
+	RETURNING a.id, a.book_id, b.title, b.author, a.kind, a.stock, a.created_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []*StockAlert
+	for rows.Next() {
+		a := &StockAlert{}
+		if err := rows.Scan(&a.ID, &a.BookID, &a.Title, &a.Author, &a.Kind, &a.Stock, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// ClaimSubscribers marks the book's pending subscriptions as notified and
+// returns their users. Nothing is claimed if the book sold out again in the
+// meantime, so those subscribers wait for the next restock.
+func (s *StockNotificationStore) ClaimSubscribers(ctx context.Context, bookID int) ([]*Recipient, error) {
+	query := `UPDATE stock_subscriptions s SET notified_at = CURRENT_TIMESTAMP
+	FROM users u
+	WHERE u.id = s.user_id AND s.book_id = $1 AND s.notified_at IS NULL
+	AND EXISTS (SELECT 1 FROM books WHERE id = $1 AND stock > 0)
+	RETURNING u.id, u.username, u.email`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return s.queryRecipients(ctx, query, bookID)
+}
+
+// GetStaff returns the active users of moderator level or above.
+func (s *StockNotificationStore) GetStaff(ctx context.Context) ([]*Recipient, error) {
+	query := `SELECT u.id, u.username, u.email FROM users u
+	JOIN roles r ON r.id = u.role_id
+	WHERE u.is_active AND r.level >= (SELECT level FROM roles WHERE name = 'moderator')
+	ORDER BY u.id`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return s.queryRecipients(ctx, query)
+}
+
+func (s *StockNotificationStore) queryRecipients(ctx context.Context, query string, args ...any) ([]*Recipient, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []*Recipient
+	for rows.Next() {
+		r := &Recipient{}
+		if err := rows.Scan(&r.UserID, &r.Username, &r.Email); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, rows.Err()
+}
+
+// recordStockAlerts queues the alerts a movement triggers: low stock when
+// the book drops below LowStockThreshold and back in stock when a sold out
+// book with subscribers is restocked. Transfers leave the total unchanged
+// and never alert.
+func recordStockAlerts(ctx context.Context, tx *sql.Tx, movement *StockMovement) error {
+	if movement.Kind == StockMovementTransfer {
+		return nil
+	}
+	before, after := movement.StockAfter-movement.Quantity, movement.StockAfter
+
+	if before >= LowStockThreshold && after < LowStockThreshold {
+		query := `INSERT INTO stock_alerts (book_id, kind, stock) VALUES ($1, $2, $3)`
+		if _, err := tx.ExecContext(ctx, query, movement.BookID, StockAlertLowStock, after); err != nil {
+			return err
+		}
+	}
+	if before <= 0 && after > 0 {
+		query := `INSERT INTO stock_alerts (book_id, kind, stock)
+		SELECT $1, $2, $3
+		WHERE EXISTS (SELECT 1 FROM stock_subscriptions WHERE book_id = $1 AND notified_at IS NULL)`
+		if _, err := tx.ExecContext(ctx, query, movement.BookID, StockAlertBackInStock, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnshippedAllocations(t *testing.T) {
+	tests := []struct {
+		name        string
+		allocations []itemAllocation
+		want        []itemAllocation
+	}{
+		{
+			name: "nothing shipped",
+			allocations: []itemAllocation{
+				{orderItemID: 1, bookID: 10, locationID: 1, quantity: 3},
+				{orderItemID: 1, bookID: 10, locationID: 2, quantity: 2},
+			},
+			want: []itemAllocation{
+				{orderItemID: 1, bookID: 10, locationID: 1, quantity: 3},
+				{orderItemID: 1, bookID: 10, locationID: 2, quantity: 2},
+			},
+		},
+		{
+			name: "partly shipped then cancelled",
+			allocations: []itemAllocation{
+				{orderItemID: 1, bookID: 10, locationID: 1, quantity: 3, shipped: 4},
+				{orderItemID: 1, bookID: 10, locationID: 2, quantity: 2, shipped: 4},
+				{orderItemID: 2, bookID: 20, locationID: 1, quantity: 2},
+			},
+			want: []itemAllocation{
+				{orderItemID: 1, bookID: 10, locationID: 2, quantity: 1, shipped: 4},
+				{orderItemID: 2, bookID: 20, locationID: 1, quantity: 2},
+			},
+		},
+		{
+			name: "fully shipped line is dropped",
+			allocations: []itemAllocation{
+				{orderItemID: 1, bookID: 10, locationID: 0, quantity: 2, shipped: 2},
+				{orderItemID: 2, bookID: 20, locationID: 0, quantity: 1, shipped: 0},
+			},
+			want: []itemAllocation{
+				{orderItemID: 2, bookID: 20, locationID: 0, quantity: 1},
+			},
+		},
+		{
+			name: "everything shipped",
+			allocations: []itemAllocation{
+				{orderItemID: 1, bookID: 10, locationID: 1, quantity: 1, shipped: 3},
+				{orderItemID: 1, bookID: 10, locationID: 2, quantity: 2, shipped: 3},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unshippedAllocations(tt.allocations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unshippedAllocations() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
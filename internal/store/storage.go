@@ -12,6 +12,7 @@ var (
 	ErrorNotFound        = errors.New("resource not found")
 	ErrDuplicateEmail    = errors.New("duplicate email")
 	ErrDuplicateUsername = errors.New("duplicate username")
+	ErrEmptyCart         = errors.New("cart is empty")
 )
 
 type Storage struct {
@@ -19,8 +20,14 @@ type Storage struct {
 		Create(context.Context, *Book) error
 		GetByID(context.Context, int) (*Book, error)
 		Update(context.Context, *Book) error
+		UpdateWithStock(ctx context.Context, book *Book, movement *StockMovement, level int) error
 		Delete(context.Context, int) error
-		SearchByBooks(ctx context.Context, filters BooksBySearchPayload) ([]*Book, error)
+		SearchByBooks(ctx context.Context, filters BooksBySearchPayload) (*BookSearchPage, error)
+		SearchFacets(ctx context.Context, filters BooksBySearchPayload) (*SearchFacets, error)
+		Suggest(ctx context.Context, text string, limit int) ([]*BookSuggestion, error)
+		SetSearchLanguage(ctx context.Context, language string) error
+		ReindexSearch(ctx context.Context, batchSize int) (int64, error)
+		Import(ctx context.Context, rows []*BookImportRow, opts BookImportOptions) error
 	}
 	Users interface {
 		Create(context.Context, *User) error
@@ -42,8 +49,14 @@ type Storage struct {
 	Orders interface {
 		GetByID(context.Context, int) (*Order, error)
 		Create(ctx context.Context, order *Order) error
+		CreateFromCart(ctx context.Context, order *Order, cartID int) error
+		Quote(context.Context, *Order) error
+		GetBooks(ctx context.Context, orderID int) (map[int]*Book, error)
+		List(ctx context.Context, filters OrderFilters) (*OrderPage, error)
 		Get(ctx context.Context, userID int) ([]Order, error)
 		Update(context.Context, *Order) error
+		UpdateStatus(ctx context.Context, order *Order, status string, changedBy int, note string) error
+		GetStatusHistory(ctx context.Context, orderID int) ([]OrderStatusChange, error)
 	}
 	Reviews interface {
 		Create(context.Context, *Review) error
@@ -55,28 +68,142 @@ type Storage struct {
 	Carts interface {
 		GetOrCreateCart(ctx context.Context, userID int) (*Cart, error)
 		GetCartItem(ctx context.Context, cartID int) (*CartItem, error)
-		InsertOrUpdateCartItem(ctx context.Context, cartID, bookID, quantity int) error
+		InsertOrUpdateCartItem(ctx context.Context, cartID, bookID, quantity int, hold time.Duration) error
+		MoveFromWishlist(ctx context.Context, cartID, wishlistID, bookID int, hold time.Duration) error
 		GetCartItemsWithBooks(ctx context.Context, cartID int) ([]CartItemWithBook, error)
 		DeleteCartItem(context.Context, int, int) error
 		DeleteCart(context.Context, int) error
-		UpdateQuantity(ctx context.Context, quantity int, itemID, userID int) error
+		UpdateQuantity(ctx context.Context, quantity int, itemID, userID int, hold time.Duration) error
+		ReleaseExpiredReservations(context.Context) (int64, error)
+	}
+	Returns interface {
+		Create(context.Context, *ReturnRequest) error
+		GetByID(context.Context, int) (*ReturnRequest, error)
+		GetByOrderID(ctx context.Context, orderID int) ([]ReturnRequest, error)
+		List(ctx context.Context, status string) ([]ReturnRequest, error)
+		Review(ctx context.Context, ret *ReturnRequest, approve bool, reviewerID int, note string) error
+		Receive(ctx context.Context, ret *ReturnRequest, restock bool, refundAmount float64, actorID int) (*Refund, error)
+		CreateRefund(context.Context, *Refund) error
+		CompleteRefund(ctx context.Context, refund *Refund, sent bool) error
+		GetRefunds(ctx context.Context, orderID int) ([]Refund, error)
+	}
+	Payments interface {
+		Create(context.Context, *Payment) error
+		GetByOrderID(ctx context.Context, orderID int) ([]Payment, error)
+		GetByReference(ctx context.Context, provider, reference string) (*Payment, error)
+		UpdateStatus(ctx context.Context, payment *Payment, status string, actorID int) error
+	}
+	IdempotencyKeys interface {
+		Reserve(ctx context.Context, key *IdempotencyKey, ttl time.Duration) (bool, error)
+		Get(ctx context.Context, userID int, key string) (*IdempotencyKey, error)
+		Complete(context.Context, *IdempotencyKey) error
+		Delete(context.Context, int) error
+		DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error)
+	}
+	Promotions interface {
+		Create(context.Context, *Promotion) error
+		GetByID(context.Context, int) (*Promotion, error)
+		List(context.Context) ([]*Promotion, error)
+		Update(context.Context, *Promotion) error
+		Delete(context.Context, int) error
+	}
+	ShippingRates interface {
+		Create(context.Context, *ShippingRate) error
+		GetByID(context.Context, int) (*ShippingRate, error)
+		List(context.Context) ([]*ShippingRate, error)
+		Update(context.Context, *ShippingRate) error
+		Delete(context.Context, int) error
+	}
+	TaxRates interface {
+		Create(context.Context, *TaxRate) error
+		GetByID(context.Context, int) (*TaxRate, error)
+		List(context.Context) ([]*TaxRate, error)
+		Update(context.Context, *TaxRate) error
+		Delete(context.Context, int) error
+	}
+	Addresses interface {
+		Create(context.Context, *Address) error
+		GetByID(ctx context.Context, userID, ID int) (*Address, error)
+		GetByUserID(ctx context.Context, userID int) ([]*Address, error)
+		Update(context.Context, *Address) error
+		Delete(ctx context.Context, userID, ID int) error
+	}
+	Shipments interface {
+		Create(context.Context, *Shipment) error
+		MarkDelivered(ctx context.Context, shipment *Shipment, actorID int) error
+		GetByID(context.Context, int) (*Shipment, error)
+		GetByOrderID(ctx context.Context, orderID int) ([]*Shipment, error)
+	}
+	Invoices interface {
+		GetOrIssue(ctx context.Context, orderID int) (*Invoice, error)
+	}
+	StockMovements interface {
+		Record(context.Context, *StockMovement) error
+		GetByBookID(ctx context.Context, bookID, beforeID, limit int) ([]*StockMovement, error)
+		Transfer(context.Context, *StockTransfer) error
+		GetTransfers(ctx context.Context, bookID int) ([]*StockTransfer, error)
+	}
+	StockNotifications interface {
+		Subscribe(context.Context, *StockSubscription) error
+		Unsubscribe(ctx context.Context, userID, bookID int) error
+		ClaimAlerts(ctx context.Context, limit int) ([]*StockAlert, error)
+		ClaimSubscribers(ctx context.Context, bookID int) ([]*Recipient, error)
+		GetStaff(context.Context) ([]*Recipient, error)
+	}
+	Locations interface {
+		Create(context.Context, *StockLocation) error
+		GetByID(context.Context, int) (*StockLocation, error)
+		List(context.Context) ([]*StockLocation, error)
+		Update(context.Context, *StockLocation) error
+		GetBookStock(ctx context.Context, bookID int) ([]*LocationStock, error)
+	}
+	Reports interface {
+		Revenue(ctx context.Context, period string, r ReportRange) ([]RevenuePoint, error)
+		TopBooks(ctx context.Context, rankBy string, limit int, r ReportRange) ([]TopBook, error)
+		TopAuthors(ctx context.Context, rankBy string, limit int, r ReportRange) ([]TopAuthor, error)
+		OrderValue(ctx context.Context, r ReportRange) (*OrderValueSummary, error)
+		StatusBreakdown(ctx context.Context, r ReportRange) ([]StatusCount, error)
+		LowStock(ctx context.Context, threshold int) ([]LowStockBook, error)
 	}
 	WishLists interface {
-		Create(ctx context.Context, wishlist *Wishlist) error
-		GetWishlistBooks(context.Context, int) ([]*Book, error)
-		Delete(ctx context.Context, userID int, BookID int) error
+		CreateList(ctx context.Context, wishlist *Wishlist) error
+		GetDefaultList(ctx context.Context, userID int) (*Wishlist, error)
+		GetListByID(ctx context.Context, wishlistID int) (*Wishlist, error)
+		GetListByToken(ctx context.Context, token string) (*Wishlist, error)
+		GetLists(ctx context.Context, userID int, publicOnly bool) ([]*Wishlist, error)
+		UpdateList(ctx context.Context, wishlist *Wishlist, newToken bool) error
+		DeleteList(ctx context.Context, wishlistID int) error
+		AddItem(ctx context.Context, item *WishlistItem) error
+		GetItems(ctx context.Context, wishlistID int) ([]*WishlistItem, error)
+		GetItem(ctx context.Context, wishlistID, bookID int) (*WishlistItem, error)
+		UpdateItem(ctx context.Context, item *WishlistItem) error
+		RemoveItem(ctx context.Context, wishlistID, bookID int) error
+		ClaimPriceAlerts(ctx context.Context, limit int) ([]*PriceAlert, error)
 	}
 }
 
 func NewStorage(db *sql.DB) Storage {
 	return Storage{
-		Books:     &BookStore{db},
-		Users:     &UserStore{db},
-		Roles:     &RoleStore{db},
-		Orders:    &OrderStore{db},
-		Reviews:   &ReviewStore{db},
-		Carts:     &CartStore{db},
-		WishLists: &WishlistStore{db},
+		Books:              &BookStore{db},
+		Users:              &UserStore{db},
+		Roles:              &RoleStore{db},
+		Orders:             &OrderStore{db},
+		Reviews:            &ReviewStore{db},
+		Carts:              &CartStore{db},
+		Returns:            &ReturnStore{db},
+		Payments:           &PaymentStore{db},
+		IdempotencyKeys:    &IdempotencyStore{db},
+		Promotions:         &PromotionStore{db},
+		ShippingRates:      &ShippingRateStore{db},
+		TaxRates:           &TaxRateStore{db},
+		Addresses:          &AddressStore{db},
+		Shipments:          &ShipmentStore{db},
+		Invoices:           &InvoiceStore{db},
+		StockMovements:     &StockStore{db},
+		Locations:          &LocationStore{db},
+		StockNotifications: &StockNotificationStore{db},
+		Reports:            &ReportStore{db},
+		WishLists:          &WishlistStore{db},
 	}
 }
 
@@ -3,46 +3,134 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/lib/pq"
 )
 
+const (
+	WishlistPrivate  = "private"
+	WishlistUnlisted = "unlisted"
+	WishlistPublic   = "public"
+
+	DefaultWishlistName = "My Wishlist"
+)
+
+var (
+	ErrDuplicateWishlistName = errors.New("a wishlist with this name already exists")
+	ErrDuplicateWishlistItem = errors.New("book is already on this wishlist")
+	ErrDefaultWishlist       = errors.New("the default wishlist cannot be deleted")
+)
+
+// Wishlist is a named list of books. Private lists are only visible to their
+// owner, unlisted ones to anybody with the share token and public ones are
+// also listed on the owner's profile. Every user has one default list, which
+// is created on first use.
 type Wishlist struct {
-	ID        int
-	BookID    int
-	UserID    int
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Name       string    `json:"name"`
+	Visibility string    `json:"visibility"`
+	ShareToken string    `json:"share_token"`
+	IsDefault  bool      `json:"is_default"`
+	ItemCount  int       `json:"item_count"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WishlistItem is one book on a wishlist. PriceAtAdd is the book's price
+// when it was wishlisted; with AlertEnabled the user is emailed when the
+// price drops to TargetPrice, or below PriceAtAdd if there is no target.
+type WishlistItem struct {
+	ID           int       `json:"id"`
+	WishlistID   int       `json:"wishlist_id"`
+	BookID       int       `json:"book_id"`
+	UserID       int       `json:"user_id"`
+	Note         string    `json:"note,omitempty"`
+	Priority     int       `json:"priority"`
+	PriceAtAdd   float32   `json:"price_at_add"`
+	AlertEnabled bool      `json:"price_alert"`
+	TargetPrice  *float32  `json:"target_price"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Book         *Book     `json:"book,omitempty"`
 }
+
 type WishlistStore struct {
 	db *sql.DB
 }
 
-func (s *WishlistStore) Create(ctx context.Context, wishlist *Wishlist) error {
-	query := `INSERT INTO wishlists(book_id , user_id) VALUES($1 , $2) RETURNING id , created_at , updated_at`
+func (s *WishlistStore) CreateList(ctx context.Context, wishlist *Wishlist) error {
+	query := `INSERT INTO wishlists (user_id, name, visibility) VALUES ($1, $2, $3)
+	RETURNING id, share_token, is_default, created_at, updated_at`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
 
-	err := s.db.QueryRowContext(ctx, query, wishlist.BookID, wishlist.UserID).Scan(
+	err := s.db.QueryRowContext(ctx, query, wishlist.UserID, wishlist.Name, wishlist.Visibility).Scan(
 		&wishlist.ID,
+		&wishlist.ShareToken,
+		&wishlist.IsDefault,
 		&wishlist.CreatedAt,
 		&wishlist.UpdatedAt,
 	)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicateWishlistName
+		}
 		return err
 	}
 	return nil
 }
 
-func (s *WishlistStore) GetWishlistBooks(ctx context.Context, userID int) ([]*Book, error) {
-	query := `SELECT b.id, b.title, b.author, b.isbn, b.description, b.price, b.stock, b.tags, b.pages, b.cover_image_url FROM books b JOIN wishlists w ON w.book_id = b.id
-	WHERE w.user_id = $1 ORDER BY w.created_at DESC`
+// GetDefaultList returns the user's default wishlist, creating it first if
+// the user has none yet.
+func (s *WishlistStore) GetDefaultList(ctx context.Context, userID int) (*Wishlist, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	query := `INSERT INTO wishlists (user_id, name, is_default) VALUES ($1, $2, TRUE) ON CONFLICT DO NOTHING`
+	if _, err := s.db.ExecContext(ctx, query, userID, DefaultWishlistName); err != nil {
+		return nil, err
+	}
+	return s.getList(ctx, `w.user_id = $1 AND w.is_default`, userID)
+}
+
+func (s *WishlistStore) GetListByID(ctx context.Context, wishlistID int) (*Wishlist, error) {
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
-	rows, err := s.db.QueryContext(ctx, query, userID)
 
+	return s.getList(ctx, `w.id = $1`, wishlistID)
+}
+
+// GetListByToken returns the shared wishlist with the token. Private lists
+// are not found, even with the right token.
+func (s *WishlistStore) GetListByToken(ctx context.Context, token string) (*Wishlist, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return s.getList(ctx, `w.share_token = $1 AND w.visibility <> 'private'`, token)
+}
+
+func (s *WishlistStore) getList(ctx context.Context, where string, args ...any) (*Wishlist, error) {
+	query := `SELECT w.id, w.user_id, w.name, w.visibility, w.share_token, w.is_default,
+	(SELECT COUNT(*) FROM wishlist_items i WHERE i.wishlist_id = w.id), w.created_at, w.updated_at
+	FROM wishlists w WHERE ` + where
+
+	wishlist := &Wishlist{}
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&wishlist.ID,
+		&wishlist.UserID,
+		&wishlist.Name,
+		&wishlist.Visibility,
+		&wishlist.ShareToken,
+		&wishlist.IsDefault,
+		&wishlist.ItemCount,
+		&wishlist.CreatedAt,
+		&wishlist.UpdatedAt,
+	)
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
@@ -51,42 +139,232 @@ func (s *WishlistStore) GetWishlistBooks(ctx context.Context, userID int) ([]*Bo
 			return nil, err
 		}
 	}
-	var books []*Book
+	return wishlist, nil
+}
+
+// GetLists returns the user's wishlists, the default one first. With
+// publicOnly only the lists shown on the user's profile are returned.
+func (s *WishlistStore) GetLists(ctx context.Context, userID int, publicOnly bool) ([]*Wishlist, error) {
+	query := `SELECT w.id, w.user_id, w.name, w.visibility, w.share_token, w.is_default,
+	(SELECT COUNT(*) FROM wishlist_items i WHERE i.wishlist_id = w.id), w.created_at, w.updated_at
+	FROM wishlists w
+	WHERE w.user_id = $1 AND (NOT $2 OR w.visibility = 'public')
+	ORDER BY w.is_default DESC, w.name`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, publicOnly)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
+	wishlists := []*Wishlist{}
 	for rows.Next() {
-		book := &Book{}
+		w := &Wishlist{}
+		err := rows.Scan(&w.ID, &w.UserID, &w.Name, &w.Visibility, &w.ShareToken, &w.IsDefault, &w.ItemCount, &w.CreatedAt, &w.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		wishlists = append(wishlists, w)
+	}
+	return wishlists, rows.Err()
+}
+
+// UpdateList saves the list's name and visibility. With newToken the share
+// token is replaced, so links handed out earlier stop working.
+func (s *WishlistStore) UpdateList(ctx context.Context, wishlist *Wishlist, newToken bool) error {
+	query := `UPDATE wishlists SET name = $1, visibility = $2, updated_at = CURRENT_TIMESTAMP,
+	share_token = CASE WHEN $3 THEN replace(gen_random_uuid()::text, '-', '') ELSE share_token END
+	WHERE id = $4 RETURNING share_token, updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, wishlist.Name, wishlist.Visibility, newToken, wishlist.ID).Scan(
+		&wishlist.ShareToken,
+		&wishlist.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrorNotFound
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return ErrDuplicateWishlistName
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteList deletes the wishlist with its items. The default list cannot
+// be deleted.
+func (s *WishlistStore) DeleteList(ctx context.Context, wishlistID int) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var isDefault bool
+		err := tx.QueryRowContext(ctx, `SELECT is_default FROM wishlists WHERE id = $1 FOR UPDATE`, wishlistID).Scan(&isDefault)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrorNotFound
+			default:
+				return err
+			}
+		}
+		if isDefault {
+			return ErrDefaultWishlist
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM wishlists WHERE id = $1`, wishlistID)
+		return err
+	})
+}
+
+// AddItem puts the book on the wishlist, remembering its current price.
+func (s *WishlistStore) AddItem(ctx context.Context, item *WishlistItem) error {
+	query := `INSERT INTO wishlist_items (wishlist_id, user_id, book_id, note, priority, price_at_add, alert_enabled, target_price)
+	SELECT w.id, w.user_id, b.id, NULLIF($3, ''), $4, b.price, $5, $6
+	FROM wishlists w, books b WHERE w.id = $1 AND b.id = $2
+	RETURNING id, user_id, price_at_add, created_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
 
+	err := s.db.QueryRowContext(ctx, query,
+		item.WishlistID,
+		item.BookID,
+		item.Note,
+		item.Priority,
+		item.AlertEnabled,
+		item.TargetPrice,
+	).Scan(
+		&item.ID,
+		&item.UserID,
+		&item.PriceAtAdd,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrorNotFound
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return ErrDuplicateWishlistItem
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// GetItems returns the wishlist's items with their books, highest priority
+// first.
+func (s *WishlistStore) GetItems(ctx context.Context, wishlistID int) ([]*WishlistItem, error) {
+	query := `SELECT i.id, i.wishlist_id, i.book_id, i.user_id, COALESCE(i.note, ''), i.priority, i.price_at_add,
+	i.alert_enabled, i.target_price, i.created_at, i.updated_at,
+	b.id, b.title, b.author, b.isbn, b.description, b.price, b.stock, b.tags, b.pages, b.cover_image_url
+	FROM wishlist_items i JOIN books b ON b.id = i.book_id
+	WHERE i.wishlist_id = $1
+	ORDER BY i.priority DESC, i.created_at DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*WishlistItem{}
+	for rows.Next() {
+		item := &WishlistItem{Book: &Book{}}
 		err := rows.Scan(
-			&book.ID,
-			&book.Title,
-			&book.Author,
-			&book.ISBN,
-			&book.Description,
-			&book.Price,
-			&book.Stock,
-			pq.Array(&book.Tags),
-			&book.Pages,
-			&book.CoverImageUrl,
+			&item.ID,
+			&item.WishlistID,
+			&item.BookID,
+			&item.UserID,
+			&item.Note,
+			&item.Priority,
+			&item.PriceAtAdd,
+			&item.AlertEnabled,
+			&item.TargetPrice,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&item.Book.ID,
+			&item.Book.Title,
+			&item.Book.Author,
+			&item.Book.ISBN,
+			&item.Book.Description,
+			&item.Book.Price,
+			&item.Book.Stock,
+			pq.Array(&item.Book.Tags),
+			&item.Book.Pages,
+			&item.Book.CoverImageUrl,
 		)
 		if err != nil {
 			return nil, err
 		}
-		books = append(books, book)
-	}
-	if rows.Err() != nil {
-		return nil, err
+		items = append(items, item)
 	}
-	return books, nil
+	return items, rows.Err()
 }
-func (s *WishlistStore) Delete(ctx context.Context, userID int, BookID int) error {
-	query := `DELETE FROM wishlists WHERE book_id = $1 AND user_id = $2`
+
+func (s *WishlistStore) GetItem(ctx context.Context, wishlistID, bookID int) (*WishlistItem, error) {
+	query := `SELECT id, wishlist_id, book_id, user_id, COALESCE(note, ''), priority, price_at_add,
+	alert_enabled, target_price, created_at, updated_at
+	FROM wishlist_items WHERE wishlist_id = $1 AND book_id = $2`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, query, BookID, userID)
+	item := &WishlistItem{}
+	err := s.db.QueryRowContext(ctx, query, wishlistID, bookID).Scan(
+		&item.ID,
+		&item.WishlistID,
+		&item.BookID,
+		&item.UserID,
+		&item.Note,
+		&item.Priority,
+		&item.PriceAtAdd,
+		&item.AlertEnabled,
+		&item.TargetPrice,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+// UpdateItem saves the item's note, priority and alert settings. Changing
+// the alert settings re-arms the alert, so the next qualifying price is
+// announced even if an earlier drop already was.
+func (s *WishlistStore) UpdateItem(ctx context.Context, item *WishlistItem) error {
+	query := `UPDATE wishlist_items SET note = NULLIF($1, ''), priority = $2, alert_enabled = $3, target_price = $4,
+	last_alerted_price = CASE
+		WHEN alert_enabled IS DISTINCT FROM $3 OR target_price IS DISTINCT FROM $4 THEN NULL
+		ELSE last_alerted_price
+	END,
+	updated_at = CURRENT_TIMESTAMP
+	WHERE id = $5 RETURNING updated_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
 
+	err := s.db.QueryRowContext(ctx, query, item.Note, item.Priority, item.AlertEnabled, item.TargetPrice, item.ID).Scan(&item.UpdatedAt)
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
@@ -95,5 +373,25 @@ func (s *WishlistStore) Delete(ctx context.Context, userID int, BookID int) erro
 			return err
 		}
 	}
-	return err
+	return nil
+}
+
+func (s *WishlistStore) RemoveItem(ctx context.Context, wishlistID, bookID int) error {
+	query := `DELETE FROM wishlist_items WHERE wishlist_id = $1 AND book_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, wishlistID, bookID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrorNotFound
+	}
+	return nil
 }